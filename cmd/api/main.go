@@ -2,23 +2,34 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"orders-service/internal/app/order"
+	"orders-service/internal/app/orderevents"
 	"orders-service/internal/app/orderformat"
 	"orders-service/internal/app/orderview"
+	"orders-service/internal/app/responsecache"
+	"orders-service/internal/app/tabstream"
+	"orders-service/internal/auth"
 	"orders-service/internal/db"
+	"orders-service/internal/health"
 	"orders-service/internal/legacy/address"
 	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
 	"orders-service/internal/repository/mysql"
 	"orders-service/internal/repository/redisactive"
 	"orders-service/internal/rest/orderhttp"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -40,6 +51,8 @@ func main() {
 		}
 	}()
 
+	metrics.RegisterDBStats("mysql", mysqlDB)
+
 	redisClient, err := db.NewRedisActiveOrders()
 	if err != nil {
 		logging.Error(context.Background(), "redis init error", err)
@@ -59,17 +72,68 @@ func main() {
 		logging.Error(context.Background(), "repository init error", err)
 		os.Exit(1)
 	}
+	statuses, err := loadStatusRegistry(mysqlDB)
+	if err != nil {
+		logging.Error(context.Background(), "status registry init error", err)
+		os.Exit(1)
+	}
+
+	translator := mysql.NewStatusTranslator(mysqlDB)
+	checker := newHealthChecker(mysqlDB, redisClient, translator)
+	warnings := newWarningCache()
+
+	// One repository for every reader, so they share the circuit breaker.
+	activeOrders := redisactive.NewActiveOrdersRepository(redisClient,
+		redisactive.WithCommandTimeout(envDuration("ORDER_REDIS_TIMEOUT", 0)),
+		redisactive.WithCircuitBreaker(
+			envInt("ORDER_REDIS_BREAKER_FAILURES", 0),
+			envDuration("ORDER_REDIS_BREAKER_COOLDOWN", 0),
+		),
+	)
 	service := order.NewService(
 		repo,
-		redisactive.NewActiveOrdersRepository(redisClient),
+		activeOrders,
 		orderformat.NewAddressResolver(address.NewParser()),
 		orderview.NewAssembler(
-			redisactive.NewActiveOrdersRepository(redisClient),
-			mysql.NewStatusTranslator(mysqlDB),
+			activeOrders,
+			translator,
 			mysql.NewShowOrderCodeProvider(mysqlDB),
+			orderview.WithStatusRegistry(statuses),
 		),
+		order.WithStatusRegistry(statuses),
+		order.WithStatusTranslator(translator),
+		order.WithWarningSettings(mysql.NewWarningSettingsProvider(mysqlDB)),
+		order.WithWarningCache(warnings),
 	)
-	handler := orderhttp.NewHandler(service)
+	stopWarningRefresh := startWarningRefresh(warnings)
+	defer stopWarningRefresh()
+	tabs := tabstream.NewHub(service, tabstream.WithRefreshInterval(envDuration("ORDER_STREAM_REFRESH", 0)))
+	cache := newResponseCache()
+	stopChangeFeed := startChangeFeed(redisClient, tabs, cache)
+	defer stopChangeFeed()
+	verifier, err := loadTokenVerifier()
+	if err != nil {
+		logging.Error(context.Background(), "auth init error", err)
+		os.Exit(1)
+	}
+	handlerOpts := []orderhttp.Option{
+		orderhttp.WithTabStream(tabs),
+		orderhttp.WithStreamHeartbeat(envDuration("ORDER_STREAM_HEARTBEAT", 0)),
+		orderhttp.WithExportRowLimit(envInt("ORDER_EXPORT_ROW_LIMIT", 0)),
+		orderhttp.WithHealth(checker),
+		orderhttp.WithResponseCache(cache),
+		orderhttp.WithWarningCache(warnings),
+		orderhttp.WithMaxDateRange(
+			envInt("ORDER_MAX_DATE_RANGE_DAYS", 0),
+			envTenantInts("ORDER_MAX_DATE_RANGE_DAYS_BY_TENANT"),
+		),
+	}
+	if verifier != nil {
+		handlerOpts = append(handlerOpts, orderhttp.WithAuthentication(verifier))
+	} else {
+		logging.Warn(context.Background(), "authentication disabled")
+	}
+	handler := orderhttp.NewHandler(service, handlerOpts...)
 
 	r := chi.NewRouter()
 	orderhttp.RegisterRoutes(r, handler)
@@ -83,6 +147,8 @@ func main() {
 		IdleTimeout:  60 * time.Second, // время простоя соединения
 	}
 
+	srv.RegisterOnShutdown(tabs.Close)
+
 	serverErrCh := make(chan error, 1)
 	go func() {
 		logging.Info(context.Background(), "server started", "port", port)
@@ -104,6 +170,14 @@ func main() {
 		}
 	}
 
+	// Fail readiness first and give the load balancer time to notice before
+	// the listener closes.
+	checker.Drain()
+	if drain := envDuration("ORDER_SHUTDOWN_DRAIN", 5*time.Second); drain > 0 {
+		logging.Info(context.Background(), "draining before shutdown", "delay", drain.String())
+		time.Sleep(drain)
+	}
+
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
@@ -117,3 +191,202 @@ func main() {
 	}
 
 }
+
+// loadStatusRegistry picks the status registry source: a JSON file from
+// ORDER_STATUS_REGISTRY_FILE, the tbl_order_status_group table when
+// ORDER_STATUS_REGISTRY_SOURCE=mysql, or the built-in defaults.
+func loadStatusRegistry(mysqlDB *sql.DB) (*order.StatusRegistry, error) {
+	if path := os.Getenv("ORDER_STATUS_REGISTRY_FILE"); path != "" {
+		return order.LoadStatusRegistryFile(path)
+	}
+
+	if os.Getenv("ORDER_STATUS_REGISTRY_SOURCE") == "mysql" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return mysql.NewStatusRegistryLoader(mysqlDB).Load(ctx)
+	}
+
+	return order.DefaultStatusRegistry(), nil
+}
+
+// newHealthChecker checks MySQL, Redis and the status translation query the
+// order views depend on. Translations are cached, so once the query succeeded
+// readiness only pings the databases. Redis is optional: without it the
+// service serves MySQL-only results, so it only degrades readiness.
+func newHealthChecker(mysqlDB *sql.DB, redisClient *redis.Client, translator *mysql.StatusTranslator) *health.Checker {
+	checker := health.NewChecker(health.WithTimeout(envDuration("HEALTH_CHECK_TIMEOUT", 0)))
+	checker.Add("mysql", mysqlDB.PingContext)
+	checker.AddOptional("redis", func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
+	checker.Add("status_translation", func(ctx context.Context) error {
+		_, err := translator.TranslateStatus(ctx, "", "New order")
+		return err
+	})
+	return checker
+}
+
+// loadTokenVerifier returns nil only when AUTH_DISABLED=1, a missing key set
+// is an error so the service never starts open by accident.
+func loadTokenVerifier() (*auth.Verifier, error) {
+	if os.Getenv("AUTH_DISABLED") == "1" {
+		return nil, nil
+	}
+
+	path := os.Getenv("AUTH_JWT_KEYS_FILE")
+	if path == "" {
+		return nil, errors.New("AUTH_JWT_KEYS_FILE is not set")
+	}
+	keys, err := auth.LoadKeySetFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewVerifier(keys,
+		auth.WithIssuer(os.Getenv("AUTH_JWT_ISSUER")),
+		auth.WithAudience(os.Getenv("AUTH_JWT_AUDIENCE")),
+		auth.WithLeeway(envDuration("AUTH_JWT_LEEWAY", 30*time.Second)),
+	), nil
+}
+
+// startChangeFeed publishes active order changes from Redis keyspace
+// notifications when ORDER_CHANGE_FEED=1 and refreshes the tab streams and
+// cached responses of the changed tenant. The response cache only serves
+// while the feed is up. ORDER_CHANGE_FEED_CONFIGURE=1 also enables the
+// notifications on the Redis server.
+func startChangeFeed(redisClient *redis.Client, tabs *tabstream.Hub, cache *responsecache.Cache) func() {
+	if !changeFeedEnabled() {
+		return func() {}
+	}
+
+	cache.Suspend()
+	opts := []redisactive.ChangeFeedOption{
+		redisactive.WithAvailability(func(available bool) {
+			if available {
+				cache.Resume()
+				return
+			}
+			cache.Suspend()
+			logging.Warn(context.Background(), "response cache suspended, the change feed is down")
+		}),
+	}
+	if os.Getenv("ORDER_CHANGE_FEED_CONFIGURE") == "1" {
+		opts = append(opts, redisactive.WithNotificationConfig())
+	}
+
+	bus := orderevents.NewBus()
+	events, unsubscribe := bus.Subscribe(256)
+	go func() {
+		for event := range events {
+			tabs.Notify(event.TenantID)
+			cache.Invalidate(event.TenantID)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	feed := redisactive.NewChangeFeed(redisClient, bus, opts...)
+	go feed.Run(ctx)
+
+	return func() {
+		cancel()
+		unsubscribe()
+	}
+}
+
+// changeFeedEnabled reports whether ORDER_CHANGE_FEED=1 turns the change feed
+// on.
+func changeFeedEnabled() bool {
+	return os.Getenv("ORDER_CHANGE_FEED") == "1"
+}
+
+// newResponseCache caches POST /orders for ORDER_CACHE_TTL_ORDERS and POST
+// /orders/all for ORDER_CACHE_TTL_ALL_ORDERS; a route without a TTL is not
+// cached. Only the change feed invalidates cached responses, so nothing is
+// cached without it and startChangeFeed suspends the cache while it is down.
+func newResponseCache() *responsecache.Cache {
+	ordersTTL := envDuration("ORDER_CACHE_TTL_ORDERS", 0)
+	allOrdersTTL := envDuration("ORDER_CACHE_TTL_ALL_ORDERS", 0)
+	if !changeFeedEnabled() {
+		if ordersTTL > 0 || allOrdersTTL > 0 {
+			logging.Warn(context.Background(), "response cache disabled, it needs ORDER_CHANGE_FEED=1")
+		}
+		return nil
+	}
+
+	return responsecache.New(
+		responsecache.WithRouteTTL("/orders", ordersTTL),
+		responsecache.WithRouteTTL("/orders/all", allOrdersTTL),
+		responsecache.WithMaxEntries(envInt("ORDER_CACHE_MAX_ENTRIES", 0)),
+	)
+}
+
+// newWarningCache keeps the warning sets when ORDER_WARNING_REFRESH sets the
+// refresh interval. ORDER_WARNING_MAX_STALE caps the age of a set served
+// while it is recomputed, ORDER_WARNING_IDLE_TIMEOUT drops sets nobody reads
+// and ORDER_WARNING_LOAD_TIMEOUT bounds one computation.
+func newWarningCache() *order.WarningCache {
+	interval := envDuration("ORDER_WARNING_REFRESH", 0)
+	if interval <= 0 {
+		return nil
+	}
+	return order.NewWarningCache(
+		order.WithWarningRefreshInterval(interval),
+		order.WithWarningMaxStale(envDuration("ORDER_WARNING_MAX_STALE", 0)),
+		order.WithWarningIdleTimeout(envDuration("ORDER_WARNING_IDLE_TIMEOUT", 0)),
+		order.WithWarningLoadTimeout(envDuration("ORDER_WARNING_LOAD_TIMEOUT", 0)),
+	)
+}
+
+// startWarningRefresh runs the background refresh of the warning cache until
+// the returned stop is called.
+func startWarningRefresh(warnings *order.WarningCache) func() {
+	if warnings == nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go warnings.Run(ctx)
+	return cancel
+}
+
+// envDuration reads a duration like "5s" from the environment. Missing or
+// invalid values give fallback.
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// envInt reads an integer from the environment. Missing or invalid values
+// give fallback.
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// envTenantInts reads per tenant values like "68=92,70=7" from the
+// environment. Malformed entries are skipped.
+func envTenantInts(name string) map[int64]int {
+	values := make(map[int64]int)
+	for _, entry := range strings.Split(os.Getenv(name), ",") {
+		rawTenant, rawValue, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		tenantID, err := strconv.ParseInt(rawTenant, 10, 64)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.Atoi(rawValue)
+		if err != nil {
+			continue
+		}
+		values[tenantID] = value
+	}
+	return values
+}
@@ -110,6 +110,11 @@ type FormattedOrder struct {
 	Tariff      TariffDTO   `json:"tariff"`
 	Options     []OptionDTO `json:"options"`
 	Currency    CurrencyDTO `json:"currency"`
+
+	Warnings []WarningReason `json:"warnings,omitempty"`
+	// WaitTime is the worker waiting time of the active order payload in
+	// seconds, whatever the status. WorkerWaitingTime tells when it applies.
+	WaitTime int64 `json:"wait_time,omitempty"`
 }
 
 type StatusDTO struct {
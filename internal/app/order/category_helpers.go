@@ -8,68 +8,54 @@ func toSet(values []int64) map[int64]struct{} {
 	return set
 }
 
-var categories = []struct {
-	Name     string
-	Statuses map[int64]struct{}
-}{
-	{
-		Name: "new",
-		Statuses: toSet([]int64{
-			1, 2, 3, 4, 5, 52, 108, 109, 115, 127, 128, 130, 131, 136,
-		}),
+// defaultStatusRegistryConfig is used when no registry file or table is
+// configured. Status 136 is an order in progress, so it belongs to "works".
+var defaultStatusRegistryConfig = StatusRegistryConfig{
+	Categories: []StatusCategoryConfig{
+		{
+			Name: "new",
+			Statuses: []int64{
+				1, 2, 3, 4, 5, 52, 108, 109, 115, 127, 128, 130, 131,
+			},
+		},
+		{
+			Name: "works",
+			Statuses: []int64{
+				17, 26, 27, 29, 30, 36, 54, 55, 106, 110, 113, 114, 132, 133, 134, 135, 136,
+			},
+		},
+		{
+			Name: "warning",
+			Statuses: []int64{
+				5, 10, 16, 27, 30, 38, 45, 46, 47, 48, 52, 54, 117, 118, 129, 135,
+			},
+		},
+		{
+			Name: "pre_order",
+			Statuses: []int64{
+				6, 7, 16, 111, 112, 116, 117, 118, 119,
+			},
+		},
+		{
+			Name: "completed",
+			Statuses: []int64{
+				37, 38,
+			},
+		},
+		{
+			Name: "rejected",
+			Statuses: []int64{
+				39, 40, 41, 42, 43, 44, 45, 46, 47, 48,
+				49, 50, 51, 107, 120, 121, 122, 123, 124, 125, 126,
+				129, 137, 138, 139, 140, 144, 145,
+			},
+		},
 	},
-	{
-		Name: "works",
-		Statuses: toSet([]int64{
-			17, 26, 27, 29, 30, 36, 54, 55, 106, 110, 113, 114, 132, 133, 134, 135,
-		}),
+	RedStatuses: []int64{
+		10, 16, 27, 30, 38,
+		39, 52, 54, 117, 118,
+		120, 135,
 	},
-	{
-		Name: "warning",
-		Statuses: toSet([]int64{
-			5, 10, 16, 27, 30, 38, 45, 46, 47, 48, 52, 54, 117, 118, 129, 135,
-		}),
-	},
-	{
-		Name: "pre_order",
-		Statuses: toSet([]int64{
-			6, 7, 16, 111, 112, 116, 117, 118, 119,
-		}),
-	},
-	{
-		Name: "completed",
-		Statuses: toSet([]int64{
-			37, 38,
-		}),
-	},
-	{
-		Name: "rejected",
-		Statuses: toSet([]int64{
-			39, 40, 41, 42, 43, 44, 45, 46, 47, 48,
-			49, 50, 51, 107, 120, 121, 122, 123, 124, 125, 126,
-			129, 137, 138, 139, 140, 144, 145,
-		}),
-	},
-}
-
-func statusBelongsToGroup(statusID int64, group string) bool {
-	for _, c := range categories {
-		if c.Name != group {
-			continue
-		}
-		_, ok := c.Statuses[statusID]
-		return ok
-	}
-	return false
-}
-
-func GetCategory(statusID int64) string {
-	for _, c := range categories {
-		if _, ok := c.Statuses[statusID]; ok {
-			return c.Name
-		}
-	}
-	return ""
 }
 
 const (
@@ -0,0 +1,134 @@
+package order
+
+import (
+	"math"
+	"time"
+)
+
+// FilterDateLayouts are the accepted formats of the date filter.
+var FilterDateLayouts = []string{"2006-01-02", "02.01.2006", "02.01.2006 15:04:05", "2006-01-02 15:04:05"}
+
+// ParseFilterDate parses the date filter in any of FilterDateLayouts.
+func ParseFilterDate(value string) (time.Time, bool) {
+	for _, layout := range FilterDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// DateRange is an inclusive range of unix timestamps.
+type DateRange struct {
+	From int64
+	To   int64
+}
+
+func (r DateRange) Contains(timestamp int64) bool {
+	return timestamp >= r.From && timestamp <= r.To
+}
+
+// OrderDateRange returns the day of the date filter as a range of order_time
+// values. It is the only date range of the order lists, MySQL and Redis
+// orders are filtered with the same bounds.
+//
+// order_time holds the wall clock of the order city encoded as UTC, the real
+// moment is order_time - time_offset (see OrderView.OrderTime). A day in the
+// city's time zone is therefore the UTC day of order_time, whatever the time
+// zone of the server or the offset of the city.
+//
+// A missing date matches everything; a date that cannot be parsed yields an
+// empty range so that no order matches.
+func OrderDateRange(date *string) DateRange {
+	if date == nil || *date == "" {
+		return DateRange{From: math.MinInt64, To: math.MaxInt64}
+	}
+
+	parsed, ok := ParseFilterDate(*date)
+	if !ok {
+		return DateRange{From: 0, To: -1}
+	}
+	from := time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC).Unix()
+	return DateRange{From: from, To: from + 24*60*60 - 1}
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// ParseClock parses a time of day like "20:00" into seconds since midnight.
+func ParseClock(value string) (int64, bool) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return int64(parsed.Hour()*3600 + parsed.Minute()*60), true
+}
+
+// DatePeriod is the date_from/date_to filter of the all-orders search with an
+// optional time of day window. All bounds are wall clock values like
+// order_time, create and finish time are compared after LocalTime.
+type DatePeriod struct {
+	// From and To are the first and last second of the period.
+	From int64
+	To   int64
+	// WindowFrom and WindowTo are seconds since midnight. The window starts
+	// at WindowFrom and ends before WindowTo; a window like 20:00-08:00
+	// crosses midnight. Equal values mean the whole day.
+	WindowFrom int64
+	WindowTo   int64
+}
+
+// NewDatePeriod covers the days from through to, both inclusive.
+func NewDatePeriod(from, to time.Time) DatePeriod {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC).Unix()
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC).Unix()
+	return DatePeriod{From: start, To: end + secondsPerDay - 1}
+}
+
+// Days is the number of calendar days of the period.
+func (p DatePeriod) Days() int {
+	return int((p.To-p.From)/secondsPerDay) + 1
+}
+
+func (p DatePeriod) HasWindow() bool {
+	return p.WindowFrom != p.WindowTo
+}
+
+// CrossesMidnight reports a window like 20:00-08:00.
+func (p DatePeriod) CrossesMidnight() bool {
+	return p.WindowFrom > p.WindowTo
+}
+
+// Contains reports whether the wall clock timestamp falls into the period and
+// its window.
+func (p DatePeriod) Contains(local int64) bool {
+	if local < p.From || local > p.To {
+		return false
+	}
+	if !p.HasWindow() {
+		return true
+	}
+
+	clock := local % secondsPerDay
+	if clock < 0 {
+		clock += secondsPerDay
+	}
+	if p.CrossesMidnight() {
+		return clock >= p.WindowFrom || clock < p.WindowTo
+	}
+	return clock >= p.WindowFrom && clock < p.WindowTo
+}
+
+// MatchesOrder reports whether the order was created, is ordered for or was
+// finished within the period.
+func (p DatePeriod) MatchesOrder(o FormattedOrder) bool {
+	if p.Contains(o.OrderTime) || p.Contains(LocalTime(o.CreateTime, o.TimeOffset)) {
+		return true
+	}
+	return o.FinishTime != nil && *o.FinishTime > 0 && p.Contains(LocalTime(*o.FinishTime, o.TimeOffset))
+}
+
+// LocalTime converts a real unix timestamp like create_time to the wall clock
+// encoding of order_time for an order with the given time_offset.
+func LocalTime(timestamp, timeOffset int64) int64 {
+	return timestamp + timeOffset
+}
@@ -0,0 +1,62 @@
+package order
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
+)
+
+// Components a response can be served without when Redis fails.
+const (
+	DegradedActiveOrders = "active_orders"
+	DegradedWaitTimes    = "wait_times"
+)
+
+type degradationKey struct{}
+
+type degradation struct {
+	mu         sync.Mutex
+	components []string
+}
+
+// WithDegradation returns a context that records the components a request
+// was served without, Degraded reads them back.
+func WithDegradation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, degradationKey{}, &degradation{})
+}
+
+// Degraded returns the components the request of ctx was served without, in
+// the order they failed.
+func Degraded(ctx context.Context) []string {
+	d, ok := ctx.Value(degradationKey{}).(*degradation)
+	if !ok {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.components)
+}
+
+// Degrade reports whether a failure of component may be served around. A
+// request that was cancelled or timed out is not degraded, it failed. When
+// it may, the component is recorded on ctx.
+func Degrade(ctx context.Context, component string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	logging.Warn(ctx, "serving without "+component, "error", err.Error())
+	metrics.DegradedResponses.Inc(component)
+
+	if d, ok := ctx.Value(degradationKey{}).(*degradation); ok {
+		d.mu.Lock()
+		if !slices.Contains(d.components, component) {
+			d.components = append(d.components, component)
+		}
+		d.mu.Unlock()
+	}
+	return true
+}
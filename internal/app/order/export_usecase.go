@@ -0,0 +1,76 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"orders-service/internal/logging"
+)
+
+const exportBatchSize = 500
+
+// ExportAllOrders walks the all-orders search in keyset mode and hands every
+// page to emit, so the caller can stream it without holding the whole result.
+// Sorts a cursor cannot page are walked page by page instead. Pagination of f
+// is ignored; at most limit orders are emitted when limit is positive. emit
+// is called at least once, also for an empty result.
+func (s *service) ExportAllOrders(
+	ctx context.Context,
+	f GetAllOrdersFilter,
+	limit int,
+	emit func(page GetAllOrdersResult) error,
+) error {
+	totalStarted := time.Now()
+	f.Page = 0
+	f.Cursor = nil
+	_, _, keyset := CursorSort(GetAllSort(f.BaseFilter))
+
+	exported := 0
+	pages := 0
+	for {
+		f.PageSize = exportBatchSize
+		if keyset && limit > 0 && limit-exported < f.PageSize {
+			f.PageSize = limit - exported
+		}
+
+		page, err := s.GetAllOrders(ctx, f)
+		if err != nil {
+			return err
+		}
+		fullPage := len(page.Orders) >= f.PageSize
+		if limit > 0 && exported+len(page.Orders) > limit {
+			page.Orders = page.Orders[:limit-exported]
+		}
+		if err := emit(page); err != nil {
+			return err
+		}
+		exported += len(page.Orders)
+		pages++
+
+		if limit > 0 && exported >= limit {
+			break
+		}
+		if keyset {
+			if page.NextCursor == nil {
+				break
+			}
+			f.Cursor = page.NextCursor
+			continue
+		}
+		if !fullPage {
+			break
+		}
+		f.Page++
+	}
+
+	observeStage("export", "total", totalStarted)
+	logging.Info(ctx, "export all orders done",
+		"tenant_id", f.TenantID,
+		"pages", pages,
+		"keyset", keyset,
+		"exported_count", exported,
+		"limit", limit,
+	)
+
+	return nil
+}
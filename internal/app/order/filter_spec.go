@@ -0,0 +1,191 @@
+package order
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FilterSpec is the filter of the all-orders search as both sources apply
+// it: MySQL translates every condition to SQL, Redis orders are matched
+// with Matches. A search returns the same orders wherever they live as long
+// as both sides read the spec and nothing else.
+type FilterSpec struct {
+	OrderIDs         []int64
+	ExcludedOrderIDs []int64
+
+	Status  []int64
+	CityIDs []int64
+	ShopIDs []int64
+	Tariffs []int64
+	// PositionIDs are the positions the caller may see.
+	PositionIDs []int64
+	// OrderTime is the day of the date filter, nil without one.
+	OrderTime *DateRange
+	Period    *DatePeriod
+	Searches  []SearchTerm
+}
+
+// SearchTerm is one word of a search attribute. Every term must match, a
+// term matches when one field of its attribute contains the word.
+type SearchTerm struct {
+	Attribute string
+	Word      string
+}
+
+// SearchField is an order value a search attribute looks into. Field names
+// the FormattedOrder field like the order field table does.
+type SearchField struct {
+	Field string
+	// Phone values and words are compared without the formatting
+	// characters of phone numbers.
+	Phone bool
+	value func(o FormattedOrder) string
+}
+
+// searchAttributeFields is the predicate set of the search attributes.
+var searchAttributeFields = map[string][]SearchField{
+	"number": {
+		{Field: "OrderNumber", value: func(o FormattedOrder) string { return strconv.FormatInt(o.OrderNumber, 10) }},
+		{Field: "OrderCode", value: func(o FormattedOrder) string { return o.OrderCode }},
+	},
+	"address": {
+		{Field: "Address", value: func(o FormattedOrder) string {
+			parts := make([]string, 0, len(o.Address))
+			for _, address := range o.Address {
+				parts = append(parts, joinAddress(address))
+			}
+			return strings.Join(parts, "\n")
+		}},
+	},
+	"comment": {
+		{Field: "Comment", value: func(o FormattedOrder) string { return stringValue(o.Comment) }},
+	},
+	"client": {
+		{Field: "Client.LastName", value: func(o FormattedOrder) string { return stringValue(o.Client.LastName) }},
+		{Field: "Client.Name", value: func(o FormattedOrder) string { return stringValue(o.Client.Name) }},
+		{Field: "Client.SecondName", value: func(o FormattedOrder) string { return stringValue(o.Client.SecondName) }},
+		{Field: "Client.Phone", Phone: true, value: func(o FormattedOrder) string { return stringValue(o.Client.Phone) }},
+	},
+	"worker": {
+		{Field: "Worker.LastName", value: func(o FormattedOrder) string { return stringValue(o.Worker.LastName) }},
+		{Field: "Worker.Name", value: func(o FormattedOrder) string { return stringValue(o.Worker.Name) }},
+		{Field: "Worker.SecondName", value: func(o FormattedOrder) string { return stringValue(o.Worker.SecondName) }},
+		{Field: "Worker.Callsign", value: func(o FormattedOrder) string { return derefInt64(o.Worker.Callsign) }},
+		{Field: "Car.GosNumber", value: func(o FormattedOrder) string { return stringValue(o.Car.GosNumber) }},
+	},
+}
+
+// SearchAttributeFields returns the fields of a search attribute, nil for
+// attributes the search does not know.
+func SearchAttributeFields(attribute string) []SearchField {
+	return searchAttributeFields[attribute]
+}
+
+// Needle returns the form of word the field compares, empty when the field
+// cannot match it.
+func (f SearchField) Needle(word string) string {
+	needle := strings.ToLower(word)
+	if f.Phone {
+		needle = normalizePhone(needle)
+	}
+	return needle
+}
+
+func (f SearchField) matches(o FormattedOrder, word string) bool {
+	needle := f.Needle(word)
+	if needle == "" {
+		return false
+	}
+	value := strings.ToLower(f.value(o))
+	if f.Phone {
+		value = normalizePhone(value)
+	}
+	return strings.Contains(value, needle)
+}
+
+// GetAllFilterSpec builds the spec of an all-orders filter whose Status is
+// already resolved from the search status. The client and worker search
+// strings are searched like the attributes of the same name.
+func GetAllFilterSpec(f GetAllOrdersFilter) FilterSpec {
+	spec := FilterSpec{
+		OrderIDs:         f.OrderIDs,
+		ExcludedOrderIDs: f.ExcludedOrderIDs,
+
+		Status:  f.Status,
+		CityIDs: f.CityIDs,
+		ShopIDs: f.ShopIDs,
+		Tariffs: f.Tariffs,
+		Period:  f.Period,
+
+		PositionIDs: f.UserPositions,
+	}
+	if f.Date != nil && *f.Date != "" {
+		dateRange := OrderDateRange(f.Date)
+		spec.OrderTime = &dateRange
+	}
+
+	addSearch := func(attribute, search string) {
+		if SearchAttributeFields(attribute) == nil {
+			return
+		}
+		for _, word := range strings.Fields(search) {
+			spec.Searches = append(spec.Searches, SearchTerm{Attribute: attribute, Word: word})
+		}
+	}
+	for _, attribute := range f.Attributes {
+		addSearch(attribute.Attribute, attribute.SearchString)
+	}
+	for _, attribute := range []string{"client", "worker"} {
+		addSearch(attribute, f.SearchString[attribute])
+	}
+
+	return spec
+}
+
+// Matches reports whether the spec keeps o.
+func (s FilterSpec) Matches(o FormattedOrder) bool {
+	if len(s.OrderIDs) > 0 && !containsInt64(s.OrderIDs, o.OrderID) {
+		return false
+	}
+	if containsInt64(s.ExcludedOrderIDs, o.OrderID) {
+		return false
+	}
+	if len(s.Status) > 0 && !containsInt64(s.Status, o.StatusID) {
+		return false
+	}
+	if len(s.CityIDs) > 0 && !containsInt64(s.CityIDs, o.CityID) {
+		return false
+	}
+	if len(s.ShopIDs) > 0 && (o.ShopID == nil || !containsInt64(s.ShopIDs, *o.ShopID)) {
+		return false
+	}
+	if len(s.Tariffs) > 0 && !containsInt64(s.Tariffs, o.TariffID) {
+		return false
+	}
+	if len(s.PositionIDs) > 0 && !containsInt64(s.PositionIDs, o.PositionID) {
+		return false
+	}
+	if s.OrderTime != nil && !s.OrderTime.Contains(o.OrderTime) {
+		return false
+	}
+	if s.Period != nil && !s.Period.MatchesOrder(o) {
+		return false
+	}
+	for _, term := range s.Searches {
+		if !matchesAttribute(o, term.Attribute, term.Word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesAttribute reports whether one field of attribute contains word.
+func matchesAttribute(o FormattedOrder, attribute, word string) bool {
+	for _, field := range SearchAttributeFields(attribute) {
+		if field.matches(o, word) {
+			return true
+		}
+	}
+	return false
+}
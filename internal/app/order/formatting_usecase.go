@@ -12,125 +12,20 @@ func (s *service) MapFullOrderToFormatted(
 	options []OptionDTO,
 	address []AddressView,
 ) FormattedOrder {
-	predvPrice := 0.0
+	formatted := FormatOrderRow(o.Row())
+	formatted.Address = address
+	formatted.Options = options
+
+	// The detail cost of MySQL outranks the estimate; Redis carries the
+	// estimate only.
+	formatted.PredvPrice = 0
 	if o.SummaryCost.Valid && o.SummaryCost.String != "" {
-		predvPrice = parseFloat(o.SummaryCost.String)
+		formatted.PredvPrice = parseFloat(o.SummaryCost.String)
 	} else if o.PredvPrice.Valid {
-		predvPrice = o.PredvPrice.Float64
+		formatted.PredvPrice = o.PredvPrice.Float64
 	}
 
-	return FormattedOrder{
-		OrderID:      o.OrderID,
-		TenantID:     o.TenantID,
-		WorkerID:     nullableInt64(o.WorkerID),
-		CarID:        nullableInt64(o.CarID),
-		CityID:       o.CityID.Int64,
-		TariffID:     o.TariffID,
-		UserCreate:   o.UserCreate.Int64,
-		StatusID:     o.StatusID,
-		UserModified: o.UserModified.Int64,
-		CompanyID:    nullableInt64(o.CompanyID),
-		ParkingID:    nullableInt64(o.ParkingID),
-		Address:      address,
-		Comment:      nullableString(o.Comment),
-
-		PredvPrice:           predvPrice,
-		PredvPriceNoDiscount: o.PredvPriceNoDiscount.Float64,
-		Device:               o.Device.String,
-		OrderNumber:          o.OrderNumber,
-		Payment:              o.Payment.String,
-		ShowPhone:            o.ShowPhone.Int64,
-		CreateTime:           o.CreateTime.Int64,
-		StatusTime:           o.StatusTime,
-		TimeToClient:         nullableInt64(o.TimeToClient),
-		ClientDeviceToken:    nullableString(o.ClientDeviceToken),
-		AppID:                nullableInt64(o.AppID),
-		OrderTime:            o.OrderTime.Int64,
-		PredvDistance:        o.PredvDistance.Float64,
-		PredvTime:            o.PredvTime.Int64,
-		CallWarningID:        nullableInt64(o.CallWarningID),
-		Phone:                o.Phone.String,
-		ClientID:             o.ClientID,
-		BonusPayment:         o.BonusPayment.Int64,
-		CurrencyID:           o.CurrencyID,
-		TimeOffset:           o.TimeOffset.Int64,
-		IsFix:                o.IsFix,
-		UpdateTime:           o.UpdateTime.Int64,
-		DenyRefuseOrder:      o.DenyRefuseOrder.Int64,
-		PositionID:           o.PositionID,
-		PromoCodeID:          nullableInt64(o.PromoCodeID),
-		TenantCompanyID:      nullableInt64(o.TenantCompanyID),
-		Mark:                 o.Mark.Int64,
-
-		ProcessedExchangeProgramID: nullableInt64(o.ProcessedExchangeProgramID),
-		ClientPassengerID:          nullableInt64(o.ClientPassengerID),
-		ClientPassengerPhone:       nullableString(o.ClientPassengerPhone),
-		Active:                     o.Active.Int64,
-		IsPreOrder:                 o.IsPreOrder.Int64,
-		AppVersion:                 nullableString(o.AppVersion),
-		AgentCommission:            o.AgentCommission.Float64,
-		IsFixByDispatcher:          o.IsFixByDispatcher.Int64,
-		FinishTime:                 nullableInt64(o.FinishTime),
-		CommentForDispatcher:       nullableString(o.CommentForDispatcher),
-		WorkerManualSurcharge:      o.WorkerManualSurcharge.Float64,
-		RealtimePrice:              nullableFloat64(o.RealtimePrice),
-		UnitQuantity:               nullableFloat64(o.UnitQuantity),
-		ShopID:                     nullableInt64(o.ShopID),
-		RequirePrepayment:          o.RequirePrepayment.Int64,
-		OrderCode:                  o.OrderCode.String,
-		ClientOfferedPrice:         nullableFloat64(o.ClientOfferedPrice),
-		IdempotentKey:              o.IdempotentKey.String,
-		AdditionalTariffID:         nullableInt64(o.AdditionalTariffID),
-		InitialPrice:               nullableFloat64(o.InitialPrice),
-		TimeToOrder:                nullableInt64(o.TimeToOrder),
-		Sort:                       nullableInt64(o.Sort),
-		SummaryCost:                nullableString(o.SummaryCost),
-		SummaryCostNoDiscount:      nullableString(o.SummaryCostNoDiscount),
-		StatusStatusID:             o.StatusStatusID,
-		StatusName:                 o.StatusName,
-		Status:                     StatusDTO{StatusID: o.StatusStatusID, Name: o.StatusName},
-		Client: ClientDTO{
-			ClientID:   o.ClientClientID.Int64,
-			Phone:      nullableString(o.ClientPhone),
-			Name:       nullableString(o.ClientName),
-			LastName:   nullableString(o.ClientLastName),
-			SecondName: nullableString(o.ClientSecondName),
-		},
-		UserCreated: UserDTO{
-			UserID:     o.UserUserID.Int64,
-			Name:       o.UserName.String,
-			LastName:   o.UserLastName.String,
-			SecondName: nullableString(o.UserSecondName),
-		},
-		Worker: WorkerDTO{
-			WorkerID:   o.WorkerWorkerID.Int64,
-			Callsign:   nullableInt64(o.WorkerCallsign),
-			Name:       nullableString(o.WorkerName),
-			LastName:   nullableString(o.WorkerLastName),
-			SecondName: nullableString(o.WorkerSecondName),
-			Phone:      nullableString(o.WorkerPhone),
-		},
-		Car: CarDTO{
-			CarID:     o.CarCarID.Int64,
-			Name:      nullableString(o.CarName),
-			Color:     nullableInt64(o.CarColor),
-			GosNumber: nullableString(o.CarGosNumber),
-		},
-		Tariff: TariffDTO{
-			TariffID:          o.TariffTariffID.Int64,
-			TariffType:        o.TariffType.String,
-			Name:              o.TariffName.String,
-			QuantitativeTitle: o.TariffQuantitativeTitle.String,
-			PriceForUnit:      o.TariffPriceForUnit.Float64,
-			UnitName:          o.TariffUnitName.String,
-		},
-		Currency: CurrencyDTO{
-			Name:   o.CurrencyName.String,
-			Code:   o.CurrencyCode.String,
-			Symbol: o.CurrencySymbol.String,
-		},
-		Options: options,
-	}
+	return formatted
 }
 
 func parseFloat(v string) float64 {
@@ -173,23 +68,18 @@ func (s *service) GetFormattedOrdersByGroup(
 	var getOrdersMS int64
 	var addressResolveMS int64
 	var optionsFetchMS int64
-	var mapMS int64
 
 	started := time.Now()
-	count, orders, err := s.GetOrdersByGroup(ctx, f, page, pageSize)
-	getOrdersMS = time.Since(started).Milliseconds()
+	count, orders, reasons, err := s.GetOrdersByGroup(ctx, f, page, pageSize)
+	getOrdersMS = observeStage("formatted_orders", "get_orders", started)
 	if err != nil {
 		logging.Error(ctx, "refresh get formatted orders fetch failed", err, "duration_ms", getOrdersMS)
 		return 0, nil, err
 	}
 
 	if len(orders) == 0 {
-		logging.Info(ctx, "refresh get formatted orders timings",
-			"total_ms", time.Since(totalStarted).Milliseconds(),
-			"get_orders_ms", getOrdersMS,
-			"address_resolve_ms", addressResolveMS,
-			"options_fetch_ms", optionsFetchMS,
-			"map_ms", mapMS,
+		observeStage("formatted_orders", "total", totalStarted)
+		logging.Info(ctx, "refresh get formatted orders done",
 			"group", f.BaseFilter.Group,
 			"page", page,
 			"page_size", pageSize,
@@ -209,7 +99,7 @@ func (s *service) GetFormattedOrdersByGroup(
 	if s.addressResolver != nil {
 		started = time.Now()
 		resolved, err := s.addressResolver.ResolveAddresses(orders)
-		addressResolveMS = time.Since(started).Milliseconds()
+		addressResolveMS = observeStage("formatted_orders", "address_resolve", started)
 		if err != nil {
 			logging.Error(ctx, "refresh address resolve failed", err, "duration_ms", addressResolveMS)
 			return 0, nil, err
@@ -219,7 +109,7 @@ func (s *service) GetFormattedOrdersByGroup(
 
 	started = time.Now()
 	optionsMap, err := s.optionsReader.GetOptionsForOrders(ctx, orderIDs)
-	optionsFetchMS = time.Since(started).Milliseconds()
+	optionsFetchMS = observeStage("formatted_orders", "options_fetch", started)
 	if err != nil {
 		logging.Error(ctx, "refresh options fetch failed", err, "duration_ms", optionsFetchMS)
 		return 0, nil, err
@@ -227,14 +117,13 @@ func (s *service) GetFormattedOrdersByGroup(
 
 	started = time.Now()
 	formatted := s.MapOrders(orders, optionsMap, addressMap)
-	mapMS = time.Since(started).Milliseconds()
-
-	logging.Info(ctx, "refresh get formatted orders timings",
-		"total_ms", time.Since(totalStarted).Milliseconds(),
-		"get_orders_ms", getOrdersMS,
-		"address_resolve_ms", addressResolveMS,
-		"options_fetch_ms", optionsFetchMS,
-		"map_ms", mapMS,
+	for i := range formatted {
+		formatted[i].Warnings = reasons[formatted[i].OrderID]
+	}
+	observeStage("formatted_orders", "map", started)
+
+	observeStage("formatted_orders", "total", totalStarted)
+	logging.Info(ctx, "refresh get formatted orders done",
 		"group", f.BaseFilter.Group,
 		"page", page,
 		"page_size", pageSize,
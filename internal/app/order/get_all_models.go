@@ -14,10 +14,21 @@ type GetAllOrdersFilter struct {
 	Attributes   []SearchAttribute
 	SearchString map[string]string
 	ShopIDs      []int64
+	// Period matches orders created, ordered for or finished within it, on
+	// top of the single day of BaseFilter.Date.
+	Period *DatePeriod
+	// OrderIDs restricts and ExcludedOrderIDs drops orders; the service
+	// sets them to resolve orders both sources return.
+	OrderIDs         []int64
+	ExcludedOrderIDs []int64
+	// WaitTimes holds the wait times of the active orders by order id when
+	// the search sorts by wait time, MySQL has none of its own.
+	WaitTimes map[int64]int64
 }
 
 type GetAllOrdersResult struct {
 	OrderTotalCount int64
 	CountPerPage    int
 	Orders          []OrderView
+	NextCursor      *PageCursor
 }
@@ -3,6 +3,7 @@ package order
 import (
 	"context"
 	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
 	"sort"
 	"strconv"
 	"strings"
@@ -11,67 +12,141 @@ import (
 
 func (s *service) GetAllOrders(ctx context.Context, f GetAllOrdersFilter) (GetAllOrdersResult, error) {
 	totalStarted := time.Now()
+	var redisFetchMS int64
+	var mysqlCountMS int64
 	var mysqlFetchMS int64
 	var addressResolveMS int64
-	var mysqlMapMS int64
-	var redisFetchMS int64
-	var mergeFilterMS int64
-	var sortMS int64
-	var paginationMS int64
 	var optionsFetchMS int64
-	var optionsAssignMS int64
 	var prepareMS int64
+	var dedupeMS int64
 
-	mysqlOrders := []FullOrder{}
+	if f.Page < 0 {
+		f.Page = 0
+	}
+	if f.PageSize <= 0 {
+		f.PageSize = 50
+	}
+	offset := f.Page * f.PageSize
+
+	// Status filters of both sources come from the registry: f.Status holds the
+	// statuses of the search status, or nil when it does not filter at all.
+	statusIDs, filtered := s.statusRegistry().SearchStatusIDs(f.TenantID, f.SearchStatus)
+	if filtered && len(statusIDs) == 0 {
+		return GetAllOrdersResult{CountPerPage: f.PageSize, Orders: []OrderView{}}, nil
+	}
+	f.Status = statusIDs
+
+	// Only the active order payloads know wait times, a search sorted by
+	// them reads the payloads even when it lists no active order.
+	sortKeys := GetAllSort(f.BaseFilter)
+	sortsByWaitTime := HasSortField(sortKeys, SortFieldWaitTime)
+
+	redisFormatted := []FormattedOrder{}
 	var err error
-	if shouldFetchMySQLForGetAll(f.SearchStatus) {
+	if s.activeOrdersReader != nil && (shouldFetchRedisForGetAll(f.SearchStatus) || sortsByWaitTime) {
 		started := time.Now()
-		mysqlOrders, err = s.allOrdersReader.FetchAllOrdersForGetAll(ctx, f)
-		mysqlFetchMS = time.Since(started).Milliseconds()
+		redisFormatted, err = s.activeOrdersReader.GetFormattedActiveOrders(ctx, f.TenantID)
+		redisFetchMS = observeStage("get_all", "redis_fetch", started)
 		if err != nil {
-			logging.Error(ctx, "getAll mysql fetch failed", err, "duration_ms", mysqlFetchMS)
-			return GetAllOrdersResult{}, err
+			if !Degrade(ctx, DegradedActiveOrders, err) {
+				logging.Error(ctx, "getAll redis fetch failed", err, "duration_ms", redisFetchMS)
+				return GetAllOrdersResult{}, err
+			}
+			redisFormatted = []FormattedOrder{}
 		}
 	}
 
-	addressMap := make(map[int64][]AddressView, len(mysqlOrders))
-	if s.addressResolver != nil {
+	if sortsByWaitTime {
+		f.WaitTimes = ActiveWaitTimes(redisFormatted)
+	}
+
+	// An order both sources return is counted and listed once, as the fresher
+	// of its two records. The fresher record is picked before the filter, so
+	// a stale record never shows up for a filter the fresher one fails.
+	// Excluding every id Redis wins from the MySQL queries keeps the MySQL
+	// count and the offset window below exact.
+	var duplicates getAllDuplicates
+	activeOrders := redisFormatted
+	if shouldDedupeGetAll(f.SearchStatus) && len(redisFormatted) > 0 {
 		started := time.Now()
-		addressMap, err = s.addressResolver.ResolveAddresses(mysqlOrders)
-		addressResolveMS = time.Since(started).Milliseconds()
+		activeOrders, duplicates, err = s.dedupeGetAllOrders(ctx, f.TenantID, redisFormatted)
+		dedupeMS = observeStage("get_all", "dedupe", started)
 		if err != nil {
-			logging.Error(ctx, "getAll address resolve failed", err, "duration_ms", addressResolveMS)
+			logging.Error(ctx, "getAll dedupe failed", err, "duration_ms", dedupeMS)
 			return GetAllOrdersResult{}, err
 		}
 	}
 
 	started := time.Now()
-	mysqlFormatted := s.MapOrders(mysqlOrders, map[int64][]OptionDTO{}, addressMap)
-	mysqlMapMS = time.Since(started).Milliseconds()
+	redisOrders := filterGetAllRedisOrders(activeOrders, f)
+	sortFormattedOrders(redisOrders, sortKeys)
+	observeStage("get_all", "redis_filter", started)
+	f.ExcludedOrderIDs = duplicates.keptRedis
+	redisMatchedCount := len(redisOrders)
+
+	// Redis orders are merged into the MySQL stream, so at most len(redisOrders)
+	// of them can precede the requested offset. Fetching the MySQL window from
+	// offset-len(redisOrders) is enough to rebuild the merged page exactly.
+	// In cursor mode both sources already start right after the cursor.
+	var mysqlCount int64
+	mysqlStart := offset - len(redisOrders)
+	if mysqlStart < 0 {
+		mysqlStart = 0
+	}
+	mysqlLimit := offset - mysqlStart + f.PageSize
+	if f.Cursor != nil {
+		offset, mysqlStart, mysqlLimit = 0, 0, f.PageSize
+		redisOrders = ordersAfterCursor(redisOrders, *f.Cursor)
+	}
 
-	redisFormatted := []FormattedOrder{}
-	if s.activeOrdersReader != nil && shouldFetchRedisForGetAll(f.SearchStatus) {
+	mysqlOrders := []FullOrder{}
+	if shouldFetchMySQLForGetAll(f.SearchStatus) {
 		started = time.Now()
-		redisFormatted, err = s.activeOrdersReader.GetFormattedActiveOrders(ctx, f.TenantID)
-		redisFetchMS = time.Since(started).Milliseconds()
+		mysqlCount, err = s.allOrdersReader.CountAllOrdersForGetAll(ctx, f)
+		mysqlCountMS = observeStage("get_all", "mysql_count", started)
 		if err != nil {
-			logging.Error(ctx, "getAll redis fetch failed", err, "duration_ms", redisFetchMS)
+			logging.Error(ctx, "getAll mysql count failed", err, "duration_ms", mysqlCountMS)
 			return GetAllOrdersResult{}, err
 		}
+
+		if int64(mysqlStart) < mysqlCount || f.Cursor != nil {
+			started = time.Now()
+			mysqlOrders, err = s.allOrdersReader.FetchAllOrdersForGetAll(ctx, f, mysqlStart, mysqlLimit)
+			mysqlFetchMS = observeStage("get_all", "mysql_fetch", started)
+			if err != nil {
+				logging.Error(ctx, "getAll mysql fetch failed", err, "duration_ms", mysqlFetchMS)
+				return GetAllOrdersResult{}, err
+			}
+		}
 	}
 
-	started = time.Now()
-	allOrders := mergeGetAllOrders(mysqlFormatted, redisFormatted, f)
-	mergeFilterMS = time.Since(started).Milliseconds()
+	addressMap := make(map[int64][]AddressView, len(mysqlOrders))
+	if s.addressResolver != nil {
+		started = time.Now()
+		addressMap, err = s.addressResolver.ResolveAddresses(mysqlOrders)
+		addressResolveMS = observeStage("get_all", "address_resolve", started)
+		if err != nil {
+			logging.Error(ctx, "getAll address resolve failed", err, "duration_ms", addressResolveMS)
+			return GetAllOrdersResult{}, err
+		}
+	}
 
 	started = time.Now()
-	sortFormattedOrders(allOrders, f.SortField, f.SortOrder)
-	sortMS = time.Since(started).Milliseconds()
+	mysqlFormatted := s.MapOrders(mysqlOrders, map[int64][]OptionDTO{}, addressMap)
+	for i := range mysqlFormatted {
+		mysqlFormatted[i].WaitTime = f.WaitTimes[mysqlFormatted[i].OrderID]
+	}
+	observeStage("get_all", "mysql_map", started)
 
 	started = time.Now()
-	totalCount := int64(len(allOrders))
-	pagedOrders := paginateFormattedOrders(allOrders, f.Page, f.PageSize)
-	paginationMS = time.Since(started).Milliseconds()
+	totalCount := mysqlCount + int64(redisMatchedCount)
+	pagedOrders := mergeGetAllPage(mysqlFormatted, mysqlStart, redisOrders, offset, f.PageSize, OrdersLess(sortKeys))
+	observeStage("get_all", "merge", started)
+
+	var nextCursor *PageCursor
+	if cursorField, desc, ok := CursorSort(sortKeys); ok {
+		nextCursor = NextPageCursor(pagedOrders, cursorField, desc, f.PageSize)
+	}
 
 	orderIDs := make([]int64, 0, len(pagedOrders))
 	for _, value := range pagedOrders {
@@ -80,7 +155,7 @@ func (s *service) GetAllOrders(ctx context.Context, f GetAllOrdersFilter) (GetAl
 
 	started = time.Now()
 	optionsMap, err := s.optionsReader.GetOptionsForOrders(ctx, orderIDs)
-	optionsFetchMS = time.Since(started).Milliseconds()
+	optionsFetchMS = observeStage("get_all", "options_fetch", started)
 	if err != nil {
 		logging.Error(ctx, "getAll options fetch failed", err, "duration_ms", optionsFetchMS)
 		return GetAllOrdersResult{}, err
@@ -90,7 +165,7 @@ func (s *service) GetAllOrders(ctx context.Context, f GetAllOrdersFilter) (GetAl
 	for i := range pagedOrders {
 		pagedOrders[i].Options = optionsMap[pagedOrders[i].OrderID]
 	}
-	optionsAssignMS = time.Since(started).Milliseconds()
+	observeStage("get_all", "options_assign", started)
 
 	started = time.Now()
 	prepared, err := s.PrepareOrdersData(ctx, pagedOrders, WarningFilter{
@@ -99,34 +174,29 @@ func (s *service) GetAllOrders(ctx context.Context, f GetAllOrdersFilter) (GetAl
 			Group:    normalizeGetAllGroup(f.SearchStatus),
 		},
 	})
-	prepareMS = time.Since(started).Milliseconds()
+	prepareMS = observeStage("get_all", "prepare", started)
 	if err != nil {
 		logging.Error(ctx, "getAll prepare orders failed", err, "duration_ms", prepareMS)
 		return GetAllOrdersResult{}, err
 	}
 
-	logging.Info(ctx, "getAll timings",
-		"total_ms", time.Since(totalStarted).Milliseconds(),
-		"mysql_fetch_ms", mysqlFetchMS,
-		"address_resolve_ms", addressResolveMS,
-		"mysql_map_ms", mysqlMapMS,
-		"redis_fetch_ms", redisFetchMS,
-		"merge_filter_ms", mergeFilterMS,
-		"sort_ms", sortMS,
-		"pagination_ms", paginationMS,
-		"options_fetch_ms", optionsFetchMS,
-		"options_assign_ms", optionsAssignMS,
-		"prepare_ms", prepareMS,
+	observeStage("get_all", "total", totalStarted)
+	logging.Info(ctx, "getAll done",
 		"tenant_id", f.TenantID,
 		"search_status", f.SearchStatus,
 		"page", f.Page,
 		"page_size", f.PageSize,
 		"sort_field", f.SortField,
 		"sort_order", f.SortOrder,
-		"mysql_orders_count", len(mysqlOrders),
-		"mysql_formatted_count", len(mysqlFormatted),
+		"mysql_total_count", mysqlCount,
+		"mysql_window_start", mysqlStart,
+		"mysql_window_count", len(mysqlOrders),
 		"redis_formatted_count", len(redisFormatted),
-		"merged_count", len(allOrders),
+		"redis_matched_count", redisMatchedCount,
+		"duplicates_kept_mysql", duplicates.keptMySQL,
+		"duplicates_kept_redis", len(duplicates.keptRedis),
+		"dedupe_ms", dedupeMS,
+		"cursor_mode", f.Cursor != nil,
 		"paged_count", len(pagedOrders),
 		"options_orders_count", len(optionsMap),
 		"prepared_count", len(prepared),
@@ -138,121 +208,183 @@ func (s *service) GetAllOrders(ctx context.Context, f GetAllOrdersFilter) (GetAl
 		OrderTotalCount: totalCount,
 		CountPerPage:    f.PageSize,
 		Orders:          prepared,
+		NextCursor:      nextCursor,
 	}, nil
 }
 
-func paginateFormattedOrders(orders []FormattedOrder, page, pageSize int) []FormattedOrder {
-	if page < 0 {
-		page = 0
+// getAllDuplicates tells how the orders both sources returned were resolved.
+type getAllDuplicates struct {
+	keptRedis []int64
+	keptMySQL int
+}
+
+// shouldDedupeGetAll reports whether a search lists orders of both sources.
+func shouldDedupeGetAll(searchStatus string) bool {
+	return shouldFetchMySQLForGetAll(searchStatus) && shouldFetchRedisForGetAll(searchStatus)
+}
+
+// dedupeGetAllOrders looks up the MySQL records of all active orders of the
+// tenant by order id alone and keeps the fresher record of every order found
+// in both, whether or not either matches the search. It returns the Redis
+// orders without those MySQL keeps, still unfiltered; the ids whose Redis
+// record is kept have to be excluded from the MySQL queries.
+func (s *service) dedupeGetAllOrders(
+	ctx context.Context,
+	tenantID int64,
+	redisOrders []FormattedOrder,
+) ([]FormattedOrder, getAllDuplicates, error) {
+	lookup := GetAllOrdersFilter{
+		BaseFilter: BaseFilter{TenantID: tenantID},
+		OrderIDs:   make([]int64, len(redisOrders)),
+	}
+	for i := range redisOrders {
+		lookup.OrderIDs[i] = redisOrders[i].OrderID
+	}
+
+	rows, err := s.allOrdersReader.FetchAllOrdersForGetAll(ctx, lookup, 0, len(lookup.OrderIDs))
+	if err != nil {
+		return nil, getAllDuplicates{}, err
+	}
+	mysqlByID := make(map[int64]FullOrder, len(rows))
+	for _, row := range rows {
+		mysqlByID[row.OrderID] = row
+	}
+
+	var duplicates getAllDuplicates
+	kept := make([]FormattedOrder, 0, len(redisOrders))
+	for _, value := range redisOrders {
+		row, ok := mysqlByID[value.OrderID]
+		switch {
+		case !ok:
+			kept = append(kept, value)
+		case redisRecordIsFresher(value, row):
+			duplicates.keptRedis = append(duplicates.keptRedis, value.OrderID)
+			kept = append(kept, value)
+			metrics.GetAllDuplicates.Inc("redis")
+		default:
+			duplicates.keptMySQL++
+			metrics.GetAllDuplicates.Inc("mysql")
+		}
 	}
-	if pageSize <= 0 {
-		pageSize = 50
+
+	return kept, duplicates, nil
+}
+
+// redisRecordIsFresher decides which record of an order in both sources is
+// kept. The later update_time wins when both records carry one, otherwise the
+// later status_time. On a tie the Redis record wins: it is the live copy the
+// order engine writes first.
+func redisRecordIsFresher(redisOrder FormattedOrder, mysqlOrder FullOrder) bool {
+	mysqlUpdateTime := mysqlOrder.UpdateTime.Int64
+	if redisOrder.UpdateTime > 0 && mysqlUpdateTime > 0 && redisOrder.UpdateTime != mysqlUpdateTime {
+		return redisOrder.UpdateTime > mysqlUpdateTime
 	}
+	return redisOrder.StatusTime >= mysqlOrder.StatusTime
+}
 
-	offset := page * pageSize
-	if offset >= len(orders) {
-		return []FormattedOrder{}
+func ordersAfterCursor(orders []FormattedOrder, cursor PageCursor) []FormattedOrder {
+	result := make([]FormattedOrder, 0, len(orders))
+	for _, value := range orders {
+		if cursor.FollowedBy(value) {
+			result = append(result, value)
+		}
 	}
 
-	end := offset + pageSize
-	if end > len(orders) {
-		end = len(orders)
+	return result
+}
+
+// mergeGetAllPage merges a sorted MySQL window that starts at mysqlStart in the
+// full MySQL result with the complete sorted list of Redis orders and returns
+// the merged items at [offset, offset+limit). Equal items keep MySQL first.
+func mergeGetAllPage(
+	mysqlWindow []FormattedOrder,
+	mysqlStart int,
+	redisOrders []FormattedOrder,
+	offset, limit int,
+	less func(a, b FormattedOrder) bool,
+) []FormattedOrder {
+	redisIndex := 0
+	position := 0
+	if mysqlStart > 0 {
+		if len(mysqlWindow) == 0 {
+			return []FormattedOrder{}
+		}
+		redisIndex = sort.Search(len(redisOrders), func(i int) bool {
+			return !less(redisOrders[i], mysqlWindow[0])
+		})
+		position = mysqlStart + redisIndex
+	}
+
+	result := make([]FormattedOrder, 0, limit)
+	mysqlIndex := 0
+	for len(result) < limit {
+		var next FormattedOrder
+		switch {
+		case mysqlIndex < len(mysqlWindow) &&
+			(redisIndex >= len(redisOrders) || !less(redisOrders[redisIndex], mysqlWindow[mysqlIndex])):
+			next = mysqlWindow[mysqlIndex]
+			mysqlIndex++
+		case redisIndex < len(redisOrders):
+			next = redisOrders[redisIndex]
+			redisIndex++
+		default:
+			return result
+		}
+
+		if position >= offset {
+			result = append(result, next)
+		}
+		position++
 	}
 
-	return orders[offset:end]
+	return result
 }
 
-func sortFormattedOrders(orders []FormattedOrder, field, direction string) {
-	desc := !strings.EqualFold(direction, "asc")
+const (
+	GetAllSortOrderID   = "o.order_id"
+	GetAllSortOrderTime = "o.order_time"
+)
+
+// GetAllSortKey resolves the requested sort field of the all-orders search to
+// the key both the SQL and the in-memory sorters use.
+func GetAllSortKey(field string) string {
 	switch field {
 	case "o.order_time", "order_time":
-		sort.SliceStable(orders, func(i, j int) bool {
-			if desc {
-				return orders[i].OrderTime > orders[j].OrderTime
-			}
-			return orders[i].OrderTime < orders[j].OrderTime
-		})
+		return GetAllSortOrderTime
 	default:
-		sort.SliceStable(orders, func(i, j int) bool {
-			if desc {
-				return orders[i].OrderID > orders[j].OrderID
-			}
-			return orders[i].OrderID < orders[j].OrderID
-		})
+		return GetAllSortOrderID
 	}
 }
 
-func matchesGetAllMySQLFilter(o FormattedOrder, f GetAllOrdersFilter) bool {
-	if !matchesSearchStatus(o.StatusID, f.SearchStatus) {
-		return false
-	}
-	if len(f.CityIDs) > 0 && !containsInt64(f.CityIDs, o.CityID) {
-		return false
-	}
-	if len(f.Tariffs) > 0 && !containsInt64(f.Tariffs, o.TariffID) {
-		return false
-	}
-	if len(f.ShopIDs) > 0 && (o.ShopID == nil || !containsInt64(f.ShopIDs, *o.ShopID)) {
-		return false
-	}
-	if !matchesDate(o.OrderTime, f.Date) {
-		return false
-	}
-	if !matchesSearchAttributes(o, f.Attributes, matchesAttribute) {
+// IsGetAllSortField reports whether GetAllSortKey knows field.
+func IsGetAllSortField(field string) bool {
+	switch field {
+	case "o.order_time", "order_time", "o.order_id", "order_id":
+		return true
+	default:
 		return false
 	}
-
-	return true
 }
 
-func matchesGetAllRedisFilter(o FormattedOrder, f GetAllOrdersFilter) bool {
-	if !matchesSearchStatus(o.StatusID, f.SearchStatus) {
-		return false
-	}
-	if len(f.CityIDs) > 0 && !containsInt64(f.CityIDs, o.CityID) {
-		return false
-	}
-	if len(f.Tariffs) > 0 && !containsInt64(f.Tariffs, o.TariffID) {
-		return false
-	}
-	if !matchesDate(o.OrderTime, f.Date) {
-		return false
-	}
-	if !matchesSearchAttributes(o, f.Attributes, matchesRedisAttribute) {
-		return false
-	}
-	if !matchesSearchString(o, "client", f.SearchString["client"]) {
-		return false
-	}
-	if !matchesSearchString(o, "worker", f.SearchString["worker"]) {
-		return false
-	}
-
-	return true
+func sortFormattedOrders(orders []FormattedOrder, keys []SortKey) {
+	less := OrdersLess(keys)
+	sort.SliceStable(orders, func(i, j int) bool {
+		return less(orders[i], orders[j])
+	})
 }
 
-func matchesSearchStatus(statusID int64, searchStatus string) bool {
+// searchStatusGroups lists the status categories a search status covers. The
+// second result is false when the search status does not filter at all.
+func searchStatusGroups(searchStatus string) ([]string, bool) {
 	switch searchStatus {
 	case "", "all":
-		return true
-	case "new":
-		return statusBelongsToGroup(statusID, "new")
+		return nil, false
 	case "works":
-		return statusBelongsToGroup(statusID, "works") || statusBelongsToGroup(statusID, "pre_order")
+		return []string{"works", "pre_order"}, true
 	case "active":
-		return statusBelongsToGroup(statusID, "new") ||
-			statusBelongsToGroup(statusID, "works") ||
-			statusBelongsToGroup(statusID, "pre_order")
-	case "completed":
-		return statusBelongsToGroup(statusID, "completed")
-	case "rejected":
-		return statusBelongsToGroup(statusID, "rejected")
-	case "warning":
-		return statusBelongsToGroup(statusID, "warning")
-	case "pre_order":
-		return statusBelongsToGroup(statusID, "pre_order")
+		return []string{"new", "works", "pre_order"}, true
 	default:
-		return statusBelongsToGroup(statusID, searchStatus)
+		return []string{searchStatus}, true
 	}
 }
 
@@ -269,127 +401,19 @@ func shouldFetchRedisForGetAll(searchStatus string) bool {
 	}
 }
 
-func shouldIncludeRedisOrderForGetAll(o FormattedOrder, searchStatus string) bool {
-	if !shouldFetchRedisForGetAll(searchStatus) {
-		return false
-	}
-	return matchesSearchStatus(o.StatusID, searchStatus)
-}
-
-func mergeGetAllOrders(mysqlFormatted, redisFormatted []FormattedOrder, f GetAllOrdersFilter) []FormattedOrder {
-	allOrders := make([]FormattedOrder, 0, len(mysqlFormatted)+len(redisFormatted))
-
-	for _, value := range mysqlFormatted {
-		if matchesGetAllMySQLFilter(value, f) {
-			allOrders = append(allOrders, value)
-		}
+func filterGetAllRedisOrders(redisFormatted []FormattedOrder, f GetAllOrdersFilter) []FormattedOrder {
+	result := make([]FormattedOrder, 0, len(redisFormatted))
+	if !shouldFetchRedisForGetAll(f.SearchStatus) {
+		return result
 	}
+	spec := GetAllFilterSpec(f)
 	for _, value := range redisFormatted {
-		if shouldIncludeRedisOrderForGetAll(value, f.SearchStatus) && matchesGetAllRedisFilter(value, f) {
-			allOrders = append(allOrders, value)
-		}
-	}
-
-	return allOrders
-}
-
-func matchesDate(orderTime int64, date *string) bool {
-	if date == nil || *date == "" {
-		return true
-	}
-
-	for _, layout := range []string{"2006-01-02", "02.01.2006", "02.01.2006 15:04:05"} {
-		parsed, err := time.Parse(layout, *date)
-		if err == nil {
-			return time.Unix(orderTime, 0).UTC().Format("2006-01-02") == parsed.Format("2006-01-02")
-		}
-	}
-
-	return time.Unix(orderTime, 0).UTC().Format("2006-01-02") == *date
-}
-
-func matchesSearchAttributes(
-	o FormattedOrder,
-	attributes []SearchAttribute,
-	matcher func(FormattedOrder, string, string) bool,
-) bool {
-	for _, attribute := range attributes {
-		search := strings.TrimSpace(attribute.SearchString)
-		if search == "" {
-			continue
-		}
-
-		matched := true
-		for _, part := range strings.Fields(search) {
-			if !matcher(o, attribute.Attribute, part) {
-				matched = false
-				break
-			}
-		}
-		if !matched {
-			return false
-		}
-	}
-
-	return true
-}
-
-func matchesRedisAttribute(o FormattedOrder, attribute, search string) bool {
-	switch attribute {
-	case "number", "address", "comment":
-		return matchesAttribute(o, attribute, search)
-	default:
-		return true
-	}
-}
-
-func matchesAttribute(o FormattedOrder, attribute, search string) bool {
-	needle := strings.ToLower(search)
-
-	switch attribute {
-	case "number":
-		return strings.Contains(strings.ToLower(strconv.FormatInt(o.OrderNumber, 10)), needle) ||
-			strings.Contains(strings.ToLower(o.OrderCode), needle)
-	case "address":
-		for _, address := range o.Address {
-			if strings.Contains(strings.ToLower(joinAddress(address)), needle) {
-				return true
-			}
+		if spec.Matches(value) {
+			result = append(result, value)
 		}
-		return false
-	case "comment":
-		return strings.Contains(strings.ToLower(stringValue(o.Comment)), needle)
-	case "client":
-		return matchesSearchString(o, "client", search)
-	case "worker":
-		return matchesSearchString(o, "worker", search)
-	default:
-		return true
-	}
-}
-
-func matchesSearchString(o FormattedOrder, field, search string) bool {
-	needle := strings.ToLower(strings.TrimSpace(search))
-	if needle == "" {
-		return true
 	}
 
-	switch field {
-	case "client":
-		phone := normalizePhone(stringValue(o.Client.Phone))
-		return strings.Contains(strings.ToLower(stringValue(o.Client.LastName)), needle) ||
-			strings.Contains(strings.ToLower(stringValue(o.Client.Name)), needle) ||
-			strings.Contains(strings.ToLower(stringValue(o.Client.SecondName)), needle) ||
-			strings.Contains(phone, normalizePhone(needle))
-	case "worker":
-		return strings.Contains(strings.ToLower(stringValue(o.Worker.LastName)), needle) ||
-			strings.Contains(strings.ToLower(stringValue(o.Worker.Name)), needle) ||
-			strings.Contains(strings.ToLower(stringValue(o.Worker.SecondName)), needle) ||
-			strings.Contains(strings.ToLower(derefInt64(o.Worker.Callsign)), needle) ||
-			strings.Contains(strings.ToLower(stringValue(o.Car.GosNumber)), needle)
-	default:
-		return true
-	}
+	return result
 }
 
 func joinAddress(address AddressView) string {
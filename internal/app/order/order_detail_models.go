@@ -0,0 +1,7 @@
+package order
+
+type OrderFilter struct {
+	TenantID int64
+	OrderID  int64
+	Language string
+}
@@ -0,0 +1,112 @@
+package order
+
+import (
+	"context"
+	"orders-service/internal/logging"
+	"time"
+)
+
+func (s *service) GetOrder(ctx context.Context, f OrderFilter) (OrderView, error) {
+	totalStarted := time.Now()
+	var redisFetchMS int64
+	var mysqlFetchMS int64
+	var optionsFetchMS int64
+	var prepareMS int64
+
+	var (
+		formatted FormattedOrder
+		found     bool
+		err       error
+		source    = "redis"
+	)
+
+	if s.activeOrdersReader != nil {
+		started := time.Now()
+		formatted, found, err = s.activeOrdersReader.GetFormattedActiveOrder(ctx, f.TenantID, f.OrderID)
+		redisFetchMS = observeStage("order_detail", "redis_fetch", started)
+		if err != nil {
+			logging.Error(ctx, "order detail redis fetch failed", err, "duration_ms", redisFetchMS)
+			return OrderView{}, err
+		}
+	}
+
+	if !found {
+		source = "mysql"
+		started := time.Now()
+		formatted, found, err = s.fetchFormattedOrder(ctx, f)
+		mysqlFetchMS = observeStage("order_detail", "mysql_fetch", started)
+		if err != nil {
+			logging.Error(ctx, "order detail mysql fetch failed", err, "duration_ms", mysqlFetchMS)
+			return OrderView{}, err
+		}
+	}
+
+	if !found {
+		return OrderView{}, ErrOrderNotFound
+	}
+	if formatted.TenantID == 0 {
+		formatted.TenantID = f.TenantID
+	}
+
+	started := time.Now()
+	optionsMap, err := s.optionsReader.GetOptionsForOrders(ctx, []int64{formatted.OrderID})
+	optionsFetchMS = observeStage("order_detail", "options_fetch", started)
+	if err != nil {
+		logging.Error(ctx, "order detail options fetch failed", err, "duration_ms", optionsFetchMS)
+		return OrderView{}, err
+	}
+	formatted.Options = optionsMap[formatted.OrderID]
+
+	started = time.Now()
+	prepared, err := s.PrepareOrdersData(ctx, []FormattedOrder{formatted}, WarningFilter{
+		BaseFilter: BaseFilter{
+			TenantID: f.TenantID,
+			Language: f.Language,
+			Group:    orderDetailGroup(s.statusRegistry(), f.TenantID, formatted.StatusID),
+		},
+	})
+	prepareMS = observeStage("order_detail", "prepare", started)
+	if err != nil {
+		logging.Error(ctx, "order detail prepare failed", err, "duration_ms", prepareMS)
+		return OrderView{}, err
+	}
+	if len(prepared) == 0 {
+		return OrderView{}, ErrOrderNotFound
+	}
+
+	observeStage("order_detail", "total", totalStarted)
+	logging.Info(ctx, "order detail done",
+		"tenant_id", f.TenantID,
+		"order_id", f.OrderID,
+		"source", source,
+	)
+
+	return prepared[0], nil
+}
+
+func (s *service) fetchFormattedOrder(ctx context.Context, f OrderFilter) (FormattedOrder, bool, error) {
+	fullOrder, found, err := s.orderReader.FetchOrderByID(ctx, f.TenantID, f.OrderID)
+	if err != nil || !found {
+		return FormattedOrder{}, false, err
+	}
+
+	orders := []FullOrder{fullOrder}
+	addressMap := map[int64][]AddressView{}
+	if s.addressResolver != nil {
+		addressMap, err = s.addressResolver.ResolveAddresses(orders)
+		if err != nil {
+			return FormattedOrder{}, false, err
+		}
+	}
+
+	return s.MapFullOrderToFormatted(fullOrder, nil, addressMap[fullOrder.OrderID]), true, nil
+}
+
+// orderDetailGroup picks the group used for summary cost resolution: a finished
+// order shows its final cost, everything else shows the preliminary price.
+func orderDetailGroup(statuses *StatusRegistry, tenantID, statusID int64) string {
+	if statuses.InGroup(tenantID, statusID, "completed") {
+		return "completed"
+	}
+	return statuses.Category(tenantID, statusID)
+}
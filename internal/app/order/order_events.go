@@ -0,0 +1,17 @@
+package order
+
+import "context"
+
+// OrderChanged describes a change of an active order. Before is nil for an
+// order that just appeared, After is nil for an order that left the active
+// set.
+type OrderChanged struct {
+	TenantID int64
+	OrderID  int64
+	Before   *FormattedOrder
+	After    *FormattedOrder
+}
+
+type OrderChangePublisher interface {
+	PublishOrderChanged(ctx context.Context, event OrderChanged)
+}
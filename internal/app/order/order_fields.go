@@ -0,0 +1,222 @@
+package order
+
+// OrderRow is one order of a source keyed like the PHP payload of an active
+// order in Redis. The ok results are false for missing or NULL values.
+type OrderRow interface {
+	Has(key string) bool
+	Int64(key string) (int64, bool)
+	Float64(key string) (float64, bool)
+	String(key string) (string, bool)
+}
+
+// OrderField maps one key of an OrderRow onto one FormattedOrder field. A
+// key feeding several fields, like wName for WName and Worker.Name, has an
+// entry per field.
+type OrderField struct {
+	Key   string
+	Field string
+	set   func(o *FormattedOrder, row OrderRow)
+}
+
+// orderFields is the mapping of every FormattedOrder field both sources fill
+// in. Address and Options are resolved by the sources, Warnings by the
+// warning rules and WaitTime by the active order payload.
+var orderFields = []OrderField{
+	int64Field("order_id", "OrderID", func(o *FormattedOrder, v int64) { o.OrderID = v }),
+	int64Field("tenant_id", "TenantID", func(o *FormattedOrder, v int64) { o.TenantID = v }),
+	optionalInt64Field("worker_id", "WorkerID", func(o *FormattedOrder, v *int64) { o.WorkerID = v }),
+	optionalInt64Field("car_id", "CarID", func(o *FormattedOrder, v *int64) { o.CarID = v }),
+	int64Field("city_id", "CityID", func(o *FormattedOrder, v int64) { o.CityID = v }),
+	int64Field("tariff_id", "TariffID", func(o *FormattedOrder, v int64) { o.TariffID = v }),
+	int64Field("user_create", "UserCreate", func(o *FormattedOrder, v int64) { o.UserCreate = v }),
+	int64Field("status_id", "StatusID", func(o *FormattedOrder, v int64) { o.StatusID = v }),
+	int64Field("user_modifed", "UserModified", func(o *FormattedOrder, v int64) { o.UserModified = v }),
+	optionalInt64Field("company_id", "CompanyID", func(o *FormattedOrder, v *int64) { o.CompanyID = v }),
+	optionalInt64Field("parking_id", "ParkingID", func(o *FormattedOrder, v *int64) { o.ParkingID = v }),
+	optionalStringField("comment", "Comment", func(o *FormattedOrder, v *string) { o.Comment = v }),
+	float64Field("predv_price", "PredvPrice", func(o *FormattedOrder, v float64) { o.PredvPrice = v }),
+	float64Field("predv_price_no_discount", "PredvPriceNoDiscount", func(o *FormattedOrder, v float64) { o.PredvPriceNoDiscount = v }),
+	stringField("device", "Device", func(o *FormattedOrder, v string) { o.Device = v }),
+	int64Field("order_number", "OrderNumber", func(o *FormattedOrder, v int64) { o.OrderNumber = v }),
+	stringField("payment", "Payment", func(o *FormattedOrder, v string) { o.Payment = v }),
+	int64Field("show_phone", "ShowPhone", func(o *FormattedOrder, v int64) { o.ShowPhone = v }),
+	int64Field("create_time", "CreateTime", func(o *FormattedOrder, v int64) { o.CreateTime = v }),
+	int64Field("status_time", "StatusTime", func(o *FormattedOrder, v int64) { o.StatusTime = v }),
+	optionalInt64Field("time_to_client", "TimeToClient", func(o *FormattedOrder, v *int64) { o.TimeToClient = v }),
+	optionalStringField("client_device_token", "ClientDeviceToken", func(o *FormattedOrder, v *string) { o.ClientDeviceToken = v }),
+	optionalInt64Field("app_id", "AppID", func(o *FormattedOrder, v *int64) { o.AppID = v }),
+	int64Field("order_time", "OrderTime", func(o *FormattedOrder, v int64) { o.OrderTime = v }),
+	float64Field("predv_distance", "PredvDistance", func(o *FormattedOrder, v float64) { o.PredvDistance = v }),
+	int64Field("predv_time", "PredvTime", func(o *FormattedOrder, v int64) { o.PredvTime = v }),
+	optionalInt64Field("call_warning_id", "CallWarningID", func(o *FormattedOrder, v *int64) { o.CallWarningID = v }),
+	stringField("phone", "Phone", func(o *FormattedOrder, v string) { o.Phone = v }),
+	int64Field("client_id", "ClientID", func(o *FormattedOrder, v int64) { o.ClientID = v }),
+	int64Field("bonus_payment", "BonusPayment", func(o *FormattedOrder, v int64) { o.BonusPayment = v }),
+	int64Field("currency_id", "CurrencyID", func(o *FormattedOrder, v int64) { o.CurrencyID = v }),
+	int64Field("time_offset", "TimeOffset", func(o *FormattedOrder, v int64) { o.TimeOffset = v }),
+	int64Field("is_fix", "IsFix", func(o *FormattedOrder, v int64) { o.IsFix = v }),
+	int64Field("update_time", "UpdateTime", func(o *FormattedOrder, v int64) { o.UpdateTime = v }),
+	int64Field("deny_refuse_order", "DenyRefuseOrder", func(o *FormattedOrder, v int64) { o.DenyRefuseOrder = v }),
+	int64Field("position_id", "PositionID", func(o *FormattedOrder, v int64) { o.PositionID = v }),
+	optionalInt64Field("promo_code_id", "PromoCodeID", func(o *FormattedOrder, v *int64) { o.PromoCodeID = v }),
+	optionalInt64Field("tenant_company_id", "TenantCompanyID", func(o *FormattedOrder, v *int64) { o.TenantCompanyID = v }),
+	int64Field("mark", "Mark", func(o *FormattedOrder, v int64) { o.Mark = v }),
+	optionalInt64Field("processed_exchange_program_id", "ProcessedExchangeProgramID", func(o *FormattedOrder, v *int64) { o.ProcessedExchangeProgramID = v }),
+	optionalInt64Field("client_passenger_id", "ClientPassengerID", func(o *FormattedOrder, v *int64) { o.ClientPassengerID = v }),
+	optionalStringField("client_passenger_phone", "ClientPassengerPhone", func(o *FormattedOrder, v *string) { o.ClientPassengerPhone = v }),
+	int64Field("active", "Active", func(o *FormattedOrder, v int64) { o.Active = v }),
+	int64Field("is_pre_order", "IsPreOrder", func(o *FormattedOrder, v int64) { o.IsPreOrder = v }),
+	optionalStringField("app_version", "AppVersion", func(o *FormattedOrder, v *string) { o.AppVersion = v }),
+	float64Field("agent_commission", "AgentCommission", func(o *FormattedOrder, v float64) { o.AgentCommission = v }),
+	int64Field("is_fix_by_dispatcher", "IsFixByDispatcher", func(o *FormattedOrder, v int64) { o.IsFixByDispatcher = v }),
+	optionalInt64Field("finish_time", "FinishTime", func(o *FormattedOrder, v *int64) { o.FinishTime = v }),
+	optionalStringField("comment_for_dispatcher", "CommentForDispatcher", func(o *FormattedOrder, v *string) { o.CommentForDispatcher = v }),
+	float64Field("worker_manual_surcharge", "WorkerManualSurcharge", func(o *FormattedOrder, v float64) { o.WorkerManualSurcharge = v }),
+	optionalFloat64Field("realtime_price", "RealtimePrice", func(o *FormattedOrder, v *float64) { o.RealtimePrice = v }),
+	optionalFloat64Field("unit_quantity", "UnitQuantity", func(o *FormattedOrder, v *float64) { o.UnitQuantity = v }),
+	optionalInt64Field("shop_id", "ShopID", func(o *FormattedOrder, v *int64) { o.ShopID = v }),
+	int64Field("require_prepayment", "RequirePrepayment", func(o *FormattedOrder, v int64) { o.RequirePrepayment = v }),
+	stringField("order_code", "OrderCode", func(o *FormattedOrder, v string) { o.OrderCode = v }),
+	optionalFloat64Field("client_offered_price", "ClientOfferedPrice", func(o *FormattedOrder, v *float64) { o.ClientOfferedPrice = v }),
+	stringField("idempotent_key", "IdempotentKey", func(o *FormattedOrder, v string) { o.IdempotentKey = v }),
+	optionalInt64Field("additional_tariff_id", "AdditionalTariffID", func(o *FormattedOrder, v *int64) { o.AdditionalTariffID = v }),
+	optionalFloat64Field("initial_price", "InitialPrice", func(o *FormattedOrder, v *float64) { o.InitialPrice = v }),
+	optionalInt64Field("time_to_order", "TimeToOrder", func(o *FormattedOrder, v *int64) { o.TimeToOrder = v }),
+	optionalInt64Field("sort", "Sort", func(o *FormattedOrder, v *int64) { o.Sort = v }),
+	optionalStringField("summary_cost", "SummaryCost", func(o *FormattedOrder, v *string) { o.SummaryCost = v }),
+	optionalStringField("summary_cost_no_discount", "SummaryCostNoDiscount", func(o *FormattedOrder, v *string) { o.SummaryCostNoDiscount = v }),
+
+	int64Field("status_status_id", "StatusStatusID", func(o *FormattedOrder, v int64) { o.StatusStatusID = v }),
+	stringField("status_name", "StatusName", func(o *FormattedOrder, v string) { o.StatusName = v }),
+	int64Field("status_status_id", "Status.StatusID", func(o *FormattedOrder, v int64) { o.Status.StatusID = v }),
+	stringField("status_name", "Status.Name", func(o *FormattedOrder, v string) { o.Status.Name = v }),
+
+	int64Field("worker_id", "Worker.WorkerID", func(o *FormattedOrder, v int64) { o.Worker.WorkerID = v }),
+	optionalInt64Field("callsign", "Callsign", func(o *FormattedOrder, v *int64) { o.Callsign = v }),
+	optionalInt64Field("callsign", "Worker.Callsign", func(o *FormattedOrder, v *int64) { o.Worker.Callsign = v }),
+	optionalStringField("wName", "WName", func(o *FormattedOrder, v *string) { o.WName = v }),
+	optionalStringField("wName", "Worker.Name", func(o *FormattedOrder, v *string) { o.Worker.Name = v }),
+	optionalStringField("wLastName", "WLastName", func(o *FormattedOrder, v *string) { o.WLastName = v }),
+	optionalStringField("wLastName", "Worker.LastName", func(o *FormattedOrder, v *string) { o.Worker.LastName = v }),
+	optionalStringField("wSecondName", "WSecondName", func(o *FormattedOrder, v *string) { o.WSecondName = v }),
+	optionalStringField("wSecondName", "Worker.SecondName", func(o *FormattedOrder, v *string) { o.Worker.SecondName = v }),
+	optionalStringField("wPhone", "WPhone", func(o *FormattedOrder, v *string) { o.WPhone = v }),
+	optionalStringField("wPhone", "Worker.Phone", func(o *FormattedOrder, v *string) { o.Worker.Phone = v }),
+
+	int64Field("client_id", "Client.ClientID", func(o *FormattedOrder, v int64) { o.Client.ClientID = v }),
+	optionalStringField("cPhone", "CPhone", func(o *FormattedOrder, v *string) { o.CPhone = v }),
+	optionalStringField("cPhone", "Client.Phone", func(o *FormattedOrder, v *string) { o.Client.Phone = v }),
+	optionalStringField("cName", "CName", func(o *FormattedOrder, v *string) { o.CName = v }),
+	optionalStringField("cName", "Client.Name", func(o *FormattedOrder, v *string) { o.Client.Name = v }),
+	optionalStringField("cLastName", "CLastName", func(o *FormattedOrder, v *string) { o.CLastName = v }),
+	optionalStringField("cLastName", "Client.LastName", func(o *FormattedOrder, v *string) { o.Client.LastName = v }),
+	optionalStringField("cSecondName", "CSecondName", func(o *FormattedOrder, v *string) { o.CSecondName = v }),
+	optionalStringField("cSecondName", "Client.SecondName", func(o *FormattedOrder, v *string) { o.Client.SecondName = v }),
+
+	int64Field("car_id", "Car.CarID", func(o *FormattedOrder, v int64) { o.Car.CarID = v }),
+	optionalStringField("car_name", "CarName", func(o *FormattedOrder, v *string) { o.CarName = v }),
+	optionalStringField("car_name", "Car.Name", func(o *FormattedOrder, v *string) { o.Car.Name = v }),
+	optionalStringField("car_color", "CarColor", func(o *FormattedOrder, v *string) { o.CarColor = v }),
+	optionalInt64Field("car_color", "Car.Color", func(o *FormattedOrder, v *int64) { o.Car.Color = v }),
+	optionalStringField("car_gos_number", "CarGosNumber", func(o *FormattedOrder, v *string) { o.CarGosNumber = v }),
+	optionalStringField("car_gos_number", "Car.GosNumber", func(o *FormattedOrder, v *string) { o.Car.GosNumber = v }),
+
+	int64Field("tariff_id", "Tariff.TariffID", func(o *FormattedOrder, v int64) { o.Tariff.TariffID = v }),
+	stringField("tariff_type", "TariffType", func(o *FormattedOrder, v string) { o.TariffType = v }),
+	stringField("tariff_type", "Tariff.TariffType", func(o *FormattedOrder, v string) { o.Tariff.TariffType = v }),
+	stringField("tName", "TName", func(o *FormattedOrder, v string) { o.TName = v }),
+	stringField("tName", "Tariff.Name", func(o *FormattedOrder, v string) { o.Tariff.Name = v }),
+	stringField("quantitative_title", "QuantitativeTitle", func(o *FormattedOrder, v string) { o.QuantitativeTitle = v }),
+	stringField("quantitative_title", "Tariff.QuantitativeTitle", func(o *FormattedOrder, v string) { o.Tariff.QuantitativeTitle = v }),
+	float64Field("price_for_unit", "PriceForUnit", func(o *FormattedOrder, v float64) { o.PriceForUnit = v }),
+	float64Field("price_for_unit", "Tariff.PriceForUnit", func(o *FormattedOrder, v float64) { o.Tariff.PriceForUnit = v }),
+	stringField("unit_name", "UnitName", func(o *FormattedOrder, v string) { o.UnitName = v }),
+	stringField("unit_name", "Tariff.UnitName", func(o *FormattedOrder, v string) { o.Tariff.UnitName = v }),
+
+	int64Field("user_id", "UserID", func(o *FormattedOrder, v int64) { o.UserID = v }),
+	int64Field("user_id", "UserCreated.UserID", func(o *FormattedOrder, v int64) { o.UserCreated.UserID = v }),
+	stringField("uName", "UName", func(o *FormattedOrder, v string) { o.UName = v }),
+	stringField("uName", "UserCreated.Name", func(o *FormattedOrder, v string) { o.UserCreated.Name = v }),
+	stringField("uLastName", "ULastName", func(o *FormattedOrder, v string) { o.ULastName = v }),
+	stringField("uLastName", "UserCreated.LastName", func(o *FormattedOrder, v string) { o.UserCreated.LastName = v }),
+	optionalStringField("uSecondName", "USecondName", func(o *FormattedOrder, v *string) { o.USecondName = v }),
+	optionalStringField("uSecondName", "UserCreated.SecondName", func(o *FormattedOrder, v *string) { o.UserCreated.SecondName = v }),
+
+	stringField("currency_name", "CurrencyName", func(o *FormattedOrder, v string) { o.CurrencyName = v }),
+	stringField("currency_name", "Currency.Name", func(o *FormattedOrder, v string) { o.Currency.Name = v }),
+	stringField("currency_code", "CurrencyCode", func(o *FormattedOrder, v string) { o.CurrencyCode = v }),
+	stringField("currency_code", "Currency.Code", func(o *FormattedOrder, v string) { o.Currency.Code = v }),
+	stringField("symbol", "Symbol", func(o *FormattedOrder, v string) { o.Symbol = v }),
+	stringField("symbol", "Currency.Symbol", func(o *FormattedOrder, v string) { o.Currency.Symbol = v }),
+}
+
+// FormatOrderRow maps row through the field table. The status of a row
+// without a joined status falls back to its status_id.
+func FormatOrderRow(row OrderRow) FormattedOrder {
+	var o FormattedOrder
+	for _, field := range orderFields {
+		field.set(&o, row)
+	}
+	if o.StatusStatusID == 0 {
+		o.StatusStatusID = o.StatusID
+		o.Status.StatusID = o.StatusID
+	}
+	return o
+}
+
+// MissingOrderFields returns the FormattedOrder fields row has no key for,
+// each field once.
+func MissingOrderFields(row OrderRow) []string {
+	var missing []string
+	for _, field := range orderFields {
+		if !row.Has(field.Key) {
+			missing = append(missing, field.Field)
+		}
+	}
+	return missing
+}
+
+func int64Field(key, field string, set func(o *FormattedOrder, v int64)) OrderField {
+	return OrderField{Key: key, Field: field, set: func(o *FormattedOrder, row OrderRow) {
+		v, _ := row.Int64(key)
+		set(o, v)
+	}}
+}
+
+func optionalInt64Field(key, field string, set func(o *FormattedOrder, v *int64)) OrderField {
+	return OrderField{Key: key, Field: field, set: func(o *FormattedOrder, row OrderRow) {
+		if v, ok := row.Int64(key); ok {
+			set(o, &v)
+		}
+	}}
+}
+
+func float64Field(key, field string, set func(o *FormattedOrder, v float64)) OrderField {
+	return OrderField{Key: key, Field: field, set: func(o *FormattedOrder, row OrderRow) {
+		v, _ := row.Float64(key)
+		set(o, v)
+	}}
+}
+
+func optionalFloat64Field(key, field string, set func(o *FormattedOrder, v *float64)) OrderField {
+	return OrderField{Key: key, Field: field, set: func(o *FormattedOrder, row OrderRow) {
+		if v, ok := row.Float64(key); ok {
+			set(o, &v)
+		}
+	}}
+}
+
+func stringField(key, field string, set func(o *FormattedOrder, v string)) OrderField {
+	return OrderField{Key: key, Field: field, set: func(o *FormattedOrder, row OrderRow) {
+		v, _ := row.String(key)
+		set(o, v)
+	}}
+}
+
+func optionalStringField(key, field string, set func(o *FormattedOrder, v *string)) OrderField {
+	return OrderField{Key: key, Field: field, set: func(o *FormattedOrder, row OrderRow) {
+		if v, ok := row.String(key); ok {
+			set(o, &v)
+		}
+	}}
+}
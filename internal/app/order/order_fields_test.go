@@ -0,0 +1,76 @@
+package order
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderFields_CoverFormattedOrder(t *testing.T) {
+	mapped := make(map[string]bool, len(orderFields))
+	for _, field := range orderFields {
+		require.False(t, mapped[field.Field], "%s is mapped twice", field.Field)
+		mapped[field.Field] = true
+	}
+
+	var leaves []string
+	var walk func(typ reflect.Type, prefix string)
+	walk = func(typ reflect.Type, prefix string) {
+		for i := range typ.NumField() {
+			f := typ.Field(i)
+			switch {
+			case prefix == "" && (f.Name == "Address" || f.Name == "Options" || f.Name == "Warnings" || f.Name == "WaitTime"):
+			case f.Type.Kind() == reflect.Struct:
+				walk(f.Type, prefix+f.Name+".")
+			default:
+				leaves = append(leaves, prefix+f.Name)
+			}
+		}
+	}
+	walk(reflect.TypeOf(FormattedOrder{}), "")
+
+	for _, leaf := range leaves {
+		require.True(t, mapped[leaf], "%s has no entry in the field table", leaf)
+		delete(mapped, leaf)
+	}
+	require.Empty(t, mapped, "the field table names fields FormattedOrder does not have")
+}
+
+func TestMapFullOrderToFormatted_FillsFlatAndNestedFields(t *testing.T) {
+	s := newServiceWithRepo(&stubRepository{})
+	o := FullOrder{
+		OrderID:        11,
+		TenantID:       68,
+		WorkerID:       sql.NullInt64{Int64: 7, Valid: true},
+		CarID:          sql.NullInt64{Int64: 9, Valid: true},
+		StatusID:       26,
+		StatusStatusID: 26,
+		Payment:        sql.NullString{String: "CASH", Valid: true},
+		PredvPrice:     sql.NullFloat64{Float64: 300, Valid: true},
+		SummaryCost:    sql.NullString{String: "350.5", Valid: true},
+		WorkerWorkerID: sql.NullInt64{Int64: 7, Valid: true},
+		WorkerName:     sql.NullString{String: "Ivan", Valid: true},
+		CarCarID:       sql.NullInt64{Int64: 9, Valid: true},
+		CarColor:       sql.NullInt64{Int64: 3, Valid: true},
+		UserUserID:     sql.NullInt64{Int64: 5, Valid: true},
+	}
+
+	got := s.MapFullOrderToFormatted(o, nil, nil)
+
+	require.Equal(t, int64(7), *got.WorkerID)
+	require.Equal(t, int64(7), got.Worker.WorkerID)
+	require.Equal(t, "Ivan", *got.WName)
+	require.Equal(t, "Ivan", *got.Worker.Name)
+	require.Equal(t, "3", *got.CarColor)
+	require.Equal(t, int64(3), *got.Car.Color)
+	require.Equal(t, int64(5), got.UserID)
+	require.Equal(t, "CASH", got.Payment)
+	require.Equal(t, 350.5, got.PredvPrice, "the detail cost outranks the estimate")
+	require.Nil(t, got.Comment)
+	require.NotNil(t, BuildWorker(got))
+	require.NotNil(t, BuildCar(got))
+
+	require.Empty(t, MissingOrderFields(FullOrder{}.Row()), "MySQL fills every field of the table")
+}
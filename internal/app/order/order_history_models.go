@@ -0,0 +1,55 @@
+package order
+
+// Change fields of tbl_order_change_data the history gives a meaning to. Other
+// fields are reported with their raw value.
+const (
+	ChangeFieldStatus = "status_id"
+	ChangeFieldWorker = "worker_id"
+)
+
+// OrderHistoryFilter selects one page of the change history of an order.
+type OrderHistoryFilter struct {
+	TenantID int64
+	OrderID  int64
+	Language string
+	Page     int
+	PageSize int
+}
+
+// OrderHistoryHead is what the history needs to know about the order itself:
+// its scope, its current status and the number of its changes.
+type OrderHistoryHead struct {
+	CityID      int64
+	PositionID  int64
+	StatusID    int64
+	ChangeCount int64
+}
+
+// OrderChange is one row of tbl_order_change_data. StatusName is the untranslated
+// name of the status a status change set.
+type OrderChange struct {
+	Field      string
+	Value      string
+	ChangeTime int64
+	StatusName string
+}
+
+type OrderHistory struct {
+	OrderID    int64
+	CityID     int64
+	PositionID int64
+	TotalCount int64
+	Changes    []OrderHistoryEntry
+}
+
+// OrderHistoryEntry is one change of the timeline. Status and StatusDuration
+// are only set for status changes; StatusDuration is the seconds the order
+// spent in the status, up to now for the current status, and nil for the
+// final status of a finished order.
+type OrderHistoryEntry struct {
+	Field          string
+	Value          string
+	ChangeTime     int64
+	Status         *OrderStatusView
+	StatusDuration *int64
+}
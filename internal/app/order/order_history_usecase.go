@@ -0,0 +1,168 @@
+package order
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"orders-service/internal/logging"
+)
+
+// GetOrderHistory returns one page of the changes of an order, oldest first.
+// Status durations are computed from every status change of the order, so
+// they are right on each page even when the next status is on a later one.
+func (s *service) GetOrderHistory(ctx context.Context, f OrderHistoryFilter) (OrderHistory, error) {
+	totalStarted := time.Now()
+
+	started := time.Now()
+	head, found, err := s.historyReader.FetchOrderHistoryHead(ctx, f.TenantID, f.OrderID)
+	headMS := observeStage("order_history", "head_fetch", started)
+	if err != nil {
+		logging.Error(ctx, "order history head fetch failed", err, "duration_ms", headMS)
+		return OrderHistory{}, err
+	}
+	if !found {
+		return OrderHistory{}, ErrOrderNotFound
+	}
+
+	history := OrderHistory{
+		OrderID:    f.OrderID,
+		CityID:     head.CityID,
+		PositionID: head.PositionID,
+		TotalCount: head.ChangeCount,
+		Changes:    []OrderHistoryEntry{},
+	}
+	offset := f.Page * f.PageSize
+	if int64(offset) >= head.ChangeCount {
+		return history, nil
+	}
+
+	started = time.Now()
+	changes, err := s.historyReader.FetchOrderChanges(ctx, f.OrderID, offset, f.PageSize)
+	changesMS := observeStage("order_history", "changes_fetch", started)
+	if err != nil {
+		logging.Error(ctx, "order history changes fetch failed", err, "duration_ms", changesMS)
+		return OrderHistory{}, err
+	}
+
+	var durations map[statusChangeKey]*int64
+	if hasStatusChange(changes) {
+		started = time.Now()
+		statusChanges, err := s.historyReader.FetchOrderStatusChanges(ctx, f.OrderID)
+		statusMS := observeStage("order_history", "status_changes_fetch", started)
+		if err != nil {
+			logging.Error(ctx, "order history status changes fetch failed", err, "duration_ms", statusMS)
+			return OrderHistory{}, err
+		}
+		finished := s.isFinalStatus(f.TenantID, head.StatusID)
+		durations = statusDurations(statusChanges, finished, s.clock().Unix())
+	}
+
+	translated := make(map[string]string)
+	for _, change := range changes {
+		entry := OrderHistoryEntry{
+			Field:      change.Field,
+			Value:      change.Value,
+			ChangeTime: change.ChangeTime,
+		}
+		if change.Field == ChangeFieldStatus {
+			status, err := s.historyStatusView(ctx, f, change, translated)
+			if err != nil {
+				logging.Error(ctx, "order history status translation failed", err, "order_id", f.OrderID)
+				return OrderHistory{}, err
+			}
+			entry.Status = &status
+			entry.StatusDuration = durations[statusChangeKey{time: change.ChangeTime, value: change.Value}]
+		}
+		history.Changes = append(history.Changes, entry)
+	}
+
+	observeStage("order_history", "total", totalStarted)
+	logging.Info(ctx, "order history done",
+		"tenant_id", f.TenantID,
+		"order_id", f.OrderID,
+		"changes", len(history.Changes),
+		"total_count", history.TotalCount,
+	)
+
+	return history, nil
+}
+
+func (s *service) historyStatusView(
+	ctx context.Context,
+	f OrderHistoryFilter,
+	change OrderChange,
+	translated map[string]string,
+) (OrderStatusView, error) {
+	statusID, _ := strconv.ParseInt(change.Value, 10, 64)
+	name := change.StatusName
+	if s.statusTranslator != nil && name != "" {
+		if cached, ok := translated[name]; ok {
+			name = cached
+		} else {
+			value, err := s.statusTranslator.TranslateStatus(ctx, f.Language, name)
+			if err != nil {
+				return OrderStatusView{}, err
+			}
+			if value != "" {
+				translated[name] = value
+				name = value
+			}
+		}
+	}
+
+	statuses := s.statusRegistry()
+	return OrderStatusView{
+		StatusID: statusID,
+		Name:     name,
+		Category: statuses.Category(f.TenantID, statusID),
+		Color:    statuses.Color(f.TenantID, statusID),
+	}, nil
+}
+
+// isFinalStatus reports whether an order in the status will not change its
+// status again, so its last status has no duration.
+func (s *service) isFinalStatus(tenantID, statusID int64) bool {
+	statuses := s.statusRegistry()
+	return statuses.InGroup(tenantID, statusID, "completed") ||
+		statuses.InGroup(tenantID, statusID, "rejected")
+}
+
+type statusChangeKey struct {
+	time  int64
+	value string
+}
+
+// statusDurations maps every status change to the seconds until the next one.
+// The last status lasts until now unless the order is finished.
+func statusDurations(changes []OrderChange, finished bool, now int64) map[statusChangeKey]*int64 {
+	durations := make(map[statusChangeKey]*int64, len(changes))
+	for i, change := range changes {
+		key := statusChangeKey{time: change.ChangeTime, value: change.Value}
+		if _, ok := durations[key]; ok {
+			continue
+		}
+
+		var duration int64
+		switch {
+		case i+1 < len(changes):
+			duration = changes[i+1].ChangeTime - change.ChangeTime
+		case finished:
+			durations[key] = nil
+			continue
+		default:
+			duration = max(now-change.ChangeTime, 0)
+		}
+		durations[key] = &duration
+	}
+	return durations
+}
+
+func hasStatusChange(changes []OrderChange) bool {
+	for _, change := range changes {
+		if change.Field == ChangeFieldStatus {
+			return true
+		}
+	}
+	return false
+}
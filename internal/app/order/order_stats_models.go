@@ -0,0 +1,76 @@
+package order
+
+// Groupings of the order statistics.
+const (
+	StatsGroupCategory   = "category"
+	StatsGroupTariff     = "tariff"
+	StatsGroupCity       = "city"
+	StatsGroupDevice     = "device"
+	StatsGroupHour       = "hour"
+	StatsGroupDispatcher = "dispatcher"
+)
+
+// StatsGroups lists the groupings of the order statistics.
+func StatsGroups() []string {
+	return []string{
+		StatsGroupCategory,
+		StatsGroupTariff,
+		StatsGroupCity,
+		StatsGroupDevice,
+		StatsGroupHour,
+		StatsGroupDispatcher,
+	}
+}
+
+// IsStatsGroup reports whether the statistics can be grouped by group.
+func IsStatsGroup(group string) bool {
+	for _, value := range StatsGroups() {
+		if value == group {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatsFilter selects the orders of the all-orders search and groups
+// them by GroupBy. Paging, cursor and sort of the search are ignored.
+type OrderStatsFilter struct {
+	GetAllOrdersFilter
+
+	GroupBy string
+}
+
+// OrderStatsRow aggregates the orders of one group key and status. The
+// repository groups by status as well, so statuses can be folded into
+// categories and the completion ratio with the registry of the tenant.
+// For the category grouping Key is the status id.
+type OrderStatsRow struct {
+	Key           string
+	StatusID      int64
+	Count         int64
+	SummaryCost   float64
+	DistanceSum   float64
+	DistanceCount int64
+	TimeSum       float64
+	TimeCount     int64
+}
+
+type OrderStats struct {
+	GroupBy string
+	Total   OrderStatsGroup
+	Groups  []OrderStatsGroup
+}
+
+// OrderStatsGroup holds the aggregates of one group. The averages are nil
+// when no order of the group has a predicted distance or time.
+type OrderStatsGroup struct {
+	Key              string
+	Count            int64
+	SummaryCost      float64
+	AvgPredvDistance *float64
+	AvgPredvTime     *float64
+	CompletedCount   int64
+	RejectedCount    int64
+	CompletionRatio  float64
+	RejectionRatio   float64
+}
@@ -0,0 +1,228 @@
+package order
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"orders-service/internal/logging"
+)
+
+// GetOrderStats aggregates the orders of the all-orders search. MySQL
+// aggregates in SQL, the active Redis orders of the open groups are folded in
+// and deduplicated like GetAllOrders merges them, so the total count matches
+// its orderTotalCount.
+func (s *service) GetOrderStats(ctx context.Context, f OrderStatsFilter) (OrderStats, error) {
+	totalStarted := time.Now()
+	var redisFetchMS int64
+	var mysqlAggregateMS int64
+	var dedupeMS int64
+
+	stats := OrderStats{GroupBy: f.GroupBy, Groups: []OrderStatsGroup{}}
+	statusIDs, filtered := s.statusRegistry().SearchStatusIDs(f.TenantID, f.SearchStatus)
+	if filtered && len(statusIDs) == 0 {
+		return stats, nil
+	}
+	f.Status = statusIDs
+
+	var redisFormatted []FormattedOrder
+	if s.activeOrdersReader != nil && shouldFetchRedisForGetAll(f.SearchStatus) {
+		started := time.Now()
+		var err error
+		redisFormatted, err = s.activeOrdersReader.GetFormattedActiveOrders(ctx, f.TenantID)
+		redisFetchMS = observeStage("order_stats", "redis_fetch", started)
+		if err != nil {
+			if !Degrade(ctx, DegradedActiveOrders, err) {
+				logging.Error(ctx, "order stats redis fetch failed", err, "duration_ms", redisFetchMS)
+				return OrderStats{}, err
+			}
+		}
+	}
+
+	// Like GetAllOrders the fresher record is picked before the filter.
+	var duplicates getAllDuplicates
+	if shouldDedupeGetAll(f.SearchStatus) && len(redisFormatted) > 0 {
+		started := time.Now()
+		var err error
+		redisFormatted, duplicates, err = s.dedupeGetAllOrders(ctx, f.TenantID, redisFormatted)
+		dedupeMS = observeStage("order_stats", "dedupe", started)
+		if err != nil {
+			logging.Error(ctx, "order stats dedupe failed", err, "duration_ms", dedupeMS)
+			return OrderStats{}, err
+		}
+	}
+	redisOrders := filterGetAllRedisOrders(redisFormatted, f.GetAllOrdersFilter)
+	f.ExcludedOrderIDs = duplicates.keptRedis
+
+	var rows []OrderStatsRow
+	if shouldFetchMySQLForGetAll(f.SearchStatus) {
+		started := time.Now()
+		mysqlRows, err := s.statsReader.AggregateOrders(ctx, f)
+		mysqlAggregateMS = observeStage("order_stats", "mysql_aggregate", started)
+		if err != nil {
+			logging.Error(ctx, "order stats mysql aggregate failed", err, "duration_ms", mysqlAggregateMS)
+			return OrderStats{}, err
+		}
+		rows = mysqlRows
+	}
+	mysqlRowCount := len(rows)
+	for _, value := range redisOrders {
+		rows = append(rows, orderStatsRow(value, f.GroupBy))
+	}
+
+	started := time.Now()
+	stats = foldOrderStats(rows, f.GroupBy, s.statusRegistry(), f.TenantID)
+	observeStage("order_stats", "fold", started)
+
+	observeStage("order_stats", "total", totalStarted)
+	logging.Info(ctx, "order stats done",
+		"tenant_id", f.TenantID,
+		"group_by", f.GroupBy,
+		"search_status", f.SearchStatus,
+		"mysql_rows", mysqlRowCount,
+		"redis_matched_count", len(redisOrders),
+		"duplicates_kept_mysql", duplicates.keptMySQL,
+		"duplicates_kept_redis", len(duplicates.keptRedis),
+		"dedupe_ms", dedupeMS,
+		"groups", len(stats.Groups),
+		"total_count", stats.Total.Count,
+	)
+
+	return stats, nil
+}
+
+// orderStatsRow turns an active order into a row of its own, keyed like the
+// SQL of the MySQL repository keys the group.
+func orderStatsRow(o FormattedOrder, groupBy string) OrderStatsRow {
+	row := OrderStatsRow{
+		StatusID:      o.StatusID,
+		Count:         1,
+		DistanceSum:   o.PredvDistance,
+		DistanceCount: 1,
+		TimeSum:       float64(o.PredvTime),
+		TimeCount:     1,
+	}
+	if o.SummaryCost != nil {
+		// CAST in MySQL turns text that is not a number into 0 as well.
+		row.SummaryCost, _ = strconv.ParseFloat(strings.TrimSpace(*o.SummaryCost), 64)
+	}
+
+	switch groupBy {
+	case StatsGroupCategory:
+		row.Key = strconv.FormatInt(o.StatusID, 10)
+	case StatsGroupTariff:
+		row.Key = strconv.FormatInt(o.TariffID, 10)
+	case StatsGroupCity:
+		row.Key = strconv.FormatInt(o.CityID, 10)
+	case StatsGroupDevice:
+		row.Key = o.Device
+	case StatsGroupHour:
+		row.Key = strconv.FormatInt(o.OrderTime%86400/3600, 10)
+	case StatsGroupDispatcher:
+		row.Key = strconv.FormatInt(o.UserCreate, 10)
+	}
+	return row
+}
+
+type orderStatsTotals struct {
+	count         int64
+	summaryCost   float64
+	distanceSum   float64
+	distanceCount int64
+	timeSum       float64
+	timeCount     int64
+	completed     int64
+	rejected      int64
+}
+
+func (t *orderStatsTotals) add(row OrderStatsRow, completed, rejected bool) {
+	t.count += row.Count
+	t.summaryCost += row.SummaryCost
+	t.distanceSum += row.DistanceSum
+	t.distanceCount += row.DistanceCount
+	t.timeSum += row.TimeSum
+	t.timeCount += row.TimeCount
+	if completed {
+		t.completed += row.Count
+	}
+	if rejected {
+		t.rejected += row.Count
+	}
+}
+
+func (t orderStatsTotals) group(key string) OrderStatsGroup {
+	group := OrderStatsGroup{
+		Key:            key,
+		Count:          t.count,
+		SummaryCost:    t.summaryCost,
+		CompletedCount: t.completed,
+		RejectedCount:  t.rejected,
+	}
+	if t.distanceCount > 0 {
+		avg := t.distanceSum / float64(t.distanceCount)
+		group.AvgPredvDistance = &avg
+	}
+	if t.timeCount > 0 {
+		avg := t.timeSum / float64(t.timeCount)
+		group.AvgPredvTime = &avg
+	}
+	if t.count > 0 {
+		group.CompletionRatio = float64(t.completed) / float64(t.count)
+		group.RejectionRatio = float64(t.rejected) / float64(t.count)
+	}
+	return group
+}
+
+// foldOrderStats sums the rows by group key. For the category grouping the
+// key is the category of the status in the registry of the tenant.
+func foldOrderStats(rows []OrderStatsRow, groupBy string, statuses *StatusRegistry, tenantID int64) OrderStats {
+	var total orderStatsTotals
+	groups := make(map[string]*orderStatsTotals)
+	for _, row := range rows {
+		key := row.Key
+		if groupBy == StatsGroupCategory {
+			key = statuses.Category(tenantID, row.StatusID)
+		}
+		completed := statuses.InGroup(tenantID, row.StatusID, "completed")
+		rejected := statuses.InGroup(tenantID, row.StatusID, "rejected")
+
+		totals := groups[key]
+		if totals == nil {
+			totals = &orderStatsTotals{}
+			groups[key] = totals
+		}
+		totals.add(row, completed, rejected)
+		total.add(row, completed, rejected)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return statsKeyLess(keys[i], keys[j])
+	})
+
+	stats := OrderStats{
+		GroupBy: groupBy,
+		Total:   total.group(""),
+		Groups:  make([]OrderStatsGroup, 0, len(keys)),
+	}
+	for _, key := range keys {
+		stats.Groups = append(stats.Groups, groups[key].group(key))
+	}
+	return stats
+}
+
+// statsKeyLess orders numeric keys like ids and hours by value and any other
+// key as text.
+func statsKeyLess(a, b string) bool {
+	left, leftErr := strconv.ParseInt(a, 10, 64)
+	right, rightErr := strconv.ParseInt(b, 10, 64)
+	if leftErr == nil && rightErr == nil {
+		return left < right
+	}
+	return a < b
+}
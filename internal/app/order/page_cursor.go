@@ -0,0 +1,123 @@
+package order
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+var allowedSortFields = map[string]struct{}{
+	"o.status_time": {},
+	"o.order_id":    {},
+	"o.order_time":  {},
+}
+
+// NormalizeSortField returns the sort column for an order list. Unknown fields
+// fall back to o.status_time.
+func NormalizeSortField(value string) string {
+	if _, ok := allowedSortFields[value]; ok {
+		return value
+	}
+
+	return "o.status_time"
+}
+
+// IsSortField reports whether value is a sort column of the order lists.
+func IsSortField(value string) bool {
+	_, ok := allowedSortFields[value]
+	return ok
+}
+
+func SortDescending(value string) bool {
+	return !strings.EqualFold(value, "asc")
+}
+
+// PageCursor points at the last row of a page. The next page starts right
+// after it in the (SortField, order_id) order, so rows that change between
+// page loads are neither skipped nor repeated.
+type PageCursor struct {
+	SortField string `json:"f"`
+	Desc      bool   `json:"d"`
+	Value     int64  `json:"v"`
+	OrderID   int64  `json:"id"`
+}
+
+func (c PageCursor) Encode() string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// ParseCursor decodes an opaque cursor. An empty value means offset mode and
+// returns nil. The cursor must have been issued for the same sort.
+func ParseCursor(raw, sortField, sortOrder string) (*PageCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c PageCursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, ok := allowedSortFields[c.SortField]; !ok {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortField != sortField || c.Desc != SortDescending(sortOrder) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// Follows reports whether a row with the given sort value and order id comes
+// after the cursor. It mirrors the keyset condition of the MySQL queries.
+func (c PageCursor) Follows(value, orderID int64) bool {
+	if c.SortField != "o.order_id" && value != c.Value {
+		if c.Desc {
+			return value < c.Value
+		}
+		return value > c.Value
+	}
+	if c.Desc {
+		return orderID < c.OrderID
+	}
+	return orderID > c.OrderID
+}
+
+func (c PageCursor) FollowedBy(o FormattedOrder) bool {
+	return c.Follows(SortValue(o, c.SortField), o.OrderID)
+}
+
+func SortValue(o FormattedOrder, sortField string) int64 {
+	switch sortField {
+	case "o.order_id":
+		return o.OrderID
+	case "o.order_time":
+		return o.OrderTime
+	default:
+		return o.StatusTime
+	}
+}
+
+// NextPageCursor builds the cursor for the page after orders. A page shorter
+// than pageSize is the last one and yields nil.
+func NextPageCursor(orders []FormattedOrder, sortField string, desc bool, pageSize int) *PageCursor {
+	if len(orders) == 0 || len(orders) < pageSize {
+		return nil
+	}
+
+	last := orders[len(orders)-1]
+	return &PageCursor{
+		SortField: sortField,
+		Desc:      desc,
+		Value:     SortValue(last, sortField),
+		OrderID:   last.OrderID,
+	}
+}
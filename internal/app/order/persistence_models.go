@@ -1,6 +1,9 @@
 package order
 
-import "database/sql"
+import (
+	"database/sql"
+	"strconv"
+)
 
 // FullOrder is a persistence row shape for the wide MySQL query.
 // It intentionally stays close to Scan order and SQL nullability.
@@ -118,23 +121,153 @@ type FullOrder struct {
 	CurrencySymbol sql.NullString
 }
 
-func nullableInt64(v sql.NullInt64) *int64 {
-	if !v.Valid {
-		return nil
+// Row keys the order like the payload of an active order in Redis, so
+// FormatOrderRow maps both sources alike. The o.* columns keep their names,
+// the joined columns take the aliases of the payload.
+func (o FullOrder) Row() OrderRow {
+	return sqlRow{
+		"order_id":                      o.OrderID,
+		"tenant_id":                     o.TenantID,
+		"worker_id":                     o.WorkerID,
+		"car_id":                        o.CarID,
+		"city_id":                       o.CityID,
+		"tariff_id":                     o.TariffID,
+		"user_create":                   o.UserCreate,
+		"status_id":                     o.StatusID,
+		"user_modifed":                  o.UserModified,
+		"company_id":                    o.CompanyID,
+		"parking_id":                    o.ParkingID,
+		"comment":                       o.Comment,
+		"predv_price":                   o.PredvPrice,
+		"predv_price_no_discount":       o.PredvPriceNoDiscount,
+		"device":                        o.Device,
+		"order_number":                  o.OrderNumber,
+		"payment":                       o.Payment,
+		"show_phone":                    o.ShowPhone,
+		"create_time":                   o.CreateTime,
+		"status_time":                   o.StatusTime,
+		"time_to_client":                o.TimeToClient,
+		"client_device_token":           o.ClientDeviceToken,
+		"app_id":                        o.AppID,
+		"order_time":                    o.OrderTime,
+		"predv_distance":                o.PredvDistance,
+		"predv_time":                    o.PredvTime,
+		"call_warning_id":               o.CallWarningID,
+		"phone":                         o.Phone,
+		"client_id":                     o.ClientID,
+		"bonus_payment":                 o.BonusPayment,
+		"currency_id":                   o.CurrencyID,
+		"time_offset":                   o.TimeOffset,
+		"is_fix":                        o.IsFix,
+		"update_time":                   o.UpdateTime,
+		"deny_refuse_order":             o.DenyRefuseOrder,
+		"position_id":                   o.PositionID,
+		"promo_code_id":                 o.PromoCodeID,
+		"tenant_company_id":             o.TenantCompanyID,
+		"mark":                          o.Mark,
+		"processed_exchange_program_id": o.ProcessedExchangeProgramID,
+		"client_passenger_id":           o.ClientPassengerID,
+		"client_passenger_phone":        o.ClientPassengerPhone,
+		"active":                        o.Active,
+		"is_pre_order":                  o.IsPreOrder,
+		"app_version":                   o.AppVersion,
+		"agent_commission":              o.AgentCommission,
+		"is_fix_by_dispatcher":          o.IsFixByDispatcher,
+		"finish_time":                   o.FinishTime,
+		"comment_for_dispatcher":        o.CommentForDispatcher,
+		"worker_manual_surcharge":       o.WorkerManualSurcharge,
+		"realtime_price":                o.RealtimePrice,
+		"unit_quantity":                 o.UnitQuantity,
+		"shop_id":                       o.ShopID,
+		"require_prepayment":            o.RequirePrepayment,
+		"order_code":                    o.OrderCode,
+		"client_offered_price":          o.ClientOfferedPrice,
+		"idempotent_key":                o.IdempotentKey,
+		"additional_tariff_id":          o.AdditionalTariffID,
+		"initial_price":                 o.InitialPrice,
+		"time_to_order":                 o.TimeToOrder,
+		"sort":                          o.Sort,
+		"summary_cost":                  o.SummaryCost,
+		"summary_cost_no_discount":      o.SummaryCostNoDiscount,
+
+		"status_status_id": o.StatusStatusID,
+		"status_name":      o.StatusName,
+
+		"callsign":    o.WorkerCallsign,
+		"wName":       o.WorkerName,
+		"wLastName":   o.WorkerLastName,
+		"wSecondName": o.WorkerSecondName,
+		"wPhone":      o.WorkerPhone,
+
+		"cPhone":      o.ClientPhone,
+		"cName":       o.ClientName,
+		"cLastName":   o.ClientLastName,
+		"cSecondName": o.ClientSecondName,
+
+		"car_name":       o.CarName,
+		"car_color":      o.CarColor,
+		"car_gos_number": o.CarGosNumber,
+
+		"tariff_type":        o.TariffType,
+		"tName":              o.TariffName,
+		"quantitative_title": o.TariffQuantitativeTitle,
+		"price_for_unit":     o.TariffPriceForUnit,
+		"unit_name":          o.TariffUnitName,
+
+		"user_id":     o.UserUserID,
+		"uName":       o.UserName,
+		"uLastName":   o.UserLastName,
+		"uSecondName": o.UserSecondName,
+
+		"currency_name": o.CurrencyName,
+		"currency_code": o.CurrencyCode,
+		"symbol":        o.CurrencySymbol,
+	}
+}
+
+// sqlRow holds scanned values; a NULL column is present but not ok.
+type sqlRow map[string]any
+
+func (r sqlRow) Has(key string) bool {
+	_, ok := r[key]
+	return ok
+}
+
+func (r sqlRow) Int64(key string) (int64, bool) {
+	switch v := r[key].(type) {
+	case int64:
+		return v, true
+	case sql.NullInt64:
+		return v.Int64, v.Valid
+	default:
+		return 0, false
 	}
-	return &v.Int64
 }
 
-func nullableString(v sql.NullString) *string {
-	if !v.Valid {
-		return nil
+func (r sqlRow) Float64(key string) (float64, bool) {
+	switch v := r[key].(type) {
+	case float64:
+		return v, true
+	case sql.NullFloat64:
+		return v.Float64, v.Valid
+	default:
+		return 0, false
 	}
-	return &v.String
 }
 
-func nullableFloat64(v sql.NullFloat64) *float64 {
-	if !v.Valid {
-		return nil
+func (r sqlRow) String(key string) (string, bool) {
+	switch v := r[key].(type) {
+	case string:
+		return v, true
+	case sql.NullString:
+		return v.String, v.Valid
+	case sql.NullInt64:
+		// car.color is numeric in MySQL, the flat car_color is its text.
+		if !v.Valid {
+			return "", false
+		}
+		return strconv.FormatInt(v.Int64, 10), true
+	default:
+		return "", false
 	}
-	return &v.Float64
 }
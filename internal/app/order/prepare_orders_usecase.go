@@ -41,7 +41,7 @@ func (s *service) PrepareOrdersData(
 
 	started := time.Now()
 	statusChangeTimes, err := s.loadStatusChangeTimes(ctx, uniqueOrders)
-	statusChangeMS = time.Since(started).Milliseconds()
+	statusChangeMS = observeStage("prepare_orders", "status_change", started)
 	if err != nil {
 		logging.Error(ctx, "prepare orders status changes failed", err, "duration_ms", statusChangeMS)
 		return nil, err
@@ -50,16 +50,14 @@ func (s *service) PrepareOrdersData(
 	started = time.Now()
 	if batchAssembler, ok := assembler.(OrderViewsAssembler); ok {
 		result, err := batchAssembler.BuildOrderViews(ctx, uniqueOrders, f, statusChangeTimes)
-		buildViewsMS = time.Since(started).Milliseconds()
+		buildViewsMS = observeStage("prepare_orders", "build_views", started)
 		if err != nil {
 			logging.Error(ctx, "prepare orders build views failed", err, "duration_ms", buildViewsMS, "batch", true)
 			return nil, err
 		}
 
-		logging.Info(ctx, "prepare orders timings",
-			"total_ms", time.Since(totalStarted).Milliseconds(),
-			"status_change_ms", statusChangeMS,
-			"build_views_ms", buildViewsMS,
+		observeStage("prepare_orders", "total", totalStarted)
+		logging.Info(ctx, "prepare orders done",
 			"orders_count", len(orders),
 			"unique_orders_count", len(uniqueOrders),
 			"prepared_count", len(result),
@@ -72,18 +70,16 @@ func (s *service) PrepareOrdersData(
 	for _, o := range uniqueOrders {
 		prepared, err := assembler.BuildOrderView(ctx, o, f, statusChangeTimes)
 		if err != nil {
-			buildViewsMS = time.Since(started).Milliseconds()
+			buildViewsMS = observeStage("prepare_orders", "build_views", started)
 			logging.Error(ctx, "prepare orders build views failed", err, "duration_ms", buildViewsMS, "batch", false)
 			return nil, err
 		}
 		result = append(result, prepared)
 	}
-	buildViewsMS = time.Since(started).Milliseconds()
+	buildViewsMS = observeStage("prepare_orders", "build_views", started)
 
-	logging.Info(ctx, "prepare orders timings",
-		"total_ms", time.Since(totalStarted).Milliseconds(),
-		"status_change_ms", statusChangeMS,
-		"build_views_ms", buildViewsMS,
+	observeStage("prepare_orders", "total", totalStarted)
+	logging.Info(ctx, "prepare orders done",
 		"orders_count", len(orders),
 		"unique_orders_count", len(uniqueOrders),
 		"prepared_count", len(result),
@@ -1,18 +1,5 @@
 package order
 
-var redStatuses = map[int64]struct{}{
-	10: {}, 16: {}, 27: {}, 30: {}, 38: {},
-	39: {}, 52: {}, 54: {}, 117: {}, 118: {},
-	120: {}, 135: {},
-}
-
-func GetColor(statusID int64) string {
-	if _, ok := redStatuses[statusID]; ok {
-		return "#cc1919"
-	}
-	return "#088142"
-}
-
 func BuildDispatcher(o FormattedOrder) any {
 	if o.Device == DeviceDispatcher {
 		return map[string]any{
@@ -1,6 +1,14 @@
 package order
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"orders-service/internal/metrics"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
 
 type SortOrder string
 
@@ -19,6 +27,10 @@ type BaseFilter struct {
 
 	SortField string
 	SortOrder string
+	// Sort replaces SortField and SortOrder with several keys when set.
+	Sort []SortKey
+	// Cursor switches the list from page/offset to keyset pagination.
+	Cursor *PageCursor
 }
 
 type UnpaidFilter struct {
@@ -47,12 +59,26 @@ type WarningFilter struct {
 	BadRatingMax           int64
 	StatusCompletedNotPaid int64
 	MinRealPrice           float64
+	// Rules overrides the tenant configuration of warning rules by rule code.
+	Rules WarningRuleSettings
+}
+
+// WarningCandidateFilter selects the active orders a time based warning rule
+// looks at. WithoutWorker keeps only orders no worker is assigned to.
+type WarningCandidateFilter struct {
+	BaseFilter    BaseFilter
+	StatusIDs     []int64
+	WithoutWorker bool
 }
 
 type WarningOrderReader interface {
 	FetchUnpaid(ctx context.Context, filter UnpaidFilter) ([]int64, error)
-	FetchBadReview(ctx context.Context, f BadReviewFilter) ([]int64, error)
-	FetchExceededPrice(ctx context.Context, f ExceededPriceFilter) ([]int64, error)
+	FetchBadReview(ctx context.Context, f BadReviewFilter) ([]BadReviewWarning, error)
+	FetchExceededPrice(ctx context.Context, f ExceededPriceFilter) ([]ExceededPriceWarning, error)
+}
+
+type WarningCandidateReader interface {
+	FetchWarningCandidates(ctx context.Context, f WarningCandidateFilter) ([]WarningCandidate, error)
 }
 
 type OrderListReader interface {
@@ -76,8 +102,17 @@ type GroupOrderReader interface {
 	) ([]int64, error)
 }
 
+type OrderReader interface {
+	FetchOrderByID(ctx context.Context, tenantID, orderID int64) (FullOrder, bool, error)
+}
+
 type AllOrdersReader interface {
-	FetchAllOrdersForGetAll(ctx context.Context, f GetAllOrdersFilter) ([]FullOrder, error)
+	CountAllOrdersForGetAll(ctx context.Context, f GetAllOrdersFilter) (int64, error)
+	FetchAllOrdersForGetAll(
+		ctx context.Context,
+		f GetAllOrdersFilter,
+		offset, limit int,
+	) ([]FullOrder, error)
 }
 
 type OrderOptionsReader interface {
@@ -94,13 +129,32 @@ type StatusChangeReader interface {
 	) (map[StatusKey]int64, error)
 }
 
+// OrderHistoryReader reads the change history of an order from
+// tbl_order_change_data. FetchOrderHistoryHead reports false when the tenant
+// has no such order.
+type OrderHistoryReader interface {
+	FetchOrderHistoryHead(ctx context.Context, tenantID, orderID int64) (OrderHistoryHead, bool, error)
+	FetchOrderChanges(ctx context.Context, orderID int64, offset, limit int) ([]OrderChange, error)
+	FetchOrderStatusChanges(ctx context.Context, orderID int64) ([]OrderChange, error)
+}
+
+// OrderStatsReader aggregates the orders of the all-orders search by the
+// group key of f.GroupBy and status.
+type OrderStatsReader interface {
+	AggregateOrders(ctx context.Context, f OrderStatsFilter) ([]OrderStatsRow, error)
+}
+
 type Repository interface {
 	WarningOrderReader
+	WarningCandidateReader
 	OrderListReader
 	GroupOrderReader
 	AllOrdersReader
+	OrderReader
 	OrderOptionsReader
 	StatusChangeReader
+	OrderHistoryReader
+	OrderStatsReader
 }
 
 type OrderAddressResolver interface {
@@ -153,6 +207,7 @@ type OrderViewsAssembler interface {
 
 type ActiveOrdersReader interface {
 	GetFormattedActiveOrders(ctx context.Context, tenantID int64) ([]FormattedOrder, error)
+	GetFormattedActiveOrder(ctx context.Context, tenantID, orderID int64) (FormattedOrder, bool, error)
 }
 
 type Service interface {
@@ -172,6 +227,15 @@ type Service interface {
 		f WarningFilter,
 	) ([]OrderView, error)
 	GetAllOrders(ctx context.Context, f GetAllOrdersFilter) (GetAllOrdersResult, error)
+	ExportAllOrders(
+		ctx context.Context,
+		f GetAllOrdersFilter,
+		limit int,
+		emit func(page GetAllOrdersResult) error,
+	) error
+	GetOrder(ctx context.Context, f OrderFilter) (OrderView, error)
+	GetOrderHistory(ctx context.Context, f OrderHistoryFilter) (OrderHistory, error)
+	GetOrderStats(ctx context.Context, f OrderStatsFilter) (OrderStats, error)
 }
 
 type WarningGroupResult struct {
@@ -182,14 +246,106 @@ type WarningGroupResult struct {
 
 type service struct {
 	warningReader      WarningOrderReader
+	candidateReader    WarningCandidateReader
 	orderListReader    OrderListReader
 	groupOrderReader   GroupOrderReader
 	allOrdersReader    AllOrdersReader
+	orderReader        OrderReader
 	optionsReader      OrderOptionsReader
 	statusChangeReader StatusChangeReader
+	historyReader      OrderHistoryReader
+	statsReader        OrderStatsReader
 	activeOrdersReader ActiveOrdersReader
 	assembler          OrderViewAssembler
 	addressResolver    OrderAddressResolver
+	statuses           *StatusRegistry
+	statusTranslator   StatusTranslator
+	warningSettings    WarningSettingsProvider
+	warningRules       *WarningRuleRegistry
+	extraWarningRules  []WarningRule
+	warningCache       *WarningCache
+	now                func() time.Time
+}
+
+type Option func(*service)
+
+// WithStatusRegistry replaces the built-in status categories, tab groups and
+// colors.
+func WithStatusRegistry(registry *StatusRegistry) Option {
+	return func(s *service) {
+		s.statuses = registry
+	}
+}
+
+// WithStatusTranslator translates the status names of the order history.
+func WithStatusTranslator(translator StatusTranslator) Option {
+	return func(s *service) {
+		s.statusTranslator = translator
+	}
+}
+
+// WithWarningSettings loads the per tenant warning rule configuration.
+func WithWarningSettings(provider WarningSettingsProvider) Option {
+	return func(s *service) {
+		s.warningSettings = provider
+	}
+}
+
+// WithWarningRules registers additional warning rules. A rule with the code of
+// a built-in rule replaces it.
+func WithWarningRules(rules ...WarningRule) Option {
+	return func(s *service) {
+		s.extraWarningRules = append(s.extraWarningRules, rules...)
+	}
+}
+
+// WithWarningCache reads the warning sets from cache instead of running the
+// warning rules on every request.
+func WithWarningCache(cache *WarningCache) Option {
+	return func(s *service) {
+		s.warningCache = cache
+	}
+}
+
+func (s *service) clock() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+// observeStage records the duration of a pipeline step and returns it in
+// milliseconds for the error logs.
+func observeStage(pipeline, stage string, started time.Time) int64 {
+	return metrics.StageDuration.ObserveDuration(started, pipeline, stage).Milliseconds()
+}
+
+func (s *service) warningRuleRegistry() *WarningRuleRegistry {
+	if s.warningRules != nil {
+		return s.warningRules
+	}
+	return s.newWarningRuleRegistry()
+}
+
+func (s *service) newWarningRuleRegistry() *WarningRuleRegistry {
+	waits, _ := s.activeOrdersReader.(BulkWaitingTimeProvider)
+	registry := NewWarningRuleRegistry(builtinWarningRules(
+		s.warningReader,
+		s.candidateReader,
+		waits,
+		s.statusRegistry(),
+	)...)
+	for _, rule := range s.extraWarningRules {
+		registry.Register(rule)
+	}
+	return registry
+}
+
+func (s *service) statusRegistry() *StatusRegistry {
+	if s.statuses == nil {
+		return defaultStatuses
+	}
+	return s.statuses
 }
 
 func NewService(
@@ -197,16 +353,30 @@ func NewService(
 	activeOrdersReader ActiveOrdersReader,
 	addressResolver OrderAddressResolver,
 	assembler OrderViewAssembler,
+	opts ...Option,
 ) Service {
-	return &service{
+	s := &service{
 		warningReader:      repo,
+		candidateReader:    repo,
 		orderListReader:    repo,
 		groupOrderReader:   repo,
 		allOrdersReader:    repo,
+		orderReader:        repo,
 		optionsReader:      repo,
 		statusChangeReader: repo,
+		historyReader:      repo,
+		statsReader:        repo,
 		activeOrdersReader: activeOrdersReader,
 		assembler:          assembler,
 		addressResolver:    addressResolver,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.warningRules = s.newWarningRuleRegistry()
+	if s.warningCache != nil {
+		s.warningCache.load = s.GetWarningReasons
+	}
+
+	return s
 }
@@ -2,9 +2,12 @@ package order
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"sort"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -16,14 +19,21 @@ type MockRepository struct {
 
 type stubRepository struct {
 	fetchUnpaidFunc             func(ctx context.Context, f UnpaidFilter) ([]int64, error)
-	fetchBadReviewFunc          func(ctx context.Context, f BadReviewFilter) ([]int64, error)
-	fetchExceededPriceFunc      func(ctx context.Context, f ExceededPriceFilter) ([]int64, error)
-	fetchAllOrdersForGetAllFunc func(ctx context.Context, f GetAllOrdersFilter) ([]FullOrder, error)
+	fetchBadReviewFunc          func(ctx context.Context, f BadReviewFilter) ([]BadReviewWarning, error)
+	fetchExceededPriceFunc      func(ctx context.Context, f ExceededPriceFilter) ([]ExceededPriceWarning, error)
+	fetchWarningCandidatesFunc  func(ctx context.Context, f WarningCandidateFilter) ([]WarningCandidate, error)
+	countAllOrdersForGetAllFunc func(ctx context.Context, f GetAllOrdersFilter) (int64, error)
+	fetchAllOrdersForGetAllFunc func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error)
+	fetchOrderByIDFunc          func(ctx context.Context, tenantID, orderID int64) (FullOrder, bool, error)
 	countOrdersWithWarningFunc  func(ctx context.Context, f BaseFilter, warningIDs []int64) (int64, error)
 	fetchOrdersWithWarningFunc  func(ctx context.Context, f BaseFilter, warningIDs []int64, page, pageSize int) ([]FullOrder, error)
 	fetchOrdersByStatusGroup    func(ctx context.Context, f BaseFilter) ([]int64, error)
 	getOptionsForOrdersFunc     func(ctx context.Context, orderIDs []int64) (map[int64][]OptionDTO, error)
 	getStatusChangeTimesFunc    func(ctx context.Context, keys []StatusKey) (map[StatusKey]int64, error)
+	fetchOrderHistoryHeadFunc   func(ctx context.Context, tenantID, orderID int64) (OrderHistoryHead, bool, error)
+	fetchOrderChangesFunc       func(ctx context.Context, orderID int64, offset, limit int) ([]OrderChange, error)
+	fetchOrderStatusChangesFunc func(ctx context.Context, orderID int64) ([]OrderChange, error)
+	aggregateOrdersFunc         func(ctx context.Context, f OrderStatsFilter) ([]OrderStatsRow, error)
 }
 
 func (s stubRepository) FetchUnpaid(ctx context.Context, f UnpaidFilter) ([]int64, error) {
@@ -33,28 +43,53 @@ func (s stubRepository) FetchUnpaid(ctx context.Context, f UnpaidFilter) ([]int6
 	return s.fetchUnpaidFunc(ctx, f)
 }
 
-func (s stubRepository) FetchBadReview(ctx context.Context, f BadReviewFilter) ([]int64, error) {
+func (s stubRepository) FetchBadReview(ctx context.Context, f BadReviewFilter) ([]BadReviewWarning, error) {
 	if s.fetchBadReviewFunc == nil {
 		return nil, nil
 	}
 	return s.fetchBadReviewFunc(ctx, f)
 }
 
-func (s stubRepository) FetchExceededPrice(ctx context.Context, f ExceededPriceFilter) ([]int64, error) {
+func (s stubRepository) FetchExceededPrice(ctx context.Context, f ExceededPriceFilter) ([]ExceededPriceWarning, error) {
 	if s.fetchExceededPriceFunc == nil {
 		return nil, nil
 	}
 	return s.fetchExceededPriceFunc(ctx, f)
 }
 
+func (s stubRepository) FetchWarningCandidates(ctx context.Context, f WarningCandidateFilter) ([]WarningCandidate, error) {
+	if s.fetchWarningCandidatesFunc == nil {
+		return nil, nil
+	}
+	return s.fetchWarningCandidatesFunc(ctx, f)
+}
+
+func (s stubRepository) CountAllOrdersForGetAll(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+	if s.countAllOrdersForGetAllFunc == nil {
+		return 0, nil
+	}
+	return s.countAllOrdersForGetAllFunc(ctx, f)
+}
+
 func (s stubRepository) FetchAllOrdersForGetAll(
 	ctx context.Context,
 	f GetAllOrdersFilter,
+	offset, limit int,
 ) ([]FullOrder, error) {
 	if s.fetchAllOrdersForGetAllFunc == nil {
 		return nil, nil
 	}
-	return s.fetchAllOrdersForGetAllFunc(ctx, f)
+	return s.fetchAllOrdersForGetAllFunc(ctx, f, offset, limit)
+}
+
+func (s stubRepository) FetchOrderByID(
+	ctx context.Context,
+	tenantID, orderID int64,
+) (FullOrder, bool, error) {
+	if s.fetchOrderByIDFunc == nil {
+		return FullOrder{}, false, nil
+	}
+	return s.fetchOrderByIDFunc(ctx, tenantID, orderID)
 }
 
 func (s stubRepository) CountOrdersWithWarning(
@@ -111,29 +146,83 @@ func (s stubRepository) GetStatusChangeTimes(
 	return s.getStatusChangeTimesFunc(ctx, keys)
 }
 
+func (s stubRepository) FetchOrderHistoryHead(
+	ctx context.Context,
+	tenantID, orderID int64,
+) (OrderHistoryHead, bool, error) {
+	if s.fetchOrderHistoryHeadFunc == nil {
+		return OrderHistoryHead{}, false, nil
+	}
+	return s.fetchOrderHistoryHeadFunc(ctx, tenantID, orderID)
+}
+
+func (s stubRepository) FetchOrderChanges(
+	ctx context.Context,
+	orderID int64,
+	offset, limit int,
+) ([]OrderChange, error) {
+	if s.fetchOrderChangesFunc == nil {
+		return nil, nil
+	}
+	return s.fetchOrderChangesFunc(ctx, orderID, offset, limit)
+}
+
+func (s stubRepository) FetchOrderStatusChanges(ctx context.Context, orderID int64) ([]OrderChange, error) {
+	if s.fetchOrderStatusChangesFunc == nil {
+		return nil, nil
+	}
+	return s.fetchOrderStatusChangesFunc(ctx, orderID)
+}
+
+func (s stubRepository) AggregateOrders(ctx context.Context, f OrderStatsFilter) ([]OrderStatsRow, error) {
+	if s.aggregateOrdersFunc == nil {
+		return nil, nil
+	}
+	return s.aggregateOrdersFunc(ctx, f)
+}
+
 func (m *MockRepository) FetchUnpaid(ctx context.Context, f UnpaidFilter) ([]int64, error) {
 	args := m.Called(ctx, f)
 	return args.Get(0).([]int64), args.Error(1)
 }
 
-func (m *MockRepository) FetchBadReview(ctx context.Context, f BadReviewFilter) ([]int64, error) {
+func (m *MockRepository) FetchBadReview(ctx context.Context, f BadReviewFilter) ([]BadReviewWarning, error) {
 	args := m.Called(ctx, f)
-	return args.Get(0).([]int64), args.Error(1)
+	return args.Get(0).([]BadReviewWarning), args.Error(1)
 }
 
-func (m *MockRepository) FetchExceededPrice(ctx context.Context, f ExceededPriceFilter) ([]int64, error) {
+func (m *MockRepository) FetchExceededPrice(ctx context.Context, f ExceededPriceFilter) ([]ExceededPriceWarning, error) {
 	args := m.Called(ctx, f)
-	return args.Get(0).([]int64), args.Error(1)
+	return args.Get(0).([]ExceededPriceWarning), args.Error(1)
+}
+
+func (m *MockRepository) FetchWarningCandidates(ctx context.Context, f WarningCandidateFilter) ([]WarningCandidate, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]WarningCandidate), args.Error(1)
+}
+
+func (m *MockRepository) CountAllOrdersForGetAll(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func (m *MockRepository) FetchAllOrdersForGetAll(
 	ctx context.Context,
 	f GetAllOrdersFilter,
+	offset, limit int,
 ) ([]FullOrder, error) {
-	args := m.Called(ctx, f)
+	args := m.Called(ctx, f, offset, limit)
 	return args.Get(0).([]FullOrder), args.Error(1)
 }
 
+func (m *MockRepository) FetchOrderByID(
+	ctx context.Context,
+	tenantID, orderID int64,
+) (FullOrder, bool, error) {
+	args := m.Called(ctx, tenantID, orderID)
+	return args.Get(0).(FullOrder), args.Bool(1), args.Error(2)
+}
+
 func (m *MockRepository) CountOrdersWithWarning(
 	ctx context.Context,
 	f BaseFilter,
@@ -178,6 +267,33 @@ func (m *MockRepository) GetStatusChangeTimes(
 	return args.Get(0).(map[StatusKey]int64), args.Error(1)
 }
 
+func (m *MockRepository) FetchOrderHistoryHead(
+	ctx context.Context,
+	tenantID, orderID int64,
+) (OrderHistoryHead, bool, error) {
+	args := m.Called(ctx, tenantID, orderID)
+	return args.Get(0).(OrderHistoryHead), args.Bool(1), args.Error(2)
+}
+
+func (m *MockRepository) FetchOrderChanges(
+	ctx context.Context,
+	orderID int64,
+	offset, limit int,
+) ([]OrderChange, error) {
+	args := m.Called(ctx, orderID, offset, limit)
+	return args.Get(0).([]OrderChange), args.Error(1)
+}
+
+func (m *MockRepository) FetchOrderStatusChanges(ctx context.Context, orderID int64) ([]OrderChange, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).([]OrderChange), args.Error(1)
+}
+
+func (m *MockRepository) AggregateOrders(ctx context.Context, f OrderStatsFilter) ([]OrderStatsRow, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]OrderStatsRow), args.Error(1)
+}
+
 type testService struct {
 	*service
 	getWarningOrderFunc func(ctx context.Context, f WarningFilter) ([]int64, error)
@@ -229,7 +345,18 @@ func (s stubShowOrderCodeProvider) ShouldShowOrderCode(
 }
 
 type stubActiveOrdersReader struct {
-	getFunc func(ctx context.Context, tenantID int64) ([]FormattedOrder, error)
+	getFunc    func(ctx context.Context, tenantID int64) ([]FormattedOrder, error)
+	getOneFunc func(ctx context.Context, tenantID, orderID int64) (FormattedOrder, bool, error)
+}
+
+func (s stubActiveOrdersReader) GetFormattedActiveOrder(
+	ctx context.Context,
+	tenantID, orderID int64,
+) (FormattedOrder, bool, error) {
+	if s.getOneFunc == nil {
+		return FormattedOrder{}, false, nil
+	}
+	return s.getOneFunc(ctx, tenantID, orderID)
 }
 
 func (s stubActiveOrdersReader) GetFormattedActiveOrders(
@@ -302,8 +429,8 @@ func (a *testOrderViewAssembler) BuildOrderView(
 		Status: OrderStatusView{
 			StatusID: o.Status.StatusID,
 			Name:     statusName,
-			Category: GetCategory(o.StatusID),
-			Color:    GetColor(o.StatusID),
+			Category: defaultStatuses.Category(0, o.StatusID),
+			Color:    defaultStatuses.Color(0, o.StatusID),
 		},
 		DateForSort:  formatOrderTimeForSort(o.OrderTime),
 		Date:         formatOrderTime(o.OrderTime),
@@ -327,12 +454,14 @@ func (a *testOrderViewAssembler) BuildOrderView(
 		OrderTime:    o.OrderTime - o.TimeOffset,
 		PositionID:   o.PositionID,
 		UnitQuantity: o.UnitQuantity,
+		Warnings:     o.Warnings,
 	}, nil
 }
 
 func newServiceWithRepo(repo Repository) *service {
 	return &service{
 		warningReader:      repo,
+		candidateReader:    repo,
 		orderListReader:    repo,
 		groupOrderReader:   repo,
 		optionsReader:      repo,
@@ -376,9 +505,10 @@ func TestGetOrdersByGroup_NotWarning(t *testing.T) {
 		10,
 	).Return(expectedOrders, nil)
 
-	count, orders, err := svc.GetOrdersByGroup(ctx, filter, 1, 10)
+	count, orders, reasons, err := svc.GetOrdersByGroup(ctx, filter, 1, 10)
 
 	require.NoError(t, err)
+	require.Nil(t, reasons)
 	require.Equal(t, expectedCount, count)
 	require.Len(t, orders, 2)
 	require.Equal(t, int64(1), orders[0].OrderID)
@@ -411,14 +541,14 @@ func TestGetOrdersByGroup_Warning_FullMock(t *testing.T) {
 		"FetchBadReview",
 		mock.Anything,
 		mock.AnythingOfType("order.BadReviewFilter"),
-	).Return([]int64{2, 3}, nil)
+	).Return([]BadReviewWarning{{OrderID: 2, Rating: 1}, {OrderID: 3, Rating: 2}}, nil)
 
 	// 3) exceeded price
 	repo.On(
 		"FetchExceededPrice",
 		mock.Anything,
 		mock.AnythingOfType("order.ExceededPriceFilter"),
-	).Return([]int64{2, 6}, nil)
+	).Return([]ExceededPriceWarning{{OrderID: 2, RealtimePrice: 120}, {OrderID: 6, RealtimePrice: 130}}, nil)
 
 	// ---------- ИТОГОВЫЕ ВЫЗОВЫ ----------
 
@@ -445,7 +575,7 @@ func TestGetOrdersByGroup_Warning_FullMock(t *testing.T) {
 
 	// ---------- ВЫЗОВ ----------
 
-	count, orders, err := svc.GetOrdersByGroup(ctx, filter, 1, 10)
+	count, orders, reasons, err := svc.GetOrdersByGroup(ctx, filter, 1, 10)
 
 	// ---------- ПРОВЕРКИ ----------
 
@@ -453,6 +583,11 @@ func TestGetOrdersByGroup_Warning_FullMock(t *testing.T) {
 	require.Equal(t, int64(4), count)
 	require.Len(t, orders, 2)
 	require.Equal(t, int64(1), orders[0].OrderID)
+	require.Equal(t, []WarningReason{
+		{Code: WarningBadReview, Details: map[string]any{"rating": int64(1), "bad_rating_max": int64(0)}},
+		{Code: WarningExceededPrice, Details: map[string]any{"realtime_price": 120.0, "min_real_price": 0.0, "exceeded_by": 120.0}},
+	}, reasons[2])
+	require.Equal(t, []WarningReason{{Code: WarningUnpaid}}, reasons[7])
 
 	repo.AssertExpectations(t)
 }
@@ -476,12 +611,12 @@ func TestGetWarningOrder_MergesDeduplicatesAndSorts(t *testing.T) {
 		"FetchBadReview",
 		mock.Anything,
 		BadReviewFilter{BaseFilter: filter.BaseFilter, BadRatingMax: 0},
-	).Return([]int64{3, 2}, nil)
+	).Return([]BadReviewWarning{{OrderID: 3}, {OrderID: 2}}, nil)
 	repo.On(
 		"FetchExceededPrice",
 		mock.Anything,
 		ExceededPriceFilter{BaseFilter: filter.BaseFilter, MinRealPrice: 0, FinishedStatus: nil},
-	).Return([]int64{7, 1}, nil)
+	).Return([]ExceededPriceWarning{{OrderID: 7}, {OrderID: 1}}, nil)
 
 	ids, err := svc.GetWarningOrder(ctx, filter)
 
@@ -490,6 +625,38 @@ func TestGetWarningOrder_MergesDeduplicatesAndSorts(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+func TestGetWarningReasons_KeepsReasonDetails(t *testing.T) {
+	ctx := context.Background()
+	repo := stubRepository{
+		fetchUnpaidFunc: func(ctx context.Context, f UnpaidFilter) ([]int64, error) {
+			return []int64{5}, nil
+		},
+		fetchBadReviewFunc: func(ctx context.Context, f BadReviewFilter) ([]BadReviewWarning, error) {
+			return []BadReviewWarning{{OrderID: 5, Rating: 3}, {OrderID: 5, Rating: 1}}, nil
+		},
+		fetchExceededPriceFunc: func(ctx context.Context, f ExceededPriceFilter) ([]ExceededPriceWarning, error) {
+			return []ExceededPriceWarning{{OrderID: 8, RealtimePrice: 1250.5}}, nil
+		},
+	}
+	svc := newServiceWithRepo(repo)
+
+	reasons, err := svc.GetWarningReasons(ctx, WarningFilter{
+		BaseFilter:   BaseFilter{TenantID: 68},
+		BadRatingMax: 3,
+		MinRealPrice: 1000,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []int64{5, 8}, reasons.OrderIDs())
+	require.Equal(t, []WarningReason{
+		{Code: WarningUnpaid},
+		{Code: WarningBadReview, Details: map[string]any{"rating": int64(1), "bad_rating_max": int64(3)}},
+	}, reasons[5])
+	require.Equal(t, []WarningReason{
+		{Code: WarningExceededPrice, Details: map[string]any{"realtime_price": 1250.5, "min_real_price": 1000.0, "exceeded_by": 250.5}},
+	}, reasons[8])
+}
+
 func TestGetWarningOrder_ReturnsError(t *testing.T) {
 	ctx := context.Background()
 
@@ -500,9 +667,9 @@ func TestGetWarningOrder_ReturnsError(t *testing.T) {
 	repo.On("FetchUnpaid", mock.Anything, mock.AnythingOfType("order.UnpaidFilter")).
 		Return([]int64(nil), errors.New("boom"))
 	repo.On("FetchBadReview", mock.Anything, mock.AnythingOfType("order.BadReviewFilter")).
-		Return([]int64{}, nil)
+		Return([]BadReviewWarning{}, nil)
 	repo.On("FetchExceededPrice", mock.Anything, mock.AnythingOfType("order.ExceededPriceFilter")).
-		Return([]int64{}, nil)
+		Return([]ExceededPriceWarning{}, nil)
 
 	ids, err := svc.GetWarningOrder(ctx, filter)
 
@@ -510,6 +677,38 @@ func TestGetWarningOrder_ReturnsError(t *testing.T) {
 	require.Nil(t, ids)
 }
 
+func TestGetWarningOrder_ReadsSetsFromWarningCache(t *testing.T) {
+	ctx := context.Background()
+	var unpaidCalls atomic.Int32
+	repo := stubRepository{
+		fetchUnpaidFunc: func(ctx context.Context, f UnpaidFilter) ([]int64, error) {
+			unpaidCalls.Add(1)
+			return []int64{5}, nil
+		},
+		fetchBadReviewFunc: func(ctx context.Context, f BadReviewFilter) ([]BadReviewWarning, error) {
+			return nil, nil
+		},
+		fetchExceededPriceFunc: func(ctx context.Context, f ExceededPriceFilter) ([]ExceededPriceWarning, error) {
+			return nil, nil
+		},
+	}
+	svc := newServiceWithRepo(repo)
+	svc.warningCache = NewWarningCache()
+	svc.warningCache.load = svc.GetWarningReasons
+
+	list := WarningFilter{BaseFilter: BaseFilter{TenantID: 68, Group: "warning"}}
+	tabs := WarningFilter{BaseFilter: BaseFilter{TenantID: 68, SelectForDate: true}}
+
+	ids, err := svc.GetWarningOrder(ctx, list)
+	require.NoError(t, err)
+	require.Equal(t, []int64{5}, ids)
+
+	ids, err = svc.GetWarningOrder(ctx, tabs)
+	require.NoError(t, err)
+	require.Equal(t, []int64{5}, ids)
+	require.Equal(t, int32(1), unpaidCalls.Load())
+}
+
 func TestGetFormattedOrdersByGroup_ParsesAddressesAndLoadsOptions(t *testing.T) {
 	ctx := context.Background()
 	repo := new(MockRepository)
@@ -841,13 +1040,13 @@ func TestGetOrdersForTabs_MergesWarningAndBuildsSignalGroups(t *testing.T) {
 	repo := stubRepository{
 		fetchOrdersByStatusGroup: func(ctx context.Context, f BaseFilter) ([]int64, error) {
 			switch {
-			case f.SelectForDate == false && requireStatusSet(f.Status, orderGroupIDs[StatusGroup0]):
+			case f.SelectForDate == false && requireStatusSet(f.Status, DefaultStatusRegistry().GroupStatuses(0, "new")):
 				return []int64{1, 2}, nil
-			case f.SelectForDate == false && requireStatusSet(f.Status, orderGroupIDs[StatusGroup6]):
+			case f.SelectForDate == false && requireStatusSet(f.Status, DefaultStatusRegistry().GroupStatuses(0, "pre_order")):
 				return []int64{6}, nil
-			case f.SelectForDate == true && requireStatusSet(f.Status, orderGroupIDs[StatusGroup7]):
+			case f.SelectForDate == true && requireStatusSet(f.Status, DefaultStatusRegistry().GroupStatuses(0, "warning")):
 				return []int64{7, 8}, nil
-			case f.SelectForDate == false && requireStatusSet(f.Status, orderGroupIDs[StatusGroup8]):
+			case f.SelectForDate == false && requireStatusSet(f.Status, DefaultStatusRegistry().GroupStatuses(0, "works")):
 				return []int64{10, 11, 12}, nil
 			default:
 				t.Fatalf("unexpected filter: %+v", f)
@@ -857,11 +1056,11 @@ func TestGetOrdersForTabs_MergesWarningAndBuildsSignalGroups(t *testing.T) {
 		fetchUnpaidFunc: func(ctx context.Context, f UnpaidFilter) ([]int64, error) {
 			return []int64{8, 9}, nil
 		},
-		fetchBadReviewFunc: func(ctx context.Context, f BadReviewFilter) ([]int64, error) {
-			return []int64{9, 13}, nil
+		fetchBadReviewFunc: func(ctx context.Context, f BadReviewFilter) ([]BadReviewWarning, error) {
+			return []BadReviewWarning{{OrderID: 9, Rating: 1}, {OrderID: 13, Rating: 2}}, nil
 		},
-		fetchExceededPriceFunc: func(ctx context.Context, f ExceededPriceFilter) ([]int64, error) {
-			return []int64{14}, nil
+		fetchExceededPriceFunc: func(ctx context.Context, f ExceededPriceFilter) ([]ExceededPriceWarning, error) {
+			return []ExceededPriceWarning{{OrderID: 14, RealtimePrice: 150}}, nil
 		},
 	}
 	svc := newServiceWithRepo(repo)
@@ -913,10 +1112,10 @@ func TestShowCodeOrID(t *testing.T) {
 	require.Equal(t, int64(123456), ShowCodeOrID(false, "q4ccf", 123456))
 }
 
-func TestGetCategoryAndDeviceName(t *testing.T) {
-	require.Equal(t, "warning", GetCategory(45))
-	require.Equal(t, "warning", GetCategory(38))
-	require.Equal(t, "", GetCategory(999999))
+func TestDefaultCategoryAndDeviceName(t *testing.T) {
+	require.Equal(t, "warning", defaultStatuses.Category(0, 45))
+	require.Equal(t, "warning", defaultStatuses.Category(0, 38))
+	require.Equal(t, "", defaultStatuses.Category(0, 999999))
 
 	require.Equal(t, "Android", GetDeviceName(DeviceAndroid))
 	require.Equal(t, "Диспетчер", GetDeviceName(DeviceDispatcher))
@@ -924,13 +1123,13 @@ func TestGetCategoryAndDeviceName(t *testing.T) {
 }
 
 func TestMatchesSearchStatus_IncludesPreOrdersForWorksAndActive(t *testing.T) {
-	require.True(t, matchesSearchStatus(6, "works"))
-	require.True(t, matchesSearchStatus(6, "active"))
-	require.True(t, matchesSearchStatus(6, "pre_order"))
-	require.True(t, matchesSearchStatus(16, "works"))
-	require.True(t, matchesSearchStatus(16, "warning"))
-	require.True(t, matchesSearchStatus(29, "works"))
-	require.True(t, matchesSearchStatus(1, "active"))
+	require.True(t, searchStatusMatches(6, "works"))
+	require.True(t, searchStatusMatches(6, "active"))
+	require.True(t, searchStatusMatches(6, "pre_order"))
+	require.True(t, searchStatusMatches(16, "works"))
+	require.True(t, searchStatusMatches(16, "warning"))
+	require.True(t, searchStatusMatches(29, "works"))
+	require.True(t, searchStatusMatches(1, "active"))
 }
 
 func TestMergeGetAllOrders_SkipsRedisForAllStatus(t *testing.T) {
@@ -947,14 +1146,17 @@ func TestMergeGetAllOrders_SkipsRedisForAllStatus(t *testing.T) {
 		{OrderID: 3, StatusID: 6},
 	}
 
-	merged := mergeGetAllOrders(mysqlFormatted, redisFormatted, filter)
+	redisOrders := filterGetAllRedisOrders(redisFormatted, filter)
+	merged := mergeGetAllPage(mysqlFormatted, 0, redisOrders, 0, 50, OrdersLess(GetAllSort(BaseFilter{SortField: "order_id", SortOrder: "asc"})))
 
 	require.Len(t, merged, 2)
 	require.Equal(t, int64(1), merged[0].OrderID)
 	require.Equal(t, int64(2), merged[1].OrderID)
 }
 
-func TestMergeGetAllOrders_KeepsRedisDuplicatesForWorksCountParity(t *testing.T) {
+// The page merge trusts its inputs; GetAllOrders removes the duplicates
+// before with dedupeGetAllOrders.
+func TestMergeGetAllPage_DoesNotDeduplicateItself(t *testing.T) {
 	filter := GetAllOrdersFilter{
 		SearchStatus: "works",
 	}
@@ -967,7 +1169,8 @@ func TestMergeGetAllOrders_KeepsRedisDuplicatesForWorksCountParity(t *testing.T)
 		{OrderID: 3, StatusID: 6},
 	}
 
-	merged := mergeGetAllOrders(mysqlFormatted, redisFormatted, filter)
+	redisOrders := filterGetAllRedisOrders(redisFormatted, filter)
+	merged := mergeGetAllPage(mysqlFormatted, 0, redisOrders, 0, 50, OrdersLess(GetAllSort(BaseFilter{SortField: "order_id", SortOrder: "asc"})))
 
 	require.Len(t, merged, 3)
 	require.Equal(t, int64(1), merged[0].OrderID)
@@ -975,6 +1178,213 @@ func TestMergeGetAllOrders_KeepsRedisDuplicatesForWorksCountParity(t *testing.T)
 	require.Equal(t, int64(3), merged[2].OrderID)
 }
 
+func TestMergeGetAllPage_MatchesFullMergeOnEveryPage(t *testing.T) {
+	less := OrdersLess(GetAllSort(BaseFilter{SortField: "o.order_time", SortOrder: "desc"}))
+
+	var mysqlAll []FormattedOrder
+	for i := int64(1); i <= 23; i++ {
+		mysqlAll = append(mysqlAll, FormattedOrder{OrderID: i, OrderTime: 1000 + (i%7)*10})
+	}
+	redisOrders := []FormattedOrder{
+		{OrderID: 101, OrderTime: 1065},
+		{OrderID: 102, OrderTime: 1030},
+		{OrderID: 103, OrderTime: 1030},
+		{OrderID: 104, OrderTime: 1001},
+	}
+	sortFormattedOrders(mysqlAll, GetAllSort(BaseFilter{SortField: "o.order_time", SortOrder: "desc"}))
+	sortFormattedOrders(redisOrders, GetAllSort(BaseFilter{SortField: "o.order_time", SortOrder: "desc"}))
+
+	full := mergeGetAllPage(mysqlAll, 0, redisOrders, 0, len(mysqlAll)+len(redisOrders), less)
+	require.Len(t, full, 27)
+
+	pageSize := 5
+	for page := 0; page*pageSize < len(full)+pageSize; page++ {
+		offset := page * pageSize
+		mysqlStart := offset - len(redisOrders)
+		if mysqlStart < 0 {
+			mysqlStart = 0
+		}
+		limit := offset - mysqlStart + pageSize
+		var window []FormattedOrder
+		if mysqlStart < len(mysqlAll) {
+			window = mysqlAll[mysqlStart:min(len(mysqlAll), mysqlStart+limit)]
+		}
+
+		got := mergeGetAllPage(window, mysqlStart, redisOrders, offset, pageSize, less)
+
+		want := full[min(offset, len(full)):min(offset+pageSize, len(full))]
+		require.Equal(t, want, got, "page %d", page)
+	}
+}
+
+func TestGetAllOrders_FetchesBoundedMySQLWindow(t *testing.T) {
+	ctx := context.Background()
+	var gotOffset, gotLimit int
+	repo := stubRepository{
+		countAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+			return 100, nil
+		},
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
+			gotOffset, gotLimit = offset, limit
+			return []FullOrder{{OrderID: 60, StatusID: 36}, {OrderID: 59, StatusID: 36}}, nil
+		},
+		getOptionsForOrdersFunc: func(ctx context.Context, orderIDs []int64) (map[int64][]OptionDTO, error) {
+			return map[int64][]OptionDTO{}, nil
+		},
+	}
+	svc := &service{
+		allOrdersReader:    repo,
+		optionsReader:      repo,
+		statusChangeReader: repo,
+		activeOrdersReader: stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+			return []FormattedOrder{{OrderID: 200, StatusID: 36}}, nil
+		}},
+		assembler: newTestOrderViewAssembler(nil, nil, nil),
+	}
+
+	result, err := svc.GetAllOrders(ctx, GetAllOrdersFilter{
+		BaseFilter:   BaseFilter{TenantID: 68, SortField: "o.order_id", SortOrder: "desc"},
+		Page:         2,
+		PageSize:     20,
+		SearchStatus: "works",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 39, gotOffset)
+	require.Equal(t, 21, gotLimit)
+	require.Equal(t, int64(101), result.OrderTotalCount)
+	require.Len(t, result.Orders, 2)
+	require.Equal(t, int64(60), result.Orders[0].ID)
+	require.Equal(t, int64(59), result.Orders[1].ID)
+}
+
+func TestGetAllOrders_CursorModeContinuesAfterCursor(t *testing.T) {
+	ctx := context.Background()
+	cursor := &PageCursor{SortField: "o.order_id", Desc: true, OrderID: 50}
+	var gotFilter GetAllOrdersFilter
+	var gotOffset, gotLimit int
+	repo := stubRepository{
+		countAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+			return 100, nil
+		},
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
+			gotFilter, gotOffset, gotLimit = f, offset, limit
+			return []FullOrder{{OrderID: 49, StatusID: 36}, {OrderID: 47, StatusID: 36}}, nil
+		},
+		getOptionsForOrdersFunc: func(ctx context.Context, orderIDs []int64) (map[int64][]OptionDTO, error) {
+			return map[int64][]OptionDTO{}, nil
+		},
+	}
+	svc := &service{
+		allOrdersReader:    repo,
+		optionsReader:      repo,
+		statusChangeReader: repo,
+		activeOrdersReader: stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+			return []FormattedOrder{
+				{OrderID: 60, StatusID: 36},
+				{OrderID: 48, StatusID: 36},
+			}, nil
+		}},
+		assembler: newTestOrderViewAssembler(nil, nil, nil),
+	}
+
+	result, err := svc.GetAllOrders(ctx, GetAllOrdersFilter{
+		BaseFilter:   BaseFilter{TenantID: 68, SortField: "o.order_id", SortOrder: "desc", Cursor: cursor},
+		Page:         7,
+		PageSize:     2,
+		SearchStatus: "works",
+	})
+
+	require.NoError(t, err)
+	require.Same(t, cursor, gotFilter.Cursor)
+	require.Equal(t, 0, gotOffset)
+	require.Equal(t, 2, gotLimit)
+	require.Equal(t, int64(102), result.OrderTotalCount)
+	require.Len(t, result.Orders, 2)
+	require.Equal(t, int64(49), result.Orders[0].ID)
+	require.Equal(t, int64(48), result.Orders[1].ID)
+	require.NotNil(t, result.NextCursor)
+	require.Equal(t, int64(48), result.NextCursor.OrderID)
+}
+
+func TestExportAllOrders_IgnoresPaginationAndStopsAtLimit(t *testing.T) {
+	ctx := context.Background()
+	var fetches []GetAllOrdersFilter
+	repo := stubRepository{
+		countAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+			return 100, nil
+		},
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
+			fetches = append(fetches, f)
+			require.Equal(t, 0, offset)
+			orders := make([]FullOrder, 0, limit)
+			for i := 0; i < limit; i++ {
+				orders = append(orders, FullOrder{OrderID: int64(100 - i), StatusID: 36})
+			}
+			return orders, nil
+		},
+		getOptionsForOrdersFunc: func(ctx context.Context, orderIDs []int64) (map[int64][]OptionDTO, error) {
+			return map[int64][]OptionDTO{}, nil
+		},
+	}
+	svc := &service{
+		allOrdersReader:    repo,
+		optionsReader:      repo,
+		statusChangeReader: repo,
+		activeOrdersReader: stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+			return nil, nil
+		}},
+		assembler: newTestOrderViewAssembler(nil, nil, nil),
+	}
+
+	var exported []int64
+	err := svc.ExportAllOrders(ctx, GetAllOrdersFilter{
+		BaseFilter: BaseFilter{
+			TenantID:  68,
+			SortField: "o.order_id",
+			SortOrder: "desc",
+			Cursor:    &PageCursor{SortField: "o.order_id", Desc: true, OrderID: 10},
+		},
+		Page:         4,
+		PageSize:     20,
+		SearchStatus: "completed",
+	}, 3, func(page GetAllOrdersResult) error {
+		for _, view := range page.Orders {
+			exported = append(exported, view.ID)
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, fetches, 1)
+	require.Nil(t, fetches[0].Cursor)
+	require.Equal(t, 0, fetches[0].Page)
+	require.Equal(t, 3, fetches[0].PageSize)
+	require.Equal(t, []int64{100, 99, 98}, exported)
+}
+
+func TestParseCursor_RoundTripAndValidation(t *testing.T) {
+	cursor := PageCursor{SortField: "o.status_time", Desc: true, Value: 1777409100, OrderID: 42}
+
+	parsed, err := ParseCursor(cursor.Encode(), "o.status_time", "desc")
+	require.NoError(t, err)
+	require.Equal(t, cursor, *parsed)
+
+	parsed, err = ParseCursor("", "o.status_time", "desc")
+	require.NoError(t, err)
+	require.Nil(t, parsed)
+
+	_, err = ParseCursor(cursor.Encode(), "o.status_time", "asc")
+	require.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = ParseCursor("not a cursor", "o.status_time", "desc")
+	require.ErrorIs(t, err, ErrInvalidCursor)
+
+	require.True(t, cursor.Follows(1777409100, 41))
+	require.False(t, cursor.Follows(1777409100, 42))
+	require.False(t, cursor.Follows(1777409101, 1))
+}
+
 func TestMatchesAttribute_ClientUsesClientFields(t *testing.T) {
 	clientPhone := "79990009999"
 	clientName := "Тест"
@@ -993,7 +1403,7 @@ func TestMatchesAttribute_ClientUsesClientFields(t *testing.T) {
 	require.False(t, matchesAttribute(o, "client", "другой"))
 }
 
-func TestMatchesRedisAttribute_IgnoresClientAndWorkerAttributes(t *testing.T) {
+func TestFilterSpec_SearchesClientAndWorkerAttributes(t *testing.T) {
 	clientName := "Другой"
 	workerName := "Исполнитель"
 
@@ -1002,11 +1412,31 @@ func TestMatchesRedisAttribute_IgnoresClientAndWorkerAttributes(t *testing.T) {
 		Worker: WorkerDTO{Name: &workerName},
 	}
 
-	require.True(t, matchesRedisAttribute(o, "client", "Тест"))
-	require.True(t, matchesRedisAttribute(o, "worker", "Тест"))
+	for _, attribute := range []string{"client", "worker"} {
+		spec := GetAllFilterSpec(GetAllOrdersFilter{Attributes: []SearchAttribute{{Attribute: attribute, SearchString: "Тест"}}})
+		require.False(t, spec.Matches(o), attribute)
+	}
+	spec := GetAllFilterSpec(GetAllOrdersFilter{Attributes: []SearchAttribute{{Attribute: "worker", SearchString: "исполн"}}})
+	require.True(t, spec.Matches(o))
+}
+
+func TestFilterSpec_MatchesShopsAndFormattedPhones(t *testing.T) {
+	shopID := int64(5)
+	phone := "+7 (999) 000-99-99"
+	o := FormattedOrder{ShopID: &shopID, Client: ClientDTO{Phone: &phone}}
+
+	spec := GetAllFilterSpec(GetAllOrdersFilter{
+		ShopIDs:      []int64{5},
+		SearchString: map[string]string{"client": "7999 (000)"},
+	})
+	require.Equal(t, []SearchTerm{{Attribute: "client", Word: "7999"}, {Attribute: "client", Word: "(000)"}}, spec.Searches)
+	require.True(t, spec.Matches(o))
+
+	require.False(t, GetAllFilterSpec(GetAllOrdersFilter{ShopIDs: []int64{6}}).Matches(o))
+	require.False(t, GetAllFilterSpec(GetAllOrdersFilter{ShopIDs: []int64{5}}).Matches(FormattedOrder{}))
 }
 
-func TestMergeGetAllOrders_RedisIgnoresClientAttributeWithoutSearchString(t *testing.T) {
+func TestMergeGetAllOrders_RedisAppliesClientAttribute(t *testing.T) {
 	clientTest := "Тест"
 	clientOther := "Имя"
 
@@ -1034,11 +1464,11 @@ func TestMergeGetAllOrders_RedisIgnoresClientAttributeWithoutSearchString(t *tes
 		},
 	}
 
-	merged := mergeGetAllOrders(mysqlFormatted, redisFormatted, filter)
+	redisOrders := filterGetAllRedisOrders(redisFormatted, filter)
+	merged := mergeGetAllPage(mysqlFormatted, 0, redisOrders, 0, 50, OrdersLess(GetAllSort(BaseFilter{SortField: "order_id", SortOrder: "asc"})))
 
-	require.Len(t, merged, 2)
+	require.Len(t, merged, 1)
 	require.Equal(t, int64(1), merged[0].OrderID)
-	require.Equal(t, int64(2), merged[1].OrderID)
 }
 
 func TestMergeGetAllOrders_RedisClientSearchUsesSearchString(t *testing.T) {
@@ -1069,7 +1499,8 @@ func TestMergeGetAllOrders_RedisClientSearchUsesSearchString(t *testing.T) {
 		},
 	}
 
-	merged := mergeGetAllOrders(mysqlFormatted, redisFormatted, filter)
+	redisOrders := filterGetAllRedisOrders(redisFormatted, filter)
+	merged := mergeGetAllPage(mysqlFormatted, 0, redisOrders, 0, 50, OrdersLess(GetAllSort(BaseFilter{SortField: "order_id", SortOrder: "asc"})))
 
 	require.Len(t, merged, 1)
 	require.Equal(t, int64(1), merged[0].OrderID)
@@ -1079,7 +1510,11 @@ func TestGetAllOrders_SkipsMySQLForPreOrder(t *testing.T) {
 	ctx := context.Background()
 	mysqlCalled := false
 	repo := stubRepository{
-		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) ([]FullOrder, error) {
+		countAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+			mysqlCalled = true
+			return 1, nil
+		},
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
 			mysqlCalled = true
 			return []FullOrder{{OrderID: 10}}, nil
 		},
@@ -1131,6 +1566,251 @@ func TestGetAllOrders_SkipsMySQLForPreOrder(t *testing.T) {
 	require.Equal(t, int64(11), result.Orders[0].ID)
 }
 
+func TestGetAllOrders_ServesMySQLOnlyWhenRedisFails(t *testing.T) {
+	repo := stubRepository{
+		countAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+			return 1, nil
+		},
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
+			return []FullOrder{{OrderID: 10, StatusID: 26}}, nil
+		},
+		getOptionsForOrdersFunc: func(ctx context.Context, orderIDs []int64) (map[int64][]OptionDTO, error) {
+			return map[int64][]OptionDTO{}, nil
+		},
+	}
+	redisDown := errors.New("dial tcp: connection refused")
+	svc := &service{
+		allOrdersReader:    repo,
+		optionsReader:      repo,
+		statusChangeReader: repo,
+		activeOrdersReader: stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+			return nil, redisDown
+		}},
+		assembler: newTestOrderViewAssembler(nil, nil, nil),
+	}
+	filter := GetAllOrdersFilter{BaseFilter: BaseFilter{TenantID: 68}, PageSize: 50, SearchStatus: "works"}
+
+	ctx := WithDegradation(context.Background())
+	result, err := svc.GetAllOrders(ctx, filter)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.OrderTotalCount)
+	require.Len(t, result.Orders, 1)
+	require.Equal(t, []string{DegradedActiveOrders}, Degraded(ctx))
+
+	cancelled, cancel := context.WithCancel(WithDegradation(context.Background()))
+	cancel()
+	_, err = svc.GetAllOrders(cancelled, filter)
+	require.ErrorIs(t, err, redisDown, "a cancelled request fails instead of degrading")
+}
+
+func TestGetAllOrders_KeepsFresherRecordOfOrdersInBothSources(t *testing.T) {
+	mysqlRows := []FullOrder{
+		{OrderID: 10, StatusID: 26, UpdateTime: sql.NullInt64{Int64: 100, Valid: true}},
+		{OrderID: 11, StatusID: 26, UpdateTime: sql.NullInt64{Int64: 200, Valid: true}},
+		{OrderID: 12, StatusID: 26},
+	}
+	matching := func(f GetAllOrdersFilter) []FullOrder {
+		spec := GetAllFilterSpec(f)
+		var result []FullOrder
+		for _, row := range mysqlRows {
+			if spec.Matches(FormattedOrder{OrderID: row.OrderID, StatusID: row.StatusID}) {
+				result = append(result, row)
+			}
+		}
+		return result
+	}
+	repo := stubRepository{
+		countAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+			return int64(len(matching(f))), nil
+		},
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
+			return matching(f), nil
+		},
+		getOptionsForOrdersFunc: func(ctx context.Context, orderIDs []int64) (map[int64][]OptionDTO, error) {
+			return map[int64][]OptionDTO{}, nil
+		},
+	}
+	svc := &service{
+		allOrdersReader:    repo,
+		optionsReader:      repo,
+		statusChangeReader: repo,
+		activeOrdersReader: stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+			return []FormattedOrder{
+				{OrderID: 10, StatusID: 26, UpdateTime: 150},
+				{OrderID: 11, StatusID: 26, UpdateTime: 150},
+				{OrderID: 13, StatusID: 26},
+			}, nil
+		}},
+		assembler: newTestOrderViewAssembler(nil, nil, nil),
+	}
+
+	result, err := svc.GetAllOrders(context.Background(), GetAllOrdersFilter{
+		BaseFilter:   BaseFilter{TenantID: 68, SortField: "order_id", SortOrder: "asc"},
+		PageSize:     50,
+		SearchStatus: "works",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, int64(4), result.OrderTotalCount)
+	ids := make([]int64, 0, len(result.Orders))
+	for _, view := range result.Orders {
+		ids = append(ids, view.ID)
+	}
+	require.Equal(t, []int64{10, 11, 12, 13}, ids)
+}
+
+func TestGetAllOrders_PicksFresherRecordBeforeFiltering(t *testing.T) {
+	mysqlRows := []FullOrder{
+		{OrderID: 10, StatusID: 26, UpdateTime: sql.NullInt64{Int64: 100, Valid: true}},
+		{OrderID: 11, StatusID: 37, UpdateTime: sql.NullInt64{Int64: 200, Valid: true}},
+		{OrderID: 12, StatusID: 26},
+	}
+	matching := func(f GetAllOrdersFilter) []FullOrder {
+		spec := GetAllFilterSpec(f)
+		var result []FullOrder
+		for _, row := range mysqlRows {
+			if spec.Matches(FormattedOrder{OrderID: row.OrderID, StatusID: row.StatusID}) {
+				result = append(result, row)
+			}
+		}
+		return result
+	}
+	repo := stubRepository{
+		countAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+			return int64(len(matching(f))), nil
+		},
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
+			return matching(f), nil
+		},
+	}
+	svc := &service{
+		allOrdersReader:    repo,
+		optionsReader:      repo,
+		statusChangeReader: repo,
+		activeOrdersReader: stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+			return []FormattedOrder{
+				// Completed since MySQL was written: the stale MySQL row is
+				// no works order anymore.
+				{OrderID: 10, StatusID: 37, UpdateTime: 150},
+				// Completed in MySQL, the Redis record lags behind.
+				{OrderID: 11, StatusID: 26, UpdateTime: 150},
+				{OrderID: 13, StatusID: 26},
+			}, nil
+		}},
+		assembler: newTestOrderViewAssembler(nil, nil, nil),
+	}
+
+	result, err := svc.GetAllOrders(context.Background(), GetAllOrdersFilter{
+		BaseFilter:   BaseFilter{TenantID: 68, SortField: "order_id", SortOrder: "asc"},
+		PageSize:     50,
+		SearchStatus: "works",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.OrderTotalCount)
+	ids := make([]int64, 0, len(result.Orders))
+	for _, view := range result.Orders {
+		ids = append(ids, view.ID)
+	}
+	require.Equal(t, []int64{12, 13}, ids)
+}
+
+func TestRedisRecordIsFresher(t *testing.T) {
+	mysqlOrder := FullOrder{StatusTime: 500, UpdateTime: sql.NullInt64{Int64: 600, Valid: true}}
+
+	require.True(t, redisRecordIsFresher(FormattedOrder{UpdateTime: 700, StatusTime: 400}, mysqlOrder))
+	require.False(t, redisRecordIsFresher(FormattedOrder{UpdateTime: 550, StatusTime: 900}, mysqlOrder))
+	require.True(t, redisRecordIsFresher(FormattedOrder{StatusTime: 900}, mysqlOrder), "without update_time status_time decides")
+	require.True(t, redisRecordIsFresher(FormattedOrder{UpdateTime: 600, StatusTime: 500}, mysqlOrder), "a tie keeps the Redis record")
+	require.False(t, redisRecordIsFresher(FormattedOrder{UpdateTime: 600, StatusTime: 499}, mysqlOrder))
+}
+
+func TestGetOrder_PrefersActiveOrderFromRedis(t *testing.T) {
+	ctx := context.Background()
+	repo := stubRepository{
+		fetchOrderByIDFunc: func(ctx context.Context, tenantID, orderID int64) (FullOrder, bool, error) {
+			t.Fatal("mysql must not be queried when redis has the order")
+			return FullOrder{}, false, nil
+		},
+		getOptionsForOrdersFunc: func(ctx context.Context, orderIDs []int64) (map[int64][]OptionDTO, error) {
+			require.Equal(t, []int64{11}, orderIDs)
+			return map[int64][]OptionDTO{11: {{OptionID: 1, Name: "wifi", Quantity: 1}}}, nil
+		},
+		getStatusChangeTimesFunc: func(ctx context.Context, keys []StatusKey) (map[StatusKey]int64, error) {
+			return map[StatusKey]int64{{OrderID: 11, StatusID: 26}: 900}, nil
+		},
+	}
+	svc := &service{
+		orderReader:        repo,
+		optionsReader:      repo,
+		statusChangeReader: repo,
+		activeOrdersReader: stubActiveOrdersReader{
+			getOneFunc: func(ctx context.Context, tenantID, orderID int64) (FormattedOrder, bool, error) {
+				require.Equal(t, int64(68), tenantID)
+				require.Equal(t, int64(11), orderID)
+				return FormattedOrder{
+					OrderID:  11,
+					StatusID: 26,
+					Status:   StatusDTO{StatusID: 26, Name: "Worker is waiting"},
+				}, true, nil
+			},
+		},
+		assembler: newTestOrderViewAssembler(
+			stubWaitingTimeProvider{
+				getFunc: func(ctx context.Context, tenantID, orderID int64) (int64, error) {
+					require.Equal(t, int64(68), tenantID)
+					return 120, nil
+				},
+			},
+			nil,
+			nil,
+		),
+	}
+
+	view, err := svc.GetOrder(ctx, OrderFilter{TenantID: 68, OrderID: 11, Language: "ru"})
+
+	require.NoError(t, err)
+	require.Equal(t, int64(11), view.ID)
+	require.Equal(t, int64(900), view.StatusTime)
+	require.Equal(t, int64(120), view.WaitTime)
+	require.Equal(t, []OptionDTO{{OptionID: 1, Name: "wifi", Quantity: 1}}, view.Options)
+}
+
+func TestGetOrder_FallsBackToMySQL(t *testing.T) {
+	ctx := context.Background()
+	repo := stubRepository{
+		fetchOrderByIDFunc: func(ctx context.Context, tenantID, orderID int64) (FullOrder, bool, error) {
+			require.Equal(t, int64(68), tenantID)
+			return FullOrder{OrderID: orderID, TenantID: tenantID, StatusID: 37, Address: "a:1"}, true, nil
+		},
+	}
+	svc := newServiceWithRepo(repo)
+	svc.orderReader = repo
+	svc.activeOrdersReader = stubActiveOrdersReader{}
+	svc.addressResolver = stubAddressResolver{
+		resolveFunc: func(orders []FullOrder) (map[int64][]AddressView, error) {
+			return map[int64][]AddressView{12: {{Street: strPtr("Lenina"), Type: "house"}}}, nil
+		},
+	}
+
+	view, err := svc.GetOrder(ctx, OrderFilter{TenantID: 68, OrderID: 12})
+
+	require.NoError(t, err)
+	require.Equal(t, int64(12), view.ID)
+	require.Equal(t, "Lenina", derefString(view.Address[0].Street))
+}
+
+func TestGetOrder_ReturnsNotFound(t *testing.T) {
+	svc := newServiceWithRepo(stubRepository{})
+	svc.orderReader = stubRepository{}
+	svc.activeOrdersReader = stubActiveOrdersReader{}
+
+	_, err := svc.GetOrder(context.Background(), OrderFilter{TenantID: 68, OrderID: 13})
+
+	require.ErrorIs(t, err, ErrOrderNotFound)
+}
+
 func requireStatusSet(got, expected []int64) bool {
 	if len(got) != len(expected) {
 		return false
@@ -1148,6 +1828,11 @@ func requireStatusSet(got, expected []int64) bool {
 	return true
 }
 
+func searchStatusMatches(statusID int64, searchStatus string) bool {
+	ids, filtered := DefaultStatusRegistry().SearchStatusIDs(0, searchStatus)
+	return !filtered || containsInt64(ids, statusID)
+}
+
 func strPtr(v string) *string {
 	return &v
 }
@@ -1158,3 +1843,408 @@ func derefString(v *string) string {
 	}
 	return *v
 }
+
+func TestFilterGetAllRedisOrders_DateUsesCityWallClock(t *testing.T) {
+	date := "28.04.2026"
+	filter := GetAllOrdersFilter{
+		BaseFilter:   BaseFilter{Date: &date},
+		SearchStatus: "works",
+	}
+
+	// order_time is the wall clock of the city. The first order starts at
+	// 00:30 on April 28 in a UTC+7 city, which is still April 27 in UTC and on
+	// a Moscow server, and belongs to the 28th all the same. The third one is
+	// at 23:30 on the 27th local time.
+	redisFormatted := []FormattedOrder{
+		{OrderID: 1, OrderTime: 1777336200, TimeOffset: 7 * 3600},
+		{OrderID: 2, OrderTime: 1777419000, TimeOffset: 7 * 3600},
+		{OrderID: 3, OrderTime: 1777332600, TimeOffset: 3 * 3600},
+	}
+
+	orders := filterGetAllRedisOrders(redisFormatted, filter)
+
+	ids := make([]int64, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.OrderID)
+	}
+	require.Equal(t, []int64{1, 2}, ids)
+	require.Equal(t, DateRange{From: 1777334400, To: 1777420799}, OrderDateRange(&date))
+}
+
+func TestDatePeriod_MatchesAnyOrderTimeInNightWindow(t *testing.T) {
+	from, _ := ParseFilterDate("2026-04-01")
+	to, _ := ParseFilterDate("2026-04-15")
+	period := NewDatePeriod(from, to)
+	period.WindowFrom, _ = ParseClock("20:00")
+	period.WindowTo, _ = ParseClock("08:00")
+
+	day := time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC).Unix()
+	offset := int64(7 * 3600)
+	finished := day + 2*3600 - offset
+
+	require.Equal(t, 15, period.Days())
+	require.True(t, period.Contains(day+22*3600))
+	require.True(t, period.Contains(day+3*3600))
+	require.False(t, period.Contains(day+8*3600))
+	require.False(t, period.Contains(day+12*3600))
+	require.False(t, period.Contains(time.Date(2026, 4, 16, 1, 0, 0, 0, time.UTC).Unix()))
+
+	// Ordered for noon, finished at 02:00 local time.
+	require.True(t, period.MatchesOrder(FormattedOrder{
+		OrderTime:  day + 12*3600,
+		CreateTime: day + 11*3600 - offset,
+		FinishTime: &finished,
+		TimeOffset: offset,
+	}))
+	require.False(t, period.MatchesOrder(FormattedOrder{
+		OrderTime:  day + 12*3600,
+		CreateTime: day + 11*3600 - offset,
+		TimeOffset: offset,
+	}))
+}
+
+func TestOrdersLess_SortsByEveryKeyWithMissingValuesFirst(t *testing.T) {
+	cost := func(value string) *string { return &value }
+	callsign := func(value int64) *int64 { return &value }
+	orders := []FormattedOrder{
+		{OrderID: 1, StatusID: 36, OrderTime: 300, SummaryCost: cost("150.50")},
+		{OrderID: 2, StatusID: 17, OrderTime: 100, SummaryCost: cost("99")},
+		{OrderID: 3, StatusID: 36, OrderTime: 300},
+		{OrderID: 4, StatusID: 17, OrderTime: 200, Callsign: callsign(7)},
+		{OrderID: 5, StatusID: 36, OrderTime: 100, SummaryCost: cost("1000")},
+	}
+
+	sortFormattedOrders(orders, []SortKey{
+		{Field: SortFieldStatusID},
+		{Field: SortFieldOrderTime, Desc: true},
+	})
+	require.Equal(t, []int64{4, 2, 1, 3, 5}, orderIDs(orders))
+
+	sortFormattedOrders(orders, []SortKey{{Field: SortFieldSummaryCost}})
+	require.Equal(t, []int64{3, 4, 2, 1, 5}, orderIDs(orders))
+
+	sortFormattedOrders(orders, []SortKey{{Field: SortFieldWorkerCallsign, Desc: true}})
+	require.Equal(t, []int64{4, 5, 3, 2, 1}, orderIDs(orders))
+}
+
+func TestGetAllOrders_SortsByWaitTimeOfActiveOrders(t *testing.T) {
+	workerID := int64(7)
+	var mysqlWaitTimes map[int64]int64
+	repo := stubRepository{
+		countAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+			return 1, nil
+		},
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
+			mysqlWaitTimes = f.WaitTimes
+			if len(f.OrderIDs) > 0 {
+				return nil, nil
+			}
+			return []FullOrder{{OrderID: 10, StatusID: 26, WorkerID: sql.NullInt64{Int64: workerID, Valid: true}}}, nil
+		},
+	}
+	svc := &service{
+		allOrdersReader:    repo,
+		optionsReader:      repo,
+		statusChangeReader: repo,
+		activeOrdersReader: stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+			return []FormattedOrder{
+				// The payload of order 10 lags behind MySQL, only its wait
+				// time is used.
+				{OrderID: 10, TenantID: tenantID, StatusID: 1, WaitTime: 600},
+				{OrderID: 20, TenantID: tenantID, StatusID: 26, WorkerID: &workerID, WaitTime: 300},
+				{OrderID: 21, TenantID: tenantID, StatusID: 26, WaitTime: 900},
+			}, nil
+		}},
+		assembler: newTestOrderViewAssembler(nil, nil, nil),
+	}
+
+	result, err := svc.GetAllOrders(context.Background(), GetAllOrdersFilter{
+		BaseFilter:   BaseFilter{TenantID: 68, Sort: []SortKey{{Field: SortFieldWaitTime, Desc: true}}},
+		PageSize:     50,
+		SearchStatus: "works",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, map[int64]int64{10: 600, 20: 300, 21: 900}, mysqlWaitTimes)
+	ids := make([]int64, 0, len(result.Orders))
+	for _, view := range result.Orders {
+		ids = append(ids, view.ID)
+	}
+	require.Equal(t, []int64{10, 20, 21}, ids, "order 21 has no worker waiting, so no wait time")
+}
+
+func TestExportAllOrders_PagesByOffsetForSortsWithoutCursor(t *testing.T) {
+	ctx := context.Background()
+	var offsets []int
+	repo := stubRepository{
+		countAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter) (int64, error) {
+			return 1200, nil
+		},
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
+			offsets = append(offsets, offset)
+			orders := make([]FullOrder, 0, limit)
+			for i := 0; i < limit && offset+i < 1200; i++ {
+				orders = append(orders, FullOrder{OrderID: int64(offset + i + 1), StatusID: 36})
+			}
+			return orders, nil
+		},
+		getOptionsForOrdersFunc: func(ctx context.Context, orderIDs []int64) (map[int64][]OptionDTO, error) {
+			return map[int64][]OptionDTO{}, nil
+		},
+	}
+	svc := &service{
+		allOrdersReader:    repo,
+		optionsReader:      repo,
+		statusChangeReader: repo,
+		assembler:          newTestOrderViewAssembler(nil, nil, nil),
+	}
+
+	exported := 0
+	err := svc.ExportAllOrders(ctx, GetAllOrdersFilter{
+		BaseFilter: BaseFilter{
+			TenantID: 68,
+			Sort:     []SortKey{{Field: SortFieldStatusID}, {Field: SortFieldOrderTime}},
+		},
+		SearchStatus: "completed",
+	}, 1100, func(page GetAllOrdersResult) error {
+		require.Nil(t, page.NextCursor)
+		exported += len(page.Orders)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 500, 1000}, offsets)
+	require.Equal(t, 1100, exported)
+}
+
+func orderIDs(orders []FormattedOrder) []int64 {
+	ids := make([]int64, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.OrderID)
+	}
+	return ids
+}
+
+func TestGetOrderHistory_TranslatesStatusesAndMeasuresDurations(t *testing.T) {
+	statusChanges := []OrderChange{
+		{Field: ChangeFieldStatus, Value: "1", ChangeTime: 100, StatusName: "New order"},
+		{Field: ChangeFieldStatus, Value: "17", ChangeTime: 160, StatusName: "Executing"},
+		{Field: ChangeFieldStatus, Value: "37", ChangeTime: 400, StatusName: "Completed"},
+	}
+	var gotOffset, gotLimit int
+	svc := newServiceWithRepo(stubRepository{})
+	svc.historyReader = stubRepository{
+		fetchOrderHistoryHeadFunc: func(ctx context.Context, tenantID, orderID int64) (OrderHistoryHead, bool, error) {
+			require.Equal(t, int64(68), tenantID)
+			require.Equal(t, int64(12), orderID)
+			return OrderHistoryHead{CityID: 26068, PositionID: 1, StatusID: 37, ChangeCount: 4}, true, nil
+		},
+		fetchOrderChangesFunc: func(ctx context.Context, orderID int64, offset, limit int) ([]OrderChange, error) {
+			gotOffset, gotLimit = offset, limit
+			return []OrderChange{
+				statusChanges[1],
+				{Field: ChangeFieldWorker, Value: "77", ChangeTime: 170},
+				statusChanges[2],
+			}, nil
+		},
+		fetchOrderStatusChangesFunc: func(ctx context.Context, orderID int64) ([]OrderChange, error) {
+			return statusChanges, nil
+		},
+	}
+	translations := 0
+	svc.statusTranslator = stubStatusTranslator{translateFunc: func(ctx context.Context, language, name string) (string, error) {
+		translations++
+		require.Equal(t, "ru", language)
+		return "ru:" + name, nil
+	}}
+
+	history, err := svc.GetOrderHistory(context.Background(), OrderHistoryFilter{
+		TenantID: 68,
+		OrderID:  12,
+		Language: "ru",
+		Page:     1,
+		PageSize: 3,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 3, gotOffset)
+	require.Equal(t, 3, gotLimit)
+	require.Equal(t, int64(4), history.TotalCount)
+	require.Equal(t, int64(26068), history.CityID)
+	require.Len(t, history.Changes, 3)
+	require.Equal(t, 2, translations)
+
+	executing := history.Changes[0]
+	require.Equal(t, "ru:Executing", executing.Status.Name)
+	require.Equal(t, int64(17), executing.Status.StatusID)
+	require.Equal(t, "works", executing.Status.Category)
+	require.Equal(t, int64(240), *executing.StatusDuration)
+
+	worker := history.Changes[1]
+	require.Nil(t, worker.Status)
+	require.Nil(t, worker.StatusDuration)
+	require.Equal(t, "77", worker.Value)
+
+	completed := history.Changes[2]
+	require.Equal(t, "completed", completed.Status.Category)
+	require.Nil(t, completed.StatusDuration, "a finished order stays in its final status")
+}
+
+func TestGetOrderHistory_CurrentStatusLastsUntilNow(t *testing.T) {
+	changes := []OrderChange{
+		{Field: ChangeFieldStatus, Value: "1", ChangeTime: 100},
+		{Field: ChangeFieldStatus, Value: "17", ChangeTime: 160},
+	}
+	svc := newServiceWithRepo(stubRepository{})
+	svc.now = func() time.Time { return time.Unix(1000, 0) }
+	svc.historyReader = stubRepository{
+		fetchOrderHistoryHeadFunc: func(ctx context.Context, tenantID, orderID int64) (OrderHistoryHead, bool, error) {
+			return OrderHistoryHead{StatusID: 17, ChangeCount: 2}, true, nil
+		},
+		fetchOrderChangesFunc: func(ctx context.Context, orderID int64, offset, limit int) ([]OrderChange, error) {
+			return changes, nil
+		},
+		fetchOrderStatusChangesFunc: func(ctx context.Context, orderID int64) ([]OrderChange, error) {
+			return changes, nil
+		},
+	}
+
+	history, err := svc.GetOrderHistory(context.Background(), OrderHistoryFilter{TenantID: 68, OrderID: 12, PageSize: 10})
+
+	require.NoError(t, err)
+	require.Equal(t, int64(60), *history.Changes[0].StatusDuration)
+	require.Equal(t, int64(840), *history.Changes[1].StatusDuration)
+}
+
+func TestGetOrderHistory_ReturnsNotFoundForOtherTenant(t *testing.T) {
+	svc := newServiceWithRepo(stubRepository{})
+	svc.historyReader = stubRepository{}
+
+	_, err := svc.GetOrderHistory(context.Background(), OrderHistoryFilter{TenantID: 70, OrderID: 12, PageSize: 10})
+
+	require.ErrorIs(t, err, ErrOrderNotFound)
+}
+
+func TestGetOrderStats_FoldsStatusesIntoCategoriesAndRatios(t *testing.T) {
+	var gotFilter OrderStatsFilter
+	svc := newServiceWithRepo(stubRepository{})
+	svc.statsReader = stubRepository{
+		aggregateOrdersFunc: func(ctx context.Context, f OrderStatsFilter) ([]OrderStatsRow, error) {
+			gotFilter = f
+			return []OrderStatsRow{
+				{Key: "37", StatusID: 37, Count: 4, SummaryCost: 1000, DistanceSum: 30, DistanceCount: 3, TimeSum: 1800, TimeCount: 3},
+				{Key: "39", StatusID: 39, Count: 4},
+			}, nil
+		},
+	}
+	svc.activeOrdersReader = stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+		t.Fatal("redis is not read for the all search status")
+		return nil, nil
+	}}
+
+	stats, err := svc.GetOrderStats(context.Background(), OrderStatsFilter{
+		GetAllOrdersFilter: GetAllOrdersFilter{BaseFilter: BaseFilter{TenantID: 68}, SearchStatus: "all"},
+		GroupBy:            StatsGroupCategory,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, int64(68), gotFilter.TenantID)
+	require.Equal(t, StatsGroupCategory, stats.GroupBy)
+	require.Len(t, stats.Groups, 2)
+
+	completed := stats.Groups[0]
+	require.Equal(t, "completed", completed.Key)
+	require.Equal(t, int64(4), completed.Count)
+	require.Equal(t, float64(1000), completed.SummaryCost)
+	require.Equal(t, float64(10), *completed.AvgPredvDistance, "orders without a distance do not count")
+	require.Equal(t, float64(600), *completed.AvgPredvTime)
+	require.Equal(t, float64(1), completed.CompletionRatio)
+
+	rejected := stats.Groups[1]
+	require.Equal(t, "rejected", rejected.Key)
+	require.Nil(t, rejected.AvgPredvDistance)
+	require.Equal(t, float64(1), rejected.RejectionRatio)
+
+	require.Equal(t, int64(8), stats.Total.Count)
+	require.Equal(t, int64(4), stats.Total.CompletedCount)
+	require.Equal(t, 0.5, stats.Total.CompletionRatio)
+	require.Equal(t, 0.5, stats.Total.RejectionRatio)
+}
+
+func TestGetOrderStats_AddsMatchingActiveOrdersForOpenGroups(t *testing.T) {
+	cost := "250"
+	svc := newServiceWithRepo(stubRepository{})
+	svc.allOrdersReader = stubRepository{}
+	svc.statsReader = stubRepository{
+		aggregateOrdersFunc: func(ctx context.Context, f OrderStatsFilter) ([]OrderStatsRow, error) {
+			require.Equal(t, StatsGroupTariff, f.GroupBy)
+			require.NotEmpty(t, f.Status)
+			return []OrderStatsRow{
+				{Key: "10", StatusID: 17, Count: 2, SummaryCost: 500, DistanceSum: 8, DistanceCount: 2, TimeSum: 1200, TimeCount: 2},
+			}, nil
+		},
+	}
+	svc.activeOrdersReader = stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+		return []FormattedOrder{
+			{OrderID: 1, StatusID: 17, TariffID: 10, SummaryCost: &cost, PredvDistance: 5, PredvTime: 300},
+			{OrderID: 2, StatusID: 26, TariffID: 9, PredvDistance: 2, PredvTime: 120},
+			{OrderID: 3, StatusID: 37, TariffID: 10},
+		}, nil
+	}}
+
+	stats, err := svc.GetOrderStats(context.Background(), OrderStatsFilter{
+		GetAllOrdersFilter: GetAllOrdersFilter{BaseFilter: BaseFilter{TenantID: 68}, SearchStatus: "works"},
+		GroupBy:            StatsGroupTariff,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, int64(4), stats.Total.Count, "the completed active order is not in the works search")
+	require.Len(t, stats.Groups, 2)
+	require.Equal(t, "9", stats.Groups[0].Key)
+	require.Equal(t, int64(1), stats.Groups[0].Count)
+	require.Equal(t, "10", stats.Groups[1].Key)
+	require.Equal(t, int64(3), stats.Groups[1].Count)
+	require.Equal(t, float64(750), stats.Groups[1].SummaryCost)
+	require.Equal(t, float64(500), *stats.Groups[1].AvgPredvTime)
+}
+
+func TestGetOrderStats_CountsOrdersInBothSourcesOnce(t *testing.T) {
+	var gotExcluded []int64
+	svc := newServiceWithRepo(stubRepository{})
+	svc.allOrdersReader = stubRepository{
+		fetchAllOrdersForGetAllFunc: func(ctx context.Context, f GetAllOrdersFilter, offset, limit int) ([]FullOrder, error) {
+			require.Equal(t, []int64{1, 2, 3, 5}, f.OrderIDs)
+			require.Nil(t, f.Status, "the records are looked up by order id alone")
+			return []FullOrder{
+				{OrderID: 1, StatusID: 17, UpdateTime: sql.NullInt64{Int64: 200, Valid: true}},
+				{OrderID: 2, StatusID: 17, UpdateTime: sql.NullInt64{Int64: 100, Valid: true}},
+				{OrderID: 5, StatusID: 17, UpdateTime: sql.NullInt64{Int64: 100, Valid: true}},
+			}, nil
+		},
+	}
+	svc.statsReader = stubRepository{
+		aggregateOrdersFunc: func(ctx context.Context, f OrderStatsFilter) ([]OrderStatsRow, error) {
+			gotExcluded = f.ExcludedOrderIDs
+			// Orders 1 and 4, order 2 is left to Redis.
+			return []OrderStatsRow{{Key: "10", StatusID: 17, Count: 2}}, nil
+		},
+	}
+	svc.activeOrdersReader = stubActiveOrdersReader{getFunc: func(ctx context.Context, tenantID int64) ([]FormattedOrder, error) {
+		return []FormattedOrder{
+			{OrderID: 1, StatusID: 17, TariffID: 10, UpdateTime: 150},
+			{OrderID: 2, StatusID: 17, TariffID: 10, UpdateTime: 150},
+			{OrderID: 3, StatusID: 17, TariffID: 10},
+			// Completed since MySQL was written, so neither record counts.
+			{OrderID: 5, StatusID: 37, TariffID: 10, UpdateTime: 150},
+		}, nil
+	}}
+
+	stats, err := svc.GetOrderStats(context.Background(), OrderStatsFilter{
+		GetAllOrdersFilter: GetAllOrdersFilter{BaseFilter: BaseFilter{TenantID: 68}, SearchStatus: "works"},
+		GroupBy:            StatsGroupTariff,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 5}, gotExcluded)
+	require.Equal(t, int64(4), stats.Total.Count, "orders 1 and 4 from MySQL, 2 and 3 from Redis")
+}
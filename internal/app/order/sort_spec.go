@@ -0,0 +1,217 @@
+package order
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Sort fields of the sort request form. The MySQL repository maps every one
+// of them to a column, the in-memory sorter reads the same value from the
+// formatted order, so MySQL and Redis orders interleave correctly. Wait times
+// only live in the active orders, the service hands them to both sides.
+// Tariffs, cities and statuses sort by id: their names are translated and
+// neither the active orders nor the SQL of the lists carry them.
+const (
+	SortFieldStatusTime     = "status_time"
+	SortFieldOrderID        = "order_id"
+	SortFieldOrderTime      = "order_time"
+	SortFieldSummaryCost    = "summary_cost"
+	SortFieldTariffID       = "tariff_id"
+	SortFieldCityID         = "city_id"
+	SortFieldWorkerCallsign = "worker_callsign"
+	SortFieldStatusID       = "status_id"
+	SortFieldWaitTime       = "wait_time"
+)
+
+// MaxSortKeys caps the keys of one sort.
+const MaxSortKeys = 4
+
+// SortKey is one key of a multi-field sort.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// sortValue returns the sort value of an order and false for a missing value.
+// Missing values sort before any value, like NULL in MySQL.
+type sortValue func(o FormattedOrder) (float64, bool)
+
+var sortValues = map[string]sortValue{
+	SortFieldStatusTime: func(o FormattedOrder) (float64, bool) { return float64(o.StatusTime), true },
+	SortFieldOrderID:    func(o FormattedOrder) (float64, bool) { return float64(o.OrderID), true },
+	SortFieldOrderTime:  func(o FormattedOrder) (float64, bool) { return float64(o.OrderTime), true },
+	SortFieldSummaryCost: func(o FormattedOrder) (float64, bool) {
+		if o.SummaryCost == nil {
+			return 0, false
+		}
+		// CAST in MySQL turns text that is not a number into 0 as well.
+		value, err := strconv.ParseFloat(strings.TrimSpace(*o.SummaryCost), 64)
+		if err != nil {
+			return 0, true
+		}
+		return value, true
+	},
+	SortFieldTariffID: func(o FormattedOrder) (float64, bool) { return float64(o.TariffID), true },
+	SortFieldCityID:   func(o FormattedOrder) (float64, bool) { return float64(o.CityID), true },
+	SortFieldWorkerCallsign: func(o FormattedOrder) (float64, bool) {
+		if o.Callsign == nil {
+			return 0, false
+		}
+		return float64(*o.Callsign), true
+	},
+	SortFieldStatusID: func(o FormattedOrder) (float64, bool) { return float64(o.StatusID), true },
+	SortFieldWaitTime: func(o FormattedOrder) (float64, bool) { return float64(WorkerWaitingTime(o)), true },
+}
+
+// NeedsWorkerWaitingTime reports whether a worker waits for the client of o,
+// the only orders that show a wait time.
+func NeedsWorkerWaitingTime(o FormattedOrder) bool {
+	return o.StatusID == statusWorkerWaiting && (o.WorkerID != nil || o.Worker.WorkerID > 0)
+}
+
+// WorkerWaitingTime returns the wait time o shows and sorts by, zero unless
+// a worker waits for the client.
+func WorkerWaitingTime(o FormattedOrder) int64 {
+	if !NeedsWorkerWaitingTime(o) {
+		return 0
+	}
+	return o.WaitTime
+}
+
+// ActiveWaitTimes returns the non-zero wait times of the active orders by
+// order id. Only the active order payloads carry them, MySQL orders look
+// their wait time up here.
+func ActiveWaitTimes(orders []FormattedOrder) map[int64]int64 {
+	result := make(map[int64]int64)
+	for _, o := range orders {
+		if o.WaitTime != 0 {
+			result[o.OrderID] = o.WaitTime
+		}
+	}
+	return result
+}
+
+// cursorSortFields are the sort fields keyset pagination supports, with the
+// field name the page cursor was issued with.
+var cursorSortFields = map[string]string{
+	SortFieldStatusTime: "o.status_time",
+	SortFieldOrderID:    "o.order_id",
+	SortFieldOrderTime:  "o.order_time",
+}
+
+// SortFields lists the fields of the sort request form.
+func SortFields() []string {
+	return []string{
+		SortFieldStatusTime,
+		SortFieldOrderID,
+		SortFieldOrderTime,
+		SortFieldSummaryCost,
+		SortFieldTariffID,
+		SortFieldCityID,
+		SortFieldWorkerCallsign,
+		SortFieldStatusID,
+		SortFieldWaitTime,
+	}
+}
+
+// ParseSortField resolves a sort field of the request, the column names of
+// sort_field like "o.order_time" are accepted as well.
+func ParseSortField(value string) (string, bool) {
+	field := strings.TrimPrefix(value, "o.")
+	if _, ok := sortValues[field]; !ok {
+		return "", false
+	}
+	return field, true
+}
+
+// ListSort returns the sort of the order lists: the sort keys of f or else
+// sort_field and sort_order, where an unknown field means status_time.
+func ListSort(f BaseFilter) []SortKey {
+	if len(f.Sort) > 0 {
+		return f.Sort
+	}
+	field, _ := ParseSortField(NormalizeSortField(f.SortField))
+	return []SortKey{{Field: field, Desc: SortDescending(f.SortOrder)}}
+}
+
+// GetAllSort returns the sort of the all-orders search: the sort keys of f or
+// else sort_field and sort_order, where anything but order_time means
+// order_id.
+func GetAllSort(f BaseFilter) []SortKey {
+	if len(f.Sort) > 0 {
+		return f.Sort
+	}
+	field, _ := ParseSortField(GetAllSortKey(f.SortField))
+	return []SortKey{{Field: field, Desc: SortDescending(f.SortOrder)}}
+}
+
+// HasSortField reports whether keys sort by field.
+func HasSortField(keys []SortKey, field string) bool {
+	for _, key := range keys {
+		if key.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// TieBreakDesc is the direction of the order_id key appended to every sort
+// that does not contain order_id: the one of the first key.
+func TieBreakDesc(keys []SortKey) bool {
+	return len(keys) > 0 && keys[0].Desc
+}
+
+// CursorSort returns the cursor field and direction of a sort. Only a single
+// key on status_time, order_id or order_time can be paged with a cursor,
+// other sorts use page and page_size.
+func CursorSort(keys []SortKey) (string, bool, bool) {
+	if len(keys) != 1 {
+		return "", false, false
+	}
+	field, ok := cursorSortFields[keys[0].Field]
+	return field, keys[0].Desc, ok
+}
+
+// OrdersLess compares orders by keys and breaks ties by order_id, mirroring
+// the ORDER BY the MySQL repository writes for the same keys.
+func OrdersLess(keys []SortKey) func(a, b FormattedOrder) bool {
+	tieDesc := TieBreakDesc(keys)
+
+	return func(a, b FormattedOrder) bool {
+		for _, key := range keys {
+			value := sortValues[key.Field]
+			if value == nil {
+				continue
+			}
+			left, leftOK := value(a)
+			right, rightOK := value(b)
+			if cmp := compareSortValues(left, leftOK, right, rightOK); cmp != 0 {
+				if key.Desc {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+		}
+		if tieDesc {
+			return a.OrderID > b.OrderID
+		}
+		return a.OrderID < b.OrderID
+	}
+}
+
+func compareSortValues(left float64, leftOK bool, right float64, rightOK bool) int {
+	switch {
+	case !leftOK && !rightOK:
+		return 0
+	case !leftOK:
+		return -1
+	case !rightOK:
+		return 1
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	default:
+		return 0
+	}
+}
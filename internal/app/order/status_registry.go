@@ -0,0 +1,285 @@
+package order
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+const (
+	StatusColorRed   = "#cc1919"
+	StatusColorGreen = "#088142"
+)
+
+// requiredStatusCategories are the categories the tabs, the search statuses
+// and the summary cost resolution rely on.
+var requiredStatusCategories = []string{"new", "works", "warning", "pre_order", "completed", "rejected"}
+
+type StatusCategoryConfig struct {
+	Name     string  `json:"name"`
+	Statuses []int64 `json:"statuses"`
+}
+
+// StatusOverrideConfig changes the registry for one tenant. A category listed
+// here replaces the default category with the same name, unknown names are
+// appended. RedStatuses replaces the default list when it is not nil.
+type StatusOverrideConfig struct {
+	Categories  []StatusCategoryConfig `json:"categories"`
+	RedStatuses []int64                `json:"red_statuses"`
+}
+
+// StatusRegistryConfig is the single definition of status categories, tab
+// groups and colors. Categories are ordered: a status listed in several of
+// them gets the first one as its category, while every category still acts as
+// a group with all of its statuses.
+type StatusRegistryConfig struct {
+	Categories  []StatusCategoryConfig          `json:"categories"`
+	RedStatuses []int64                         `json:"red_statuses"`
+	Tenants     map[string]StatusOverrideConfig `json:"tenants"`
+}
+
+type statusCategory struct {
+	name     string
+	ids      []int64
+	statuses map[int64]struct{}
+}
+
+type statusSet struct {
+	categories []statusCategory
+	red        map[int64]struct{}
+}
+
+type StatusRegistry struct {
+	base    statusSet
+	tenants map[int64]statusSet
+}
+
+var defaultStatuses = mustStatusRegistry(defaultStatusRegistryConfig)
+
+// DefaultStatusRegistry returns the built-in registry.
+func DefaultStatusRegistry() *StatusRegistry {
+	return defaultStatuses
+}
+
+func mustStatusRegistry(cfg StatusRegistryConfig) *StatusRegistry {
+	registry, err := NewStatusRegistry(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// NewStatusRegistry builds a registry and checks it for consistency.
+func NewStatusRegistry(cfg StatusRegistryConfig) (*StatusRegistry, error) {
+	registry := &StatusRegistry{
+		base:    newStatusSet(cfg.Categories, cfg.RedStatuses),
+		tenants: make(map[int64]statusSet, len(cfg.Tenants)),
+	}
+
+	for key, override := range cfg.Tenants {
+		tenantID, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || tenantID <= 0 {
+			return nil, fmt.Errorf("status registry: invalid tenant id %q", key)
+		}
+
+		categories := mergeStatusCategories(cfg.Categories, override.Categories)
+		red := cfg.RedStatuses
+		if override.RedStatuses != nil {
+			red = override.RedStatuses
+		}
+		registry.tenants[tenantID] = newStatusSet(categories, red)
+	}
+
+	if err := registry.Validate(); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// LoadStatusRegistryFile reads a StatusRegistryConfig from a JSON file.
+func LoadStatusRegistryFile(path string) (*StatusRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("status registry: %w", err)
+	}
+
+	var cfg StatusRegistryConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("status registry: decode %s: %w", path, err)
+	}
+
+	return NewStatusRegistry(cfg)
+}
+
+func mergeStatusCategories(base, override []StatusCategoryConfig) []StatusCategoryConfig {
+	result := make([]StatusCategoryConfig, len(base))
+	copy(result, base)
+
+	for _, category := range override {
+		replaced := false
+		for i := range result {
+			if result[i].Name == category.Name {
+				result[i] = category
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, category)
+		}
+	}
+
+	return result
+}
+
+func newStatusSet(categories []StatusCategoryConfig, red []int64) statusSet {
+	set := statusSet{
+		categories: make([]statusCategory, 0, len(categories)),
+		red:        toSet(red),
+	}
+
+	for _, category := range categories {
+		ids := append([]int64(nil), category.Statuses...)
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+		set.categories = append(set.categories, statusCategory{
+			name:     category.Name,
+			ids:      ids,
+			statuses: toSet(ids),
+		})
+	}
+
+	return set
+}
+
+// Validate rejects registries the service cannot work with: missing or
+// duplicated categories, invalid status ids and colors for unknown statuses.
+func (r *StatusRegistry) Validate() error {
+	if err := r.base.validate(); err != nil {
+		return fmt.Errorf("status registry: %w", err)
+	}
+
+	for tenantID, set := range r.tenants {
+		if err := set.validate(); err != nil {
+			return fmt.Errorf("status registry: tenant %d: %w", tenantID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s statusSet) validate() error {
+	seen := make(map[string]struct{}, len(s.categories))
+	for _, category := range s.categories {
+		if category.name == "" {
+			return fmt.Errorf("category without name")
+		}
+		if _, ok := seen[category.name]; ok {
+			return fmt.Errorf("duplicate category %q", category.name)
+		}
+		seen[category.name] = struct{}{}
+
+		for _, statusID := range category.ids {
+			if statusID <= 0 {
+				return fmt.Errorf("category %q: invalid status id %d", category.name, statusID)
+			}
+		}
+		if len(category.ids) != len(category.statuses) {
+			return fmt.Errorf("category %q: duplicate status ids", category.name)
+		}
+	}
+
+	for _, name := range requiredStatusCategories {
+		if _, ok := seen[name]; !ok {
+			return fmt.Errorf("missing category %q", name)
+		}
+	}
+
+	for statusID := range s.red {
+		if s.category(statusID) == "" {
+			return fmt.Errorf("red status %d has no category", statusID)
+		}
+	}
+
+	return nil
+}
+
+func (r *StatusRegistry) set(tenantID int64) statusSet {
+	if set, ok := r.tenants[tenantID]; ok {
+		return set
+	}
+	return r.base
+}
+
+func (s statusSet) category(statusID int64) string {
+	for _, c := range s.categories {
+		if _, ok := c.statuses[statusID]; ok {
+			return c.name
+		}
+	}
+	return ""
+}
+
+// Category returns the first category the status belongs to.
+func (r *StatusRegistry) Category(tenantID, statusID int64) string {
+	return r.set(tenantID).category(statusID)
+}
+
+func (r *StatusRegistry) Color(tenantID, statusID int64) string {
+	if _, ok := r.set(tenantID).red[statusID]; ok {
+		return StatusColorRed
+	}
+	return StatusColorGreen
+}
+
+// GroupStatuses returns every status of the group in ascending order.
+func (r *StatusRegistry) GroupStatuses(tenantID int64, group string) []int64 {
+	for _, c := range r.set(tenantID).categories {
+		if c.name == group {
+			return append([]int64(nil), c.ids...)
+		}
+	}
+	return nil
+}
+
+func (r *StatusRegistry) InGroup(tenantID, statusID int64, group string) bool {
+	for _, c := range r.set(tenantID).categories {
+		if c.name != group {
+			continue
+		}
+		_, ok := c.statuses[statusID]
+		return ok
+	}
+	return false
+}
+
+// SearchStatusIDs returns the statuses matched by a search status of the
+// all-orders search. The second result is false when the search status does
+// not filter at all; an empty slice with true means nothing can match.
+func (r *StatusRegistry) SearchStatusIDs(tenantID int64, searchStatus string) ([]int64, bool) {
+	groups, ok := searchStatusGroups(searchStatus)
+	if !ok {
+		return nil, false
+	}
+
+	seen := make(map[int64]struct{})
+	result := make([]int64, 0)
+	for _, group := range groups {
+		for _, statusID := range r.GroupStatuses(tenantID, group) {
+			if _, ok := seen[statusID]; ok {
+				continue
+			}
+			seen[statusID] = struct{}{}
+			result = append(result, statusID)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result, true
+}
@@ -0,0 +1,107 @@
+package order
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultStatusRegistry_TabsAndCategoriesAgree(t *testing.T) {
+	registry := DefaultStatusRegistry()
+
+	require.NoError(t, registry.Validate())
+	require.Equal(t, "works", registry.Category(0, 136))
+	require.Contains(t, registry.GroupStatuses(0, string(StatusGroup8)), int64(136))
+	require.NotContains(t, registry.GroupStatuses(0, string(StatusGroup0)), int64(136))
+	require.Equal(t, StatusColorRed, registry.Color(0, 27))
+	require.Equal(t, StatusColorGreen, registry.Color(0, 36))
+}
+
+func TestNewStatusRegistry_AppliesTenantOverrides(t *testing.T) {
+	cfg := defaultStatusRegistryConfig
+	cfg.Tenants = map[string]StatusOverrideConfig{
+		"68": {
+			Categories: []StatusCategoryConfig{
+				{Name: "works", Statuses: []int64{17, 26, 200}},
+			},
+			RedStatuses: []int64{200},
+		},
+	}
+
+	registry, err := NewStatusRegistry(cfg)
+
+	require.NoError(t, err)
+	require.Equal(t, "works", registry.Category(68, 200))
+	require.Equal(t, "", registry.Category(69, 200))
+	require.Equal(t, []int64{17, 26, 200}, registry.GroupStatuses(68, "works"))
+	require.Equal(t, StatusColorRed, registry.Color(68, 200))
+	require.Equal(t, StatusColorGreen, registry.Color(68, 27))
+	require.Equal(t, StatusColorRed, registry.Color(69, 27))
+}
+
+func TestNewStatusRegistry_RejectsInconsistentConfig(t *testing.T) {
+	_, err := NewStatusRegistry(StatusRegistryConfig{
+		Categories: []StatusCategoryConfig{{Name: "new", Statuses: []int64{1}}},
+	})
+	require.ErrorContains(t, err, `missing category "works"`)
+
+	cfg := defaultStatusRegistryConfig
+	cfg.RedStatuses = []int64{999}
+	_, err = NewStatusRegistry(cfg)
+	require.ErrorContains(t, err, "red status 999 has no category")
+
+	cfg = defaultStatusRegistryConfig
+	cfg.Tenants = map[string]StatusOverrideConfig{"abc": {}}
+	_, err = NewStatusRegistry(cfg)
+	require.ErrorContains(t, err, "invalid tenant id")
+}
+
+func TestLoadStatusRegistryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "statuses.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"categories": [
+			{"name": "new", "statuses": [1]},
+			{"name": "works", "statuses": [17, 136]},
+			{"name": "warning", "statuses": [27]},
+			{"name": "pre_order", "statuses": [6]},
+			{"name": "completed", "statuses": [37]},
+			{"name": "rejected", "statuses": [39]}
+		],
+		"red_statuses": [27]
+	}`), 0o600))
+
+	registry, err := LoadStatusRegistryFile(path)
+
+	require.NoError(t, err)
+	require.Equal(t, "works", registry.Category(0, 136))
+	require.Equal(t, StatusColorRed, registry.Color(0, 27))
+}
+
+func TestGetOrdersForTabs_UsesTenantStatusGroups(t *testing.T) {
+	cfg := defaultStatusRegistryConfig
+	cfg.Tenants = map[string]StatusOverrideConfig{
+		"68": {Categories: []StatusCategoryConfig{{Name: "works", Statuses: []int64{200}}}},
+	}
+	registry, err := NewStatusRegistry(cfg)
+	require.NoError(t, err)
+
+	var worksStatuses []int64
+	repo := stubRepository{
+		fetchOrdersByStatusGroup: func(ctx context.Context, f BaseFilter) ([]int64, error) {
+			if containsInt64(f.Status, 200) {
+				worksStatuses = f.Status
+			}
+			return nil, nil
+		},
+	}
+	svc := newServiceWithRepo(repo)
+	WithStatusRegistry(registry)(svc)
+
+	_, err = svc.GetOrdersForTabs(context.Background(), WarningFilter{BaseFilter: BaseFilter{TenantID: 68}})
+
+	require.NoError(t, err)
+	require.Equal(t, []int64{200}, worksStatuses)
+}
@@ -19,14 +19,11 @@ const (
 type GroupOrdersResult struct {
 	GroupCounts     map[StatusGroup]int
 	OrdersForSignal map[StatusGroup][]int64
+	// Warnings tells how old the warning set of the warning tab is.
+	Warnings WarningFreshness
 }
 
-var orderGroupIDs = map[StatusGroup][]int64{
-	StatusGroup0: {1, 2, 3, 4, 5, 52, 108, 109, 115, 127, 128, 130, 131},
-	StatusGroup6: {6, 7, 16, 111, 112, 116, 117, 118, 119},
-	StatusGroup7: {5, 10, 16, 27, 30, 38, 45, 46, 47, 48, 52, 54, 117, 118, 129, 135},
-	StatusGroup8: {17, 26, 27, 29, 30, 36, 54, 55, 106, 110, 113, 114, 132, 133, 134, 135, 136},
-}
+var tabGroups = []StatusGroup{StatusGroup0, StatusGroup6, StatusGroup7, StatusGroup8}
 
 func (s *service) GetOrdersForTabs(
 	ctx context.Context,
@@ -37,9 +34,9 @@ func (s *service) GetOrdersForTabs(
 
 	g, groupCtx := errgroup.WithContext(ctx)
 
-	for group, statusIDs := range orderGroupIDs {
+	for _, group := range tabGroups {
 		group := group
-		statusIDs := statusIDs
+		statusIDs := s.statusRegistry().GroupStatuses(f.BaseFilter.TenantID, string(group))
 
 		bf := f.BaseFilter
 		bf.Status = statusIDs
@@ -63,10 +60,11 @@ func (s *service) GetOrdersForTabs(
 	}
 
 	f.BaseFilter.SelectForDate = true
-	warningIDs, err := s.GetWarningOrder(ctx, f)
+	warnings, freshness, err := s.warningReasons(ctx, f)
 	if err != nil {
 		return GroupOrdersResult{}, err
 	}
+	warningIDs := warnings.OrderIDs()
 
 	idSet := make(map[int64]struct{})
 	for _, id := range groupOrders[StatusGroup7] {
@@ -93,5 +91,6 @@ func (s *service) GetOrdersForTabs(
 			StatusGroup0: groupOrders[StatusGroup0],
 			StatusGroup6: groupOrders[StatusGroup6],
 		},
+		Warnings: freshness,
 	}, nil
 }
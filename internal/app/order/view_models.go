@@ -27,6 +27,7 @@ type OrderView struct {
 	OrderTime      int64           `json:"order_time"`
 	PositionID     int64           `json:"positionId"`
 	UnitQuantity   *float64        `json:"unit_quantity,omitempty"`
+	Warnings       []WarningReason `json:"warnings"`
 }
 
 type OrderStatusView struct {
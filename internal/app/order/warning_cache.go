@@ -0,0 +1,291 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"orders-service/internal/logging"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultWarningRefreshInterval = 15 * time.Second
+	defaultWarningMaxStale        = 2 * time.Minute
+	defaultWarningIdleTimeout     = 5 * time.Minute
+	defaultWarningLoadTimeout     = 30 * time.Second
+	warningRefreshConcurrency     = 4
+)
+
+// WarningFreshness tells how old the warning set behind a response is. It is
+// zero when no warning cache is configured.
+type WarningFreshness struct {
+	RefreshedAt time.Time
+	Age         time.Duration
+	Stale       bool
+}
+
+type WarningCacheOption func(*WarningCache)
+
+// WithWarningRefreshInterval sets how often the worker recomputes a warning
+// set; a set older than that is stale.
+func WithWarningRefreshInterval(interval time.Duration) WarningCacheOption {
+	return func(c *WarningCache) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WithWarningMaxStale sets the age up to which a stale set is still served
+// while it is recomputed. Older sets are recomputed before the response.
+func WithWarningMaxStale(maxStale time.Duration) WarningCacheOption {
+	return func(c *WarningCache) {
+		if maxStale > 0 {
+			c.maxStale = maxStale
+		}
+	}
+}
+
+// WithWarningIdleTimeout drops sets nobody read for that long, so the worker
+// only refreshes filters dispatchers still look at.
+func WithWarningIdleTimeout(timeout time.Duration) WarningCacheOption {
+	return func(c *WarningCache) {
+		if timeout > 0 {
+			c.idleTimeout = timeout
+		}
+	}
+}
+
+// WithWarningLoadTimeout bounds one computation of a set. It runs detached
+// from the request that started it, so the callers sharing it are not failed
+// by the first one giving up.
+func WithWarningLoadTimeout(timeout time.Duration) WarningCacheOption {
+	return func(c *WarningCache) {
+		if timeout > 0 {
+			c.loadTimeout = timeout
+		}
+	}
+}
+
+type warningEntry struct {
+	filter      WarningFilter
+	reasons     WarningReasons
+	refreshedAt time.Time
+	lastRead    time.Time
+	refreshing  bool
+}
+
+// WarningCache keeps the warning set per tenant and filter. Run refreshes the
+// sets in the background; a set that went stale meanwhile is served while it
+// is recomputed. The tabs and the warning list of one request read the same
+// set. The returned reasons are shared and must not be modified.
+type WarningCache struct {
+	interval    time.Duration
+	maxStale    time.Duration
+	idleTimeout time.Duration
+	loadTimeout time.Duration
+	load        func(ctx context.Context, f WarningFilter) (WarningReasons, error)
+	now         func() time.Time
+	group       singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*warningEntry
+}
+
+func NewWarningCache(opts ...WarningCacheOption) *WarningCache {
+	c := &WarningCache{
+		interval:    defaultWarningRefreshInterval,
+		maxStale:    defaultWarningMaxStale,
+		idleTimeout: defaultWarningIdleTimeout,
+		loadTimeout: defaultWarningLoadTimeout,
+		now:         time.Now,
+		entries:     make(map[string]*warningEntry),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxStale < c.interval {
+		c.maxStale = c.interval
+	}
+	return c
+}
+
+// Get returns the warning set of f. A missing set, or one older than the
+// max staleness, is computed before it returns.
+func (c *WarningCache) Get(ctx context.Context, f WarningFilter) (WarningReasons, WarningFreshness, error) {
+	key := warningCacheKey(f)
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok {
+		entry.lastRead = now
+		age := now.Sub(entry.refreshedAt)
+		if age < c.maxStale {
+			stale := age >= c.interval
+			if stale && !entry.refreshing {
+				entry.refreshing = true
+				go c.refresh(ctx, key, entry.filter)
+			}
+			reasons, freshness := entry.reasons, WarningFreshness{RefreshedAt: entry.refreshedAt, Age: age, Stale: stale}
+			c.mu.Unlock()
+			return reasons, freshness, nil
+		}
+	}
+	c.mu.Unlock()
+
+	reasons, err := c.refresh(ctx, key, f)
+	if err != nil {
+		return nil, WarningFreshness{}, err
+	}
+	return reasons, WarningFreshness{RefreshedAt: now}, nil
+}
+
+// Refresh recomputes every warning set of the tenant now and returns how
+// many there were.
+func (c *WarningCache) Refresh(ctx context.Context, tenantID int64) (int, error) {
+	c.mu.Lock()
+	filters := make(map[string]WarningFilter)
+	for key, entry := range c.entries {
+		if entry.filter.BaseFilter.TenantID == tenantID {
+			filters[key] = entry.filter
+		}
+	}
+	c.mu.Unlock()
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(warningRefreshConcurrency)
+	for key, f := range filters {
+		g.Go(func() error {
+			_, err := c.refresh(gctx, key, f)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return 0, err
+	}
+
+	logging.Info(ctx, "warning cache refreshed", "tenant_id", tenantID, "filters", len(filters))
+	return len(filters), nil
+}
+
+// Run refreshes the stale sets every refresh interval and drops idle ones
+// until ctx is done.
+func (c *WarningCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.refreshDue(ctx)
+		}
+	}
+}
+
+func (c *WarningCache) refreshDue(ctx context.Context) {
+	now := c.now()
+	due := make(map[string]WarningFilter)
+
+	c.mu.Lock()
+	for key, entry := range c.entries {
+		switch {
+		case now.Sub(entry.lastRead) >= c.idleTimeout:
+			delete(c.entries, key)
+		case !entry.refreshing && now.Sub(entry.refreshedAt) >= c.interval:
+			entry.refreshing = true
+			due[key] = entry.filter
+		}
+	}
+	c.mu.Unlock()
+
+	var g errgroup.Group
+	g.SetLimit(warningRefreshConcurrency)
+	for key, f := range due {
+		g.Go(func() error {
+			// A failed refresh keeps the old set until it is too stale.
+			_, _ = c.refresh(ctx, key, f)
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// refresh computes the set of key once for all concurrent callers and stores
+// it. The computation runs without the cancellation of the caller that
+// started it and within the load timeout, every caller only gives up on its
+// own ctx.
+func (c *WarningCache) refresh(ctx context.Context, key string, f WarningFilter) (WarningReasons, error) {
+	result := c.group.DoChan(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.loadTimeout)
+		defer cancel()
+
+		started := time.Now()
+		reasons, err := c.load(ctx, f)
+		refreshMS := observeStage("warning_cache", "refresh", started)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		entry := c.entries[key]
+		if err != nil {
+			if entry != nil {
+				entry.refreshing = false
+			}
+			logging.Error(ctx, "warning cache refresh failed", err,
+				"tenant_id", f.BaseFilter.TenantID,
+				"duration_ms", refreshMS,
+			)
+			return nil, err
+		}
+
+		now := c.now()
+		if entry == nil {
+			entry = &warningEntry{filter: f, lastRead: now}
+			c.entries[key] = entry
+		}
+		entry.reasons = reasons
+		entry.refreshedAt = now
+		entry.refreshing = false
+		return reasons, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(WarningReasons), nil
+	}
+}
+
+// warningCacheKey keys a filter by the fields the warning rules read. The
+// group, language, sort and paging of the list do not change the warning
+// set, and the status time range only counts when select_for_date applies
+// it, so the tabs and the warning list of one request share a key.
+func warningCacheKey(f WarningFilter) string {
+	base := f.BaseFilter
+	base.Group = ""
+	base.Language = ""
+	base.SortField = ""
+	base.SortOrder = ""
+	base.Sort = nil
+	base.Cursor = nil
+	if !base.SelectForDate || base.StatusTimeFrom == nil || base.StatusTimeTo == nil {
+		base.SelectForDate = false
+		base.StatusTimeFrom = nil
+		base.StatusTimeTo = nil
+	}
+	f.BaseFilter = base
+
+	payload, _ := json.Marshal(f)
+	return strconv.FormatInt(base.TenantID, 10) + ":" + string(payload)
+}
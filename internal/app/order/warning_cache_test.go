@@ -0,0 +1,188 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWarningCache(now *time.Time, loads *atomic.Int32, opts ...WarningCacheOption) *WarningCache {
+	c := NewWarningCache(opts...)
+	c.now = func() time.Time { return *now }
+	c.load = func(ctx context.Context, f WarningFilter) (WarningReasons, error) {
+		n := loads.Add(1)
+		return WarningReasons{int64(n): {{Code: WarningUnpaid}}}, nil
+	}
+	return c
+}
+
+func TestWarningCache_ServesStaleSetWhileRefreshing(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var loads atomic.Int32
+	c := newTestWarningCache(&now, &loads,
+		WithWarningRefreshInterval(10*time.Second),
+		WithWarningMaxStale(time.Minute),
+	)
+	f := WarningFilter{BaseFilter: BaseFilter{TenantID: 68}}
+
+	reasons, freshness, err := c.Get(context.Background(), f)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1}, reasons.OrderIDs())
+	require.Equal(t, WarningFreshness{RefreshedAt: now}, freshness)
+
+	now = now.Add(5 * time.Second)
+	_, freshness, err = c.Get(context.Background(), f)
+	require.NoError(t, err)
+	require.False(t, freshness.Stale)
+	require.Equal(t, 5*time.Second, freshness.Age)
+	require.Equal(t, int32(1), loads.Load())
+
+	now = now.Add(10 * time.Second)
+	reasons, freshness, err = c.Get(context.Background(), f)
+	require.NoError(t, err)
+	require.True(t, freshness.Stale)
+	require.Equal(t, []int64{1}, reasons.OrderIDs(), "the stale set is served while it is recomputed")
+	require.Eventually(t, func() bool {
+		reasons, freshness, _ := c.Get(context.Background(), f)
+		return !freshness.Stale && len(reasons) == 1 && reasons.OrderIDs()[0] == 2
+	}, time.Second, time.Millisecond)
+
+	now = now.Add(time.Minute)
+	reasons, freshness, err = c.Get(context.Background(), f)
+	require.NoError(t, err)
+	require.False(t, freshness.Stale)
+	require.Equal(t, []int64{3}, reasons.OrderIDs(), "a set older than the max staleness is recomputed first")
+}
+
+func TestWarningCache_TabsAndWarningListShareASet(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var loads atomic.Int32
+	c := newTestWarningCache(&now, &loads)
+
+	list := WarningFilter{BaseFilter: BaseFilter{TenantID: 68, CityIDs: []int64{26068}, Group: "warning", SortField: "order_id"}}
+	tabs := list
+	tabs.BaseFilter.Group = ""
+	tabs.BaseFilter.SortField = ""
+	tabs.BaseFilter.SelectForDate = true
+
+	_, _, err := c.Get(context.Background(), list)
+	require.NoError(t, err)
+	_, _, err = c.Get(context.Background(), tabs)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), loads.Load())
+
+	other := list
+	other.BaseFilter.CityIDs = []int64{26069}
+	_, _, err = c.Get(context.Background(), other)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), loads.Load())
+}
+
+func TestWarningCache_RefreshRecomputesTenantSets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var loads atomic.Int32
+	c := newTestWarningCache(&now, &loads)
+
+	for _, f := range []WarningFilter{
+		{BaseFilter: BaseFilter{TenantID: 68, CityIDs: []int64{26068}}},
+		{BaseFilter: BaseFilter{TenantID: 68, CityIDs: []int64{26069}}},
+		{BaseFilter: BaseFilter{TenantID: 70}},
+	} {
+		_, _, err := c.Get(context.Background(), f)
+		require.NoError(t, err)
+	}
+
+	refreshed, err := c.Refresh(context.Background(), 68)
+	require.NoError(t, err)
+	require.Equal(t, 2, refreshed)
+	require.Equal(t, int32(5), loads.Load())
+
+	boom := errors.New("mysql down")
+	c.load = func(ctx context.Context, f WarningFilter) (WarningReasons, error) { return nil, boom }
+	_, err = c.Refresh(context.Background(), 70)
+	require.ErrorIs(t, err, boom)
+
+	_, freshness, err := c.Get(context.Background(), WarningFilter{BaseFilter: BaseFilter{TenantID: 70}})
+	require.NoError(t, err, "a failed refresh keeps the old set")
+	require.Equal(t, now, freshness.RefreshedAt)
+}
+
+func TestWarningCache_CancelledCallerDoesNotFailSharedRefresh(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var loads atomic.Int32
+	c := newTestWarningCache(&now, &loads)
+	release := make(chan struct{})
+	loading := make(chan struct{}, 1)
+	c.load = func(ctx context.Context, f WarningFilter) (WarningReasons, error) {
+		n := loads.Add(1)
+		loading <- struct{}{}
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return WarningReasons{int64(n): {{Code: WarningUnpaid}}}, nil
+	}
+	f := WarningFilter{BaseFilter: BaseFilter{TenantID: 68}}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, _, err := c.Get(first, f)
+		firstErr <- err
+	}()
+	<-loading
+
+	type result struct {
+		reasons WarningReasons
+		err     error
+	}
+	second := make(chan result, 1)
+	go func() {
+		reasons, _, err := c.Get(context.Background(), f)
+		second <- result{reasons, err}
+	}()
+	cancel()
+	require.ErrorIs(t, <-firstErr, context.Canceled)
+
+	close(release)
+	got := <-second
+	require.NoError(t, got.err, "the load outlives the caller that started it")
+	require.Equal(t, []int64{1}, got.reasons.OrderIDs())
+	require.Equal(t, int32(1), loads.Load())
+}
+
+func TestWarningCache_RefreshDueDropsIdleSets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var loads atomic.Int32
+	c := newTestWarningCache(&now, &loads,
+		WithWarningRefreshInterval(10*time.Second),
+		WithWarningIdleTimeout(30*time.Second),
+	)
+	read := WarningFilter{BaseFilter: BaseFilter{TenantID: 68}}
+	idle := WarningFilter{BaseFilter: BaseFilter{TenantID: 70}}
+
+	_, _, err := c.Get(context.Background(), read)
+	require.NoError(t, err)
+	_, _, err = c.Get(context.Background(), idle)
+	require.NoError(t, err)
+
+	now = now.Add(20 * time.Second)
+	_, _, err = c.Get(context.Background(), read)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, freshness, _ := c.Get(context.Background(), read)
+		return !freshness.Stale
+	}, time.Second, time.Millisecond)
+	require.Equal(t, int32(3), loads.Load())
+
+	now = now.Add(15 * time.Second)
+	c.refreshDue(context.Background())
+	require.Equal(t, int32(4), loads.Load())
+	require.Len(t, c.entries, 1)
+	require.Contains(t, c.entries, warningCacheKey(read))
+}
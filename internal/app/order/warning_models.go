@@ -0,0 +1,65 @@
+package order
+
+import "sort"
+
+const (
+	WarningUnpaid        = "unpaid"
+	WarningBadReview     = "bad_review"
+	WarningExceededPrice = "exceeded_price"
+
+	WarningDriverLate       = "driver_late"
+	WarningWorkerWaiting    = "worker_waiting"
+	WarningNoWorkerAssigned = "no_worker_assigned"
+	WarningPreOrderNoDriver = "preorder_no_driver"
+)
+
+// WarningReason explains why an order is in the warning tab. Details hold the
+// values that triggered the reason, e.g. the review rating or how far the
+// realtime price exceeded the limit.
+type WarningReason struct {
+	Code    string         `json:"code"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type BadReviewWarning struct {
+	OrderID int64
+	Rating  int64
+}
+
+type ExceededPriceWarning struct {
+	OrderID       int64
+	RealtimePrice float64
+}
+
+// WarningCandidate carries the times the time based rules compare against
+// the current time. TimeToClient is in minutes and 0 when unknown.
+type WarningCandidate struct {
+	OrderID      int64
+	StatusID     int64
+	StatusTime   int64
+	CreateTime   int64
+	OrderTime    int64
+	TimeOffset   int64
+	TimeToClient int64
+}
+
+// WarningReasons maps an order id to the reasons it is a warning order.
+type WarningReasons map[int64][]WarningReason
+
+// OrderIDs returns the ids of all warning orders in ascending order.
+func (r WarningReasons) OrderIDs() []int64 {
+	result := make([]int64, 0, len(r))
+	for id := range r {
+		result = append(result, id)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+
+	return result
+}
+
+func (r WarningReasons) add(orderID int64, reason WarningReason) {
+	r[orderID] = append(r[orderID], reason)
+}
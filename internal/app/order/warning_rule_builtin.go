@@ -0,0 +1,370 @@
+package order
+
+import "context"
+
+const (
+	defaultDriverLateGraceMinutes            = 5
+	defaultWorkerWaitingMinutes              = 5
+	defaultNoWorkerAssignedMinutes           = 10
+	defaultPreOrderNoDriverLeadMinutes       = 30
+	statusWorkerOnTheWay               int64 = 17
+	statusWorkerWaiting                int64 = 26
+)
+
+// builtinWarningRules returns the rules every service starts with. The three
+// reader based rules are on by default, the time based ones are opt-in per
+// tenant or request.
+func builtinWarningRules(
+	warnings WarningOrderReader,
+	candidates WarningCandidateReader,
+	waits BulkWaitingTimeProvider,
+	statuses *StatusRegistry,
+) []WarningRule {
+	return []WarningRule{
+		unpaidRule{reader: warnings},
+		badReviewRule{reader: warnings},
+		exceededPriceRule{reader: warnings},
+		driverLateRule{reader: candidates},
+		workerWaitingRule{reader: candidates, waits: waits},
+		noWorkerAssignedRule{reader: candidates, statuses: statuses},
+		preOrderNoDriverRule{reader: candidates, statuses: statuses},
+	}
+}
+
+type unpaidRule struct {
+	reader WarningOrderReader
+}
+
+func (unpaidRule) Code() string { return WarningUnpaid }
+
+func (unpaidRule) DefaultConfig() WarningRuleConfig {
+	return WarningRuleConfig{Enabled: boolPtr(true)}
+}
+
+func (r unpaidRule) Detect(ctx context.Context, in WarningRuleInput) ([]WarningHit, error) {
+	ids, err := r.reader.FetchUnpaid(ctx, UnpaidFilter{
+		BaseFilter:             in.Filter.BaseFilter,
+		StatusCompletedNotPaid: in.Filter.StatusCompletedNotPaid,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]WarningHit, 0, len(ids))
+	seen := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		hits = append(hits, WarningHit{OrderID: id})
+	}
+
+	return hits, nil
+}
+
+type badReviewRule struct {
+	reader WarningOrderReader
+}
+
+func (badReviewRule) Code() string { return WarningBadReview }
+
+func (badReviewRule) DefaultConfig() WarningRuleConfig {
+	return WarningRuleConfig{Enabled: boolPtr(true)}
+}
+
+func (r badReviewRule) Detect(ctx context.Context, in WarningRuleInput) ([]WarningHit, error) {
+	rows, err := r.reader.FetchBadReview(ctx, BadReviewFilter{
+		BaseFilter:   in.Filter.BaseFilter,
+		BadRatingMax: in.Filter.BadRatingMax,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// An order can have several reviews, the worst rating explains the warning.
+	worstRatings := make(map[int64]int64, len(rows))
+	ids := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		rating, ok := worstRatings[row.OrderID]
+		if !ok {
+			ids = append(ids, row.OrderID)
+		}
+		if !ok || row.Rating < rating {
+			worstRatings[row.OrderID] = row.Rating
+		}
+	}
+
+	hits := make([]WarningHit, 0, len(ids))
+	for _, id := range ids {
+		hits = append(hits, WarningHit{
+			OrderID: id,
+			Details: map[string]any{
+				"rating":         worstRatings[id],
+				"bad_rating_max": in.Filter.BadRatingMax,
+			},
+		})
+	}
+
+	return hits, nil
+}
+
+type exceededPriceRule struct {
+	reader WarningOrderReader
+}
+
+func (exceededPriceRule) Code() string { return WarningExceededPrice }
+
+func (exceededPriceRule) DefaultConfig() WarningRuleConfig {
+	return WarningRuleConfig{Enabled: boolPtr(true)}
+}
+
+func (r exceededPriceRule) Detect(ctx context.Context, in WarningRuleInput) ([]WarningHit, error) {
+	rows, err := r.reader.FetchExceededPrice(ctx, ExceededPriceFilter{
+		BaseFilter:     in.Filter.BaseFilter,
+		MinRealPrice:   in.Filter.MinRealPrice,
+		FinishedStatus: in.Filter.FinishedStatus,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]WarningHit, 0, len(rows))
+	seen := make(map[int64]struct{}, len(rows))
+	for _, row := range rows {
+		if _, ok := seen[row.OrderID]; ok {
+			continue
+		}
+		seen[row.OrderID] = struct{}{}
+		hits = append(hits, WarningHit{
+			OrderID: row.OrderID,
+			Details: map[string]any{
+				"realtime_price": row.RealtimePrice,
+				"min_real_price": in.Filter.MinRealPrice,
+				"exceeded_by":    row.RealtimePrice - in.Filter.MinRealPrice,
+			},
+		})
+	}
+
+	return hits, nil
+}
+
+// driverLateRule flags orders whose worker has not arrived time_to_client
+// minutes after the status was set, plus the threshold as grace.
+type driverLateRule struct {
+	reader WarningCandidateReader
+}
+
+func (driverLateRule) Code() string { return WarningDriverLate }
+
+func (driverLateRule) DefaultConfig() WarningRuleConfig {
+	return WarningRuleConfig{
+		Enabled:          boolPtr(false),
+		ThresholdMinutes: int64Ptr(defaultDriverLateGraceMinutes),
+		StatusIDs:        []int64{statusWorkerOnTheWay},
+	}
+}
+
+func (r driverLateRule) Detect(ctx context.Context, in WarningRuleInput) ([]WarningHit, error) {
+	candidates, err := fetchWarningCandidates(ctx, r.reader, in, in.Config.StatusIDs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	now := in.Now.Unix()
+	grace := int64(in.Config.Threshold().Seconds())
+	hits := make([]WarningHit, 0)
+	for _, c := range candidates {
+		if c.TimeToClient <= 0 {
+			continue
+		}
+		lateBy := now - (c.StatusTime + c.TimeToClient*60)
+		if lateBy <= grace {
+			continue
+		}
+		hits = append(hits, WarningHit{
+			OrderID: c.OrderID,
+			Details: map[string]any{
+				"time_to_client":    c.TimeToClient,
+				"late_seconds":      lateBy,
+				"threshold_minutes": *in.Config.ThresholdMinutes,
+			},
+		})
+	}
+
+	return hits, nil
+}
+
+// workerWaitingRule flags orders where the worker waits for the client longer
+// than the threshold. The waiting time comes from the active orders in Redis.
+type workerWaitingRule struct {
+	reader WarningCandidateReader
+	waits  BulkWaitingTimeProvider
+}
+
+func (workerWaitingRule) Code() string { return WarningWorkerWaiting }
+
+func (workerWaitingRule) DefaultConfig() WarningRuleConfig {
+	return WarningRuleConfig{
+		Enabled:          boolPtr(false),
+		ThresholdMinutes: int64Ptr(defaultWorkerWaitingMinutes),
+		StatusIDs:        []int64{statusWorkerWaiting},
+	}
+}
+
+func (r workerWaitingRule) Detect(ctx context.Context, in WarningRuleInput) ([]WarningHit, error) {
+	if r.waits == nil {
+		return nil, nil
+	}
+
+	candidates, err := fetchWarningCandidates(ctx, r.reader, in, in.Config.StatusIDs, false)
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+
+	orderIDs := make([]int64, 0, len(candidates))
+	for _, c := range candidates {
+		orderIDs = append(orderIDs, c.OrderID)
+	}
+
+	waitTimes, err := r.waits.GetWorkerWaitingTimes(ctx, in.Filter.BaseFilter.TenantID, orderIDs)
+	if err != nil {
+		if !Degrade(ctx, DegradedWaitTimes, err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	threshold := int64(in.Config.Threshold().Seconds())
+	hits := make([]WarningHit, 0)
+	for _, orderID := range orderIDs {
+		waiting, ok := waitTimes[orderID]
+		if !ok || waiting <= threshold {
+			continue
+		}
+		hits = append(hits, WarningHit{
+			OrderID: orderID,
+			Details: map[string]any{
+				"waiting_seconds":   waiting,
+				"threshold_minutes": *in.Config.ThresholdMinutes,
+			},
+		})
+	}
+
+	return hits, nil
+}
+
+// noWorkerAssignedRule flags new orders still without a worker the threshold
+// after they were created.
+type noWorkerAssignedRule struct {
+	reader   WarningCandidateReader
+	statuses *StatusRegistry
+}
+
+func (noWorkerAssignedRule) Code() string { return WarningNoWorkerAssigned }
+
+func (noWorkerAssignedRule) DefaultConfig() WarningRuleConfig {
+	return WarningRuleConfig{
+		Enabled:          boolPtr(false),
+		ThresholdMinutes: int64Ptr(defaultNoWorkerAssignedMinutes),
+	}
+}
+
+func (r noWorkerAssignedRule) Detect(ctx context.Context, in WarningRuleInput) ([]WarningHit, error) {
+	statusIDs := in.Config.StatusIDs
+	if len(statusIDs) == 0 {
+		statusIDs = r.statuses.GroupStatuses(in.Filter.BaseFilter.TenantID, "new")
+	}
+
+	candidates, err := fetchWarningCandidates(ctx, r.reader, in, statusIDs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	now := in.Now.Unix()
+	threshold := int64(in.Config.Threshold().Seconds())
+	hits := make([]WarningHit, 0)
+	for _, c := range candidates {
+		unassigned := now - c.CreateTime
+		if c.CreateTime <= 0 || unassigned <= threshold {
+			continue
+		}
+		hits = append(hits, WarningHit{
+			OrderID: c.OrderID,
+			Details: map[string]any{
+				"unassigned_seconds": unassigned,
+				"threshold_minutes":  *in.Config.ThresholdMinutes,
+			},
+		})
+	}
+
+	return hits, nil
+}
+
+// preOrderNoDriverRule flags pre-orders without a worker that start within
+// the threshold or have already started.
+type preOrderNoDriverRule struct {
+	reader   WarningCandidateReader
+	statuses *StatusRegistry
+}
+
+func (preOrderNoDriverRule) Code() string { return WarningPreOrderNoDriver }
+
+func (preOrderNoDriverRule) DefaultConfig() WarningRuleConfig {
+	return WarningRuleConfig{
+		Enabled:          boolPtr(false),
+		ThresholdMinutes: int64Ptr(defaultPreOrderNoDriverLeadMinutes),
+	}
+}
+
+func (r preOrderNoDriverRule) Detect(ctx context.Context, in WarningRuleInput) ([]WarningHit, error) {
+	statusIDs := in.Config.StatusIDs
+	if len(statusIDs) == 0 {
+		statusIDs = r.statuses.GroupStatuses(in.Filter.BaseFilter.TenantID, "pre_order")
+	}
+
+	candidates, err := fetchWarningCandidates(ctx, r.reader, in, statusIDs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	now := in.Now.Unix()
+	lead := int64(in.Config.Threshold().Seconds())
+	hits := make([]WarningHit, 0)
+	for _, c := range candidates {
+		if c.OrderTime <= 0 {
+			continue
+		}
+		startsIn := c.OrderTime - c.TimeOffset - now
+		if startsIn >= lead {
+			continue
+		}
+		hits = append(hits, WarningHit{
+			OrderID: c.OrderID,
+			Details: map[string]any{
+				"starts_in_seconds": startsIn,
+				"threshold_minutes": *in.Config.ThresholdMinutes,
+			},
+		})
+	}
+
+	return hits, nil
+}
+
+func fetchWarningCandidates(
+	ctx context.Context,
+	reader WarningCandidateReader,
+	in WarningRuleInput,
+	statusIDs []int64,
+	withoutWorker bool,
+) ([]WarningCandidate, error) {
+	if reader == nil || len(statusIDs) == 0 || in.Config.ThresholdMinutes == nil {
+		return nil, nil
+	}
+
+	return reader.FetchWarningCandidates(ctx, WarningCandidateFilter{
+		BaseFilter:    in.Filter.BaseFilter,
+		StatusIDs:     statusIDs,
+		WithoutWorker: withoutWorker,
+	})
+}
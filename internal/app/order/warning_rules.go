@@ -0,0 +1,103 @@
+package order
+
+import (
+	"context"
+	"time"
+)
+
+// WarningRule detects one kind of warning order. The rules of a
+// WarningRuleRegistry run in parallel in GetWarningReasons and every hit
+// becomes a WarningReason with the rule code.
+type WarningRule interface {
+	Code() string
+	// DefaultConfig applies when neither the tenant settings nor the request
+	// configure the rule.
+	DefaultConfig() WarningRuleConfig
+	Detect(ctx context.Context, in WarningRuleInput) ([]WarningHit, error)
+}
+
+type WarningRuleInput struct {
+	Filter WarningFilter
+	Config WarningRuleConfig
+	Now    time.Time
+}
+
+type WarningHit struct {
+	OrderID int64
+	Details map[string]any
+}
+
+// WarningRuleConfig enables and parameterizes a rule. Unset fields keep the
+// value of the layer below: rule default, tenant settings, request.
+type WarningRuleConfig struct {
+	Enabled          *bool   `json:"enabled,omitempty"`
+	ThresholdMinutes *int64  `json:"threshold_minutes,omitempty"`
+	StatusIDs        []int64 `json:"status_ids,omitempty"`
+}
+
+func (c WarningRuleConfig) IsEnabled() bool {
+	return c.Enabled != nil && *c.Enabled
+}
+
+func (c WarningRuleConfig) Threshold() time.Duration {
+	if c.ThresholdMinutes == nil {
+		return 0
+	}
+	return time.Duration(*c.ThresholdMinutes) * time.Minute
+}
+
+func (c WarningRuleConfig) merge(override WarningRuleConfig) WarningRuleConfig {
+	if override.Enabled != nil {
+		c.Enabled = override.Enabled
+	}
+	if override.ThresholdMinutes != nil {
+		c.ThresholdMinutes = override.ThresholdMinutes
+	}
+	if override.StatusIDs != nil {
+		c.StatusIDs = override.StatusIDs
+	}
+	return c
+}
+
+// WarningRuleSettings maps a rule code to its configuration.
+type WarningRuleSettings map[string]WarningRuleConfig
+
+type WarningSettingsProvider interface {
+	GetWarningRuleSettings(ctx context.Context, tenantID int64) (WarningRuleSettings, error)
+}
+
+type WarningRuleRegistry struct {
+	rules []WarningRule
+}
+
+func NewWarningRuleRegistry(rules ...WarningRule) *WarningRuleRegistry {
+	registry := &WarningRuleRegistry{}
+	for _, rule := range rules {
+		registry.Register(rule)
+	}
+	return registry
+}
+
+// Register adds a rule. A rule with the code of a registered one replaces it
+// and keeps its position, so reasons stay in registration order.
+func (r *WarningRuleRegistry) Register(rule WarningRule) {
+	for i, registered := range r.rules {
+		if registered.Code() == rule.Code() {
+			r.rules[i] = rule
+			return
+		}
+	}
+	r.rules = append(r.rules, rule)
+}
+
+func (r *WarningRuleRegistry) Rules() []WarningRule {
+	return append([]WarningRule(nil), r.rules...)
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
@@ -0,0 +1,169 @@
+package order
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubWarningSettings struct {
+	settings WarningRuleSettings
+}
+
+func (s stubWarningSettings) GetWarningRuleSettings(ctx context.Context, tenantID int64) (WarningRuleSettings, error) {
+	return s.settings, nil
+}
+
+type stubWaitingActiveOrders struct {
+	stubActiveOrdersReader
+	waits map[int64]int64
+}
+
+func (s stubWaitingActiveOrders) GetWorkerWaitingTimes(
+	ctx context.Context,
+	tenantID int64,
+	orderIDs []int64,
+) (map[int64]int64, error) {
+	return s.waits, nil
+}
+
+type staticWarningRule struct {
+	code string
+	ids  []int64
+}
+
+func (r staticWarningRule) Code() string { return r.code }
+
+func (r staticWarningRule) DefaultConfig() WarningRuleConfig {
+	return WarningRuleConfig{Enabled: boolPtr(true)}
+}
+
+func (r staticWarningRule) Detect(ctx context.Context, in WarningRuleInput) ([]WarningHit, error) {
+	hits := make([]WarningHit, 0, len(r.ids))
+	for _, id := range r.ids {
+		hits = append(hits, WarningHit{OrderID: id})
+	}
+	return hits, nil
+}
+
+func TestGetWarningReasons_RunsTimeBasedRules(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	nowUnix := now.Unix()
+
+	repo := stubRepository{
+		fetchWarningCandidatesFunc: func(ctx context.Context, f WarningCandidateFilter) ([]WarningCandidate, error) {
+			switch {
+			case len(f.StatusIDs) == 1 && f.StatusIDs[0] == statusWorkerOnTheWay:
+				require.False(t, f.WithoutWorker)
+				return []WarningCandidate{
+					// due 10 minutes ago, late beyond the 5 minute grace
+					{OrderID: 1, StatusTime: nowUnix - 20*60, TimeToClient: 10},
+					// due 2 minutes ago, within the grace
+					{OrderID: 2, StatusTime: nowUnix - 12*60, TimeToClient: 10},
+				}, nil
+			case len(f.StatusIDs) == 1 && f.StatusIDs[0] == statusWorkerWaiting:
+				return []WarningCandidate{{OrderID: 3}, {OrderID: 4}}, nil
+			case f.WithoutWorker && f.StatusIDs[0] == 1:
+				return []WarningCandidate{
+					{OrderID: 5, CreateTime: nowUnix - 3*60},
+					{OrderID: 6, CreateTime: nowUnix - 15*60},
+				}, nil
+			}
+			return nil, nil
+		},
+	}
+
+	svc := newServiceWithRepo(repo)
+	svc.now = func() time.Time { return now }
+	svc.activeOrdersReader = stubWaitingActiveOrders{waits: map[int64]int64{3: 600, 4: 60}}
+	svc.warningSettings = stubWarningSettings{settings: WarningRuleSettings{
+		WarningNoWorkerAssigned: {Enabled: boolPtr(true)},
+		WarningWorkerWaiting:    {Enabled: boolPtr(true)},
+		WarningUnpaid:           {Enabled: boolPtr(false)},
+	}}
+
+	reasons, err := svc.GetWarningReasons(context.Background(), WarningFilter{
+		BaseFilter: BaseFilter{TenantID: 68},
+		Rules: WarningRuleSettings{
+			WarningDriverLate:    {Enabled: boolPtr(true)},
+			WarningWorkerWaiting: {ThresholdMinutes: int64Ptr(8)},
+		},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 3, 6}, reasons.OrderIDs())
+	require.Equal(t, []WarningReason{{
+		Code: WarningDriverLate,
+		Details: map[string]any{
+			"time_to_client":    int64(10),
+			"late_seconds":      int64(600),
+			"threshold_minutes": int64(defaultDriverLateGraceMinutes),
+		},
+	}}, reasons[1])
+	require.Equal(t, []WarningReason{{
+		Code:    WarningWorkerWaiting,
+		Details: map[string]any{"waiting_seconds": int64(600), "threshold_minutes": int64(8)},
+	}}, reasons[3])
+	require.Equal(t, WarningNoWorkerAssigned, reasons[6][0].Code)
+}
+
+func TestGetWarningReasons_PreOrderWithoutDriverNearOrderTime(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	nowUnix := now.Unix()
+	preOrderStatuses := DefaultStatusRegistry().GroupStatuses(68, "pre_order")
+
+	repo := stubRepository{
+		fetchWarningCandidatesFunc: func(ctx context.Context, f WarningCandidateFilter) ([]WarningCandidate, error) {
+			require.True(t, f.WithoutWorker)
+			require.Equal(t, preOrderStatuses, f.StatusIDs)
+			return []WarningCandidate{
+				// order_time is local, ten minutes from now in UTC
+				{OrderID: 7, OrderTime: nowUnix + 3*3600 + 10*60, TimeOffset: 3 * 3600},
+				{OrderID: 8, OrderTime: nowUnix + 2*3600, TimeOffset: 0},
+			}, nil
+		},
+	}
+
+	svc := newServiceWithRepo(repo)
+	svc.now = func() time.Time { return now }
+
+	reasons, err := svc.GetWarningReasons(context.Background(), WarningFilter{
+		BaseFilter: BaseFilter{TenantID: 68},
+		Rules: WarningRuleSettings{
+			WarningPreOrderNoDriver: {Enabled: boolPtr(true)},
+		},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []int64{7}, reasons.OrderIDs())
+	require.Equal(t, int64(600), reasons[7][0].Details["starts_in_seconds"])
+}
+
+func TestWithWarningRules_RegistersAndReplacesRules(t *testing.T) {
+	svc := NewService(
+		stubRepository{fetchUnpaidFunc: func(ctx context.Context, f UnpaidFilter) ([]int64, error) {
+			return []int64{1}, nil
+		}},
+		nil,
+		nil,
+		nil,
+		WithWarningRules(
+			staticWarningRule{code: WarningUnpaid, ids: []int64{2}},
+			staticWarningRule{code: "vip_client", ids: []int64{2, 3}},
+		),
+	)
+
+	ids, err := svc.GetWarningOrder(context.Background(), WarningFilter{})
+
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 3}, ids)
+
+	codes := make([]string, 0)
+	for _, rule := range svc.(*service).warningRuleRegistry().Rules() {
+		codes = append(codes, rule.Code())
+	}
+	require.Equal(t, WarningUnpaid, codes[0])
+	require.Equal(t, "vip_client", codes[len(codes)-1])
+}
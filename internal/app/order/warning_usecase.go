@@ -2,140 +2,139 @@ package order
 
 import (
 	"context"
+	"fmt"
 	"orders-service/internal/logging"
-	"sort"
 	"time"
 
 	"golang.org/x/sync/errgroup"
 )
 
 func (s *service) GetWarningOrder(ctx context.Context, f WarningFilter) ([]int64, error) {
+	reasons, _, err := s.warningReasons(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return reasons.OrderIDs(), nil
+}
+
+// warningReasons reads the warning set of f from the warning cache when one
+// is configured.
+func (s *service) warningReasons(ctx context.Context, f WarningFilter) (WarningReasons, WarningFreshness, error) {
+	if s.warningCache == nil {
+		reasons, err := s.GetWarningReasons(ctx, f)
+		return reasons, WarningFreshness{}, err
+	}
+	return s.warningCache.Get(ctx, f)
+}
+
+// GetWarningReasons runs the enabled warning rules in parallel and keeps, for
+// every order, the reasons it is in the warning tab. Reasons follow the rule
+// registration order.
+func (s *service) GetWarningReasons(ctx context.Context, f WarningFilter) (WarningReasons, error) {
 	totalStarted := time.Now()
-	g, ctx := errgroup.WithContext(ctx)
 
-	var (
-		unpaidIDs []int64
-		badIDs    []int64
-		realIDs   []int64
-		unpaidMS  int64
-		badMS     int64
-		realMS    int64
-	)
+	settings, err := s.tenantWarningSettings(ctx, f.BaseFilter.TenantID)
+	if err != nil {
+		return nil, err
+	}
 
-	g.Go(func() error {
-		started := time.Now()
-		ids, err := s.warningReader.FetchUnpaid(ctx, UnpaidFilter{
-			BaseFilter:             f.BaseFilter,
-			StatusCompletedNotPaid: f.StatusCompletedNotPaid,
-		})
-		unpaidMS = time.Since(started).Milliseconds()
-		if err != nil {
-			return err
-		}
-		unpaidIDs = ids
-		return nil
-	})
+	rules := s.warningRuleRegistry().Rules()
+	now := s.clock()
+	hits := make([][]WarningHit, len(rules))
 
-	g.Go(func() error {
-		started := time.Now()
-		ids, err := s.warningReader.FetchBadReview(ctx, BadReviewFilter{
-			BaseFilter:   f.BaseFilter,
-			BadRatingMax: f.BadRatingMax,
-		})
-		badMS = time.Since(started).Milliseconds()
-		if err != nil {
-			return err
+	g, ctx := errgroup.WithContext(ctx)
+	for i, rule := range rules {
+		cfg := rule.DefaultConfig().
+			merge(settings[rule.Code()]).
+			merge(f.Rules[rule.Code()])
+		if !cfg.IsEnabled() {
+			continue
 		}
-		badIDs = ids
-		return nil
-	})
 
-	g.Go(func() error {
-		started := time.Now()
-		ids, err := s.warningReader.FetchExceededPrice(ctx, ExceededPriceFilter{
-			BaseFilter:     f.BaseFilter,
-			MinRealPrice:   f.MinRealPrice,
-			FinishedStatus: f.FinishedStatus,
+		g.Go(func() error {
+			started := time.Now()
+			ruleHits, err := rule.Detect(ctx, WarningRuleInput{
+				Filter: f,
+				Config: cfg,
+				Now:    now,
+			})
+			observeStage("warning_rules", rule.Code(), started)
+			if err != nil {
+				return fmt.Errorf("warning rule %s: %w", rule.Code(), err)
+			}
+			hits[i] = ruleHits
+			return nil
 		})
-		realMS = time.Since(started).Milliseconds()
-		if err != nil {
-			return err
-		}
-		realIDs = ids
-		return nil
-	})
+	}
 
 	if err := g.Wait(); err != nil {
-		logging.Error(ctx, "refresh warning ids failed", err,
-			"fetch_unpaid_ms", unpaidMS,
-			"fetch_bad_review_ms", badMS,
-			"fetch_exceeded_price_ms", realMS,
-		)
+		logging.Error(ctx, "refresh warning ids failed", err)
 		return nil, err
 	}
 
-	idSet := make(map[int64]struct{}, len(unpaidIDs)+len(badIDs)+len(realIDs))
-	for _, id := range unpaidIDs {
-		idSet[id] = struct{}{}
-	}
-	for _, id := range badIDs {
-		idSet[id] = struct{}{}
-	}
-	for _, id := range realIDs {
-		idSet[id] = struct{}{}
+	reasons := make(WarningReasons)
+	for i, rule := range rules {
+		for _, hit := range hits[i] {
+			reasons.add(hit.OrderID, WarningReason{Code: rule.Code(), Details: hit.Details})
+		}
 	}
 
-	result := make([]int64, 0, len(idSet))
-	for id := range idSet {
-		result = append(result, id)
+	observeStage("warning_rules", "total", totalStarted)
+	kv := append([]any{"merged_count", len(reasons)}, warningRuleCounts(rules, hits)...)
+	logging.Info(ctx, "refresh warning ids done", kv...)
+
+	return reasons, nil
+}
+
+func (s *service) tenantWarningSettings(ctx context.Context, tenantID int64) (WarningRuleSettings, error) {
+	if s.warningSettings == nil {
+		return nil, nil
 	}
 
-	sort.Slice(result, func(i, j int) bool {
-		return result[i] < result[j]
-	})
+	settings, err := s.warningSettings.GetWarningRuleSettings(ctx, tenantID)
+	if err != nil {
+		logging.Error(ctx, "load warning rule settings failed", err, "tenant_id", tenantID)
+		return nil, err
+	}
 
-	logging.Info(ctx, "refresh warning ids timings",
-		"total_ms", time.Since(totalStarted).Milliseconds(),
-		"fetch_unpaid_ms", unpaidMS,
-		"fetch_bad_review_ms", badMS,
-		"fetch_exceeded_price_ms", realMS,
-		"unpaid_count", len(unpaidIDs),
-		"bad_review_count", len(badIDs),
-		"exceeded_price_count", len(realIDs),
-		"merged_count", len(result),
-	)
+	return settings, nil
+}
 
-	return result, nil
+func warningRuleCounts(rules []WarningRule, hits [][]WarningHit) []any {
+	kv := make([]any, 0, len(rules)*2)
+	for i, rule := range rules {
+		kv = append(kv, rule.Code()+"_count", len(hits[i]))
+	}
+	return kv
 }
 
 func (s *service) GetOrdersByGroup(
 	ctx context.Context,
 	f WarningFilter,
 	page, pageSize int,
-) (int64, []FullOrder, error) {
+) (int64, []FullOrder, WarningReasons, error) {
 	totalStarted := time.Now()
 	var (
 		ordersCount     int64
 		ordersPaginated []FullOrder
-		warningIDsMS    int64
-		countMS         int64
-		fetchMS         int64
 	)
 
 	if f.BaseFilter.Group == "warning" {
 		started := time.Now()
-		warningOrderIDs, err := s.GetWarningOrder(ctx, f)
-		warningIDsMS = time.Since(started).Milliseconds()
+		reasons, _, err := s.warningReasons(ctx, f)
+		observeStage("orders_by_group", "warning_ids", started)
 		if err != nil {
-			return 0, nil, err
+			return 0, nil, nil, err
 		}
+		warningOrderIDs := reasons.OrderIDs()
 
 		g, ctx := errgroup.WithContext(ctx)
 
 		g.Go(func() error {
 			started := time.Now()
 			cnt, err := s.orderListReader.CountOrdersWithWarning(ctx, f.BaseFilter, warningOrderIDs)
-			countMS = time.Since(started).Milliseconds()
+			observeStage("orders_by_group", "count", started)
 			if err != nil {
 				return err
 			}
@@ -146,7 +145,7 @@ func (s *service) GetOrdersByGroup(
 		g.Go(func() error {
 			started := time.Now()
 			ords, err := s.orderListReader.FetchOrdersWithWarning(ctx, f.BaseFilter, warningOrderIDs, page, pageSize)
-			fetchMS = time.Since(started).Milliseconds()
+			observeStage("orders_by_group", "fetch", started)
 			if err != nil {
 				return err
 			}
@@ -155,14 +154,11 @@ func (s *service) GetOrdersByGroup(
 		})
 
 		if err := g.Wait(); err != nil {
-			return 0, nil, err
+			return 0, nil, nil, err
 		}
 
-		logging.Info(ctx, "refresh get orders by group timings",
-			"total_ms", time.Since(totalStarted).Milliseconds(),
-			"warning_ids_ms", warningIDsMS,
-			"count_ms", countMS,
-			"fetch_ms", fetchMS,
+		observeStage("orders_by_group", "total", totalStarted)
+		logging.Info(ctx, "refresh get orders by group done",
 			"group", f.BaseFilter.Group,
 			"page", page,
 			"page_size", pageSize,
@@ -171,7 +167,7 @@ func (s *service) GetOrdersByGroup(
 			"warning_ids_count", len(warningOrderIDs),
 		)
 
-		return ordersCount, ordersPaginated, nil
+		return ordersCount, ordersPaginated, reasons, nil
 	}
 
 	g, ctx := errgroup.WithContext(ctx)
@@ -179,7 +175,7 @@ func (s *service) GetOrdersByGroup(
 	g.Go(func() error {
 		started := time.Now()
 		cnt, err := s.orderListReader.CountOrdersWithWarning(ctx, f.BaseFilter, nil)
-		countMS = time.Since(started).Milliseconds()
+		observeStage("orders_by_group", "count", started)
 		if err != nil {
 			return err
 		}
@@ -190,7 +186,7 @@ func (s *service) GetOrdersByGroup(
 	g.Go(func() error {
 		started := time.Now()
 		ords, err := s.orderListReader.FetchOrdersWithWarning(ctx, f.BaseFilter, nil, page, pageSize)
-		fetchMS = time.Since(started).Milliseconds()
+		observeStage("orders_by_group", "fetch", started)
 		if err != nil {
 			return err
 		}
@@ -199,14 +195,11 @@ func (s *service) GetOrdersByGroup(
 	})
 
 	if err := g.Wait(); err != nil {
-		return 0, nil, err
+		return 0, nil, nil, err
 	}
 
-	logging.Info(ctx, "refresh get orders by group timings",
-		"total_ms", time.Since(totalStarted).Milliseconds(),
-		"warning_ids_ms", warningIDsMS,
-		"count_ms", countMS,
-		"fetch_ms", fetchMS,
+	observeStage("orders_by_group", "total", totalStarted)
+	logging.Info(ctx, "refresh get orders by group done",
 		"group", f.BaseFilter.Group,
 		"page", page,
 		"page_size", pageSize,
@@ -214,5 +207,5 @@ func (s *service) GetOrdersByGroup(
 		"orders_count", len(ordersPaginated),
 	)
 
-	return ordersCount, ordersPaginated, nil
+	return ordersCount, ordersPaginated, nil, nil
 }
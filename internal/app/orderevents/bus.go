@@ -0,0 +1,62 @@
+package orderevents
+
+import (
+	"context"
+	"sync"
+
+	"orders-service/internal/app/order"
+	"orders-service/internal/logging"
+)
+
+// Bus fans OrderChanged events out to in-process subscribers. Publishing never
+// blocks: a subscriber whose buffer is full misses the event.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[int]chan order.OrderChanged
+	nextID int
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subs: make(map[int]chan order.OrderChanged),
+	}
+}
+
+// Subscribe returns a channel with the given buffer and a function that ends
+// the subscription and closes the channel.
+func (b *Bus) Subscribe(buffer int) (<-chan order.OrderChanged, func()) {
+	ch := make(chan order.OrderChanged, buffer)
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, id)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (b *Bus) PublishOrderChanged(ctx context.Context, event order.OrderChanged) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for id, ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			logging.Warn(ctx, "order event dropped",
+				"subscriber", id,
+				"tenant_id", event.TenantID,
+				"order_id", event.OrderID,
+			)
+		}
+	}
+}
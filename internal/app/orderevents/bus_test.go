@@ -0,0 +1,31 @@
+package orderevents
+
+import (
+	"context"
+	"testing"
+
+	"orders-service/internal/app/order"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_FansOutAndDropsForFullSubscribers(t *testing.T) {
+	bus := NewBus()
+	fast, cancelFast := bus.Subscribe(2)
+	defer cancelFast()
+	slow, cancelSlow := bus.Subscribe(1)
+
+	bus.PublishOrderChanged(context.Background(), order.OrderChanged{TenantID: 68, OrderID: 1})
+	bus.PublishOrderChanged(context.Background(), order.OrderChanged{TenantID: 68, OrderID: 2})
+
+	require.Equal(t, int64(1), (<-fast).OrderID)
+	require.Equal(t, int64(2), (<-fast).OrderID)
+	require.Equal(t, int64(1), (<-slow).OrderID)
+
+	cancelSlow()
+	_, ok := <-slow
+	require.False(t, ok)
+
+	bus.PublishOrderChanged(context.Background(), order.OrderChanged{TenantID: 68, OrderID: 3})
+	require.Equal(t, int64(3), (<-fast).OrderID)
+}
@@ -0,0 +1,215 @@
+package orderexport
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"orders-service/internal/app/order"
+)
+
+// Column is one spreadsheet column. Value returns a string, an int64, a
+// float64 or nil for an empty cell.
+type Column struct {
+	Key   string
+	Title string
+	Value func(v order.OrderView, dates DateFormatter) any
+}
+
+var columns = []Column{
+	{Key: "id", Title: "ID", Value: func(v order.OrderView, _ DateFormatter) any {
+		return v.ID
+	}},
+	{Key: "order_number", Title: "Order number", Value: func(v order.OrderView, _ DateFormatter) any {
+		if v.OrderNumber == nil {
+			return nil
+		}
+		return fmt.Sprint(v.OrderNumber)
+	}},
+	{Key: "status", Title: "Status", Value: func(v order.OrderView, _ DateFormatter) any {
+		// The assembler already translated the name with the StatusTranslator.
+		return v.Status.Name
+	}},
+	{Key: "status_category", Title: "Status category", Value: func(v order.OrderView, _ DateFormatter) any {
+		return v.Status.Category
+	}},
+	{Key: "create_time", Title: "Created", Value: func(v order.OrderView, dates DateFormatter) any {
+		return dates.Format(v.CreateTime)
+	}},
+	{Key: "order_time", Title: "Order time", Value: func(v order.OrderView, dates DateFormatter) any {
+		return dates.Format(v.OrderTime)
+	}},
+	{Key: "status_time", Title: "Status time", Value: func(v order.OrderView, dates DateFormatter) any {
+		return dates.Format(v.StatusTime)
+	}},
+	{Key: "address", Title: "Address", Value: func(v order.OrderView, _ DateFormatter) any {
+		return formatAddresses(v.Address)
+	}},
+	{Key: "client", Title: "Client", Value: func(v order.OrderView, _ DateFormatter) any {
+		return joinNonEmpty(" ", v.Client.LastName, v.Client.Name)
+	}},
+	{Key: "phone", Title: "Phone", Value: func(v order.OrderView, _ DateFormatter) any {
+		return v.Phone
+	}},
+	{Key: "worker", Title: "Worker", Value: func(v order.OrderView, _ DateFormatter) any {
+		if v.Worker == nil {
+			return nil
+		}
+		return v.Worker.Name
+	}},
+	{Key: "worker_callsign", Title: "Callsign", Value: func(v order.OrderView, _ DateFormatter) any {
+		if v.Worker == nil || v.Worker.Callsign == nil {
+			return nil
+		}
+		return *v.Worker.Callsign
+	}},
+	{Key: "car", Title: "Car", Value: func(v order.OrderView, _ DateFormatter) any {
+		if v.Car == nil {
+			return nil
+		}
+		return joinNonEmpty(" ", v.Car.Name, v.Car.Number)
+	}},
+	{Key: "tariff", Title: "Tariff", Value: func(v order.OrderView, _ DateFormatter) any {
+		return v.Tariff.Name
+	}},
+	{Key: "summary_cost", Title: "Cost", Value: func(v order.OrderView, _ DateFormatter) any {
+		switch cost := v.SummaryCost.(type) {
+		case nil:
+			return nil
+		case float64, int64, string:
+			return cost
+		default:
+			return fmt.Sprint(cost)
+		}
+	}},
+	{Key: "comment", Title: "Comment", Value: func(v order.OrderView, _ DateFormatter) any {
+		if v.Comment == nil {
+			return nil
+		}
+		return *v.Comment
+	}},
+	{Key: "city_id", Title: "City ID", Value: func(v order.OrderView, _ DateFormatter) any {
+		return v.CityID
+	}},
+}
+
+// DefaultColumnKeys is used when a request does not pick the columns.
+var DefaultColumnKeys = []string{
+	"id", "order_number", "status", "create_time", "order_time",
+	"address", "client", "phone", "worker", "tariff", "summary_cost",
+}
+
+// ResolveColumns returns the columns for the keys in the given order. An
+// empty list selects the default columns.
+func ResolveColumns(keys []string) ([]Column, error) {
+	if len(keys) == 0 {
+		keys = DefaultColumnKeys
+	}
+
+	result := make([]Column, 0, len(keys))
+	for _, key := range keys {
+		column, ok := findColumn(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown export column %q", key)
+		}
+		result = append(result, column)
+	}
+	return result, nil
+}
+
+func findColumn(key string) (Column, bool) {
+	for _, column := range columns {
+		if column.Key == key {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// Row returns the cell values of a view for the columns.
+func Row(view order.OrderView, cols []Column, dates DateFormatter) []any {
+	row := make([]any, 0, len(cols))
+	for _, column := range cols {
+		row = append(row, column.Value(view, dates))
+	}
+	return row
+}
+
+func Titles(cols []Column) []string {
+	titles := make([]string, 0, len(cols))
+	for _, column := range cols {
+		titles = append(titles, column.Title)
+	}
+	return titles
+}
+
+// DateFormatter prints unix times in the layout of a language and in the
+// time zone of the reader of the export.
+type DateFormatter struct {
+	location *time.Location
+	layout   string
+}
+
+var dateLayouts = map[string]string{
+	"ru": "02.01.2006 15:04",
+	"uk": "02.01.2006 15:04",
+	"de": "02.01.2006 15:04",
+	"en": "01/02/2006 03:04 PM",
+}
+
+const defaultDateLayout = "2006-01-02 15:04"
+
+// NewDateFormatter picks the layout by language and loads the IANA time zone;
+// an empty zone means UTC.
+func NewDateFormatter(language, timezone string) (DateFormatter, error) {
+	location := time.UTC
+	if timezone != "" {
+		loaded, err := time.LoadLocation(timezone)
+		if err != nil {
+			return DateFormatter{}, fmt.Errorf("unknown time zone %q", timezone)
+		}
+		location = loaded
+	}
+
+	layout, ok := dateLayouts[strings.ToLower(language)]
+	if !ok {
+		layout = defaultDateLayout
+	}
+
+	return DateFormatter{location: location, layout: layout}, nil
+}
+
+func (d DateFormatter) Format(unix int64) any {
+	if unix <= 0 {
+		return nil
+	}
+	location := d.location
+	if location == nil {
+		location = time.UTC
+	}
+	layout := d.layout
+	if layout == "" {
+		layout = defaultDateLayout
+	}
+	return time.Unix(unix, 0).In(location).Format(layout)
+}
+
+func formatAddresses(addresses []order.AddressView) string {
+	parts := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if line := joinNonEmpty(", ", address.City, address.Street, address.House); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " → ")
+}
+
+func joinNonEmpty(sep string, values ...*string) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != nil && *value != "" {
+			parts = append(parts, *value)
+		}
+	}
+	return strings.Join(parts, sep)
+}
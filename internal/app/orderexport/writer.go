@@ -0,0 +1,234 @@
+package orderexport
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// RowWriter writes a spreadsheet row by row. Close completes the file, rows
+// written before a failed Close may be unreadable.
+type RowWriter interface {
+	WriteHeader(titles []string) error
+	WriteRow(values []any) error
+	Flush() error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(w), nil
+	case FormatXLSX:
+		return NewXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter writes UTF-8 CSV with a byte order mark, so spreadsheet
+// programs detect the encoding of translated names.
+func NewCSVWriter(w io.Writer) RowWriter {
+	_, _ = io.WriteString(w, "\ufeff")
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(titles []string) error {
+	return c.w.Write(titles)
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	record := make([]string, 0, len(values))
+	for _, value := range values {
+		switch value.(type) {
+		case nil, int64, float64:
+			record = append(record, cellString(value))
+		default:
+			record = append(record, neutralizeFormula(cellString(value)))
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+func cellString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formulaPrefixes are the first characters spreadsheet programs read as the
+// start of a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// neutralizeFormula prefixes CSV text starting like a formula with an
+// apostrophe, so a comment or name typed by a client is shown instead of
+// evaluated. Phone numbers like "+7 999 123" cannot be formulas and stay as
+// they are. XLSX stores text as inline strings, which are never evaluated.
+func neutralizeFormula(text string) string {
+	if text == "" || strings.IndexByte(formulaPrefixes, text[0]) < 0 || isPhoneNumber(text) {
+		return text
+	}
+	return "'" + text
+}
+
+// isPhoneNumber reports whether text is a plus followed by digits and spaces.
+func isPhoneNumber(text string) bool {
+	digits := strings.TrimPrefix(text, "+")
+	if len(digits) == len(text) || strings.TrimSpace(digits) == "" {
+		return false
+	}
+	for _, char := range digits {
+		if (char < '0' || char > '9') && char != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// xlsxWriter streams a single sheet workbook. The sheet is the last zip entry
+// and is written while rows arrive, strings are stored inline so no shared
+// string table has to be kept in memory.
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Orders" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+func NewXLSXWriter(w io.Writer) (RowWriter, error) {
+	archive := zip.NewWriter(w)
+	for _, part := range xlsxStaticParts {
+		entry, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(entry)
+	if _, err := sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	return &xlsxWriter{zip: archive, sheet: sheet}, nil
+}
+
+func (x *xlsxWriter) WriteHeader(titles []string) error {
+	values := make([]any, 0, len(titles))
+	for _, title := range titles {
+		values = append(values, title)
+	}
+	return x.WriteRow(values)
+}
+
+func (x *xlsxWriter) WriteRow(values []any) error {
+	x.row++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.row)
+	for i, value := range values {
+		ref := columnName(i) + strconv.Itoa(x.row)
+		switch v := value.(type) {
+		case nil:
+			continue
+		case int64:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(x.sheet, []byte(cellString(v))); err != nil {
+				return err
+			}
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+// Flush pushes the buffered rows and the compressed data written so far to the
+// underlying writer.
+func (x *xlsxWriter) Flush() error {
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Flush()
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// columnName returns the spreadsheet column letters for a zero based index.
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,115 @@
+package orderexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"orders-service/internal/app/order"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXLSXWriter_WritesReadableWorkbook(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(FormatXLSX, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.WriteHeader([]string{"ID", "Client"}))
+	require.NoError(t, writer.WriteRow([]any{int64(7), "Tom & Jerry"}))
+	require.NoError(t, writer.WriteRow([]any{float64(12.5), nil}))
+	require.NoError(t, writer.Flush())
+	require.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(archive.File))
+	var sheet string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+		if file.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		sheet = string(content)
+	}
+
+	require.Contains(t, names, "[Content_Types].xml")
+	require.Contains(t, names, "xl/workbook.xml")
+	require.Contains(t, sheet, `<c r="A2"><v>7</v></c>`)
+	require.Contains(t, sheet, `<t xml:space="preserve">Tom &amp; Jerry</t>`)
+	require.Contains(t, sheet, `<c r="A3"><v>12.5</v></c></row>`)
+	require.Contains(t, sheet, `</sheetData></worksheet>`)
+}
+
+func TestCSVWriter_NeutralizesFormulaCells(t *testing.T) {
+	row := []any{"=HYPERLINK(\"http://x\")", "+7 999 123", "+1+1", "-1", "@SUM(A1)", "\tcmd", "\rcmd", "Lenina 1", int64(-5), float64(-2.5)}
+
+	var csvBuf bytes.Buffer
+	writer := NewCSVWriter(&csvBuf)
+	require.NoError(t, writer.WriteRow(row))
+	require.NoError(t, writer.Close())
+	require.Equal(t, "\ufeff\"'=HYPERLINK(\"\"http://x\"\")\",+7 999 123,'+1+1,'-1,'@SUM(A1),'\tcmd,\"'\rcmd\",Lenina 1,-5,-2.5\n", csvBuf.String())
+
+	var xlsxBuf bytes.Buffer
+	writer, err := NewXLSXWriter(&xlsxBuf)
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteRow(row))
+	require.NoError(t, writer.Close())
+	sheet := readSheet(t, xlsxBuf.Bytes())
+	require.NotContains(t, sheet, "&#39;", "inline strings are never evaluated")
+	require.Contains(t, sheet, `<t xml:space="preserve">=HYPERLINK(&#34;http://x&#34;)</t>`)
+	require.Contains(t, sheet, `<t xml:space="preserve">+7 999 123</t>`)
+	require.Contains(t, sheet, `<c r="I1"><v>-5</v></c>`)
+}
+
+// readSheet returns the sheet of a workbook written by the XLSX writer.
+func readSheet(t *testing.T, workbook []byte) string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	require.NoError(t, err)
+
+	rc, err := archive.Open("xl/worksheets/sheet1.xml")
+	require.NoError(t, err)
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestResolveColumns(t *testing.T) {
+	cols, err := ResolveColumns(nil)
+	require.NoError(t, err)
+	require.Len(t, cols, len(DefaultColumnKeys))
+
+	cols, err = ResolveColumns([]string{"client", "car", "worker_callsign"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Client", "Car", "Callsign"}, Titles(cols))
+
+	last, first, model, number := "Ivanov", "Ivan", "Kia Rio", "A123BC"
+	row := Row(order.OrderView{
+		Client: order.ClientView{LastName: &last, Name: &first},
+		Car:    &order.CarView{Name: &model, Number: &number},
+	}, cols, DateFormatter{})
+	require.Equal(t, []any{"Ivanov Ivan", "Kia Rio A123BC", nil}, row)
+
+	_, err = ResolveColumns([]string{"id", "unknown"})
+	require.Error(t, err)
+}
+
+func TestDateFormatter_UsesLanguageAndZone(t *testing.T) {
+	dates, err := NewDateFormatter("en", "America/New_York")
+	require.NoError(t, err)
+	require.Equal(t, "03/20/2026 08:00 PM", dates.Format(1774051200))
+	require.Nil(t, dates.Format(0))
+
+	dates, err = NewDateFormatter("", "")
+	require.NoError(t, err)
+	require.Equal(t, "2026-03-21 00:00", dates.Format(1774051200))
+}
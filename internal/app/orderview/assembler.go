@@ -4,6 +4,7 @@ import (
 	"context"
 	"orders-service/internal/app/order"
 	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
 	"time"
 )
 
@@ -11,18 +12,35 @@ type Assembler struct {
 	waitingTimeProvider order.WaitingTimeProvider
 	statusTranslator    order.StatusTranslator
 	showOrderCode       order.ShowOrderCodeProvider
+	statuses            *order.StatusRegistry
+}
+
+type Option func(*Assembler)
+
+// WithStatusRegistry sets the registry used for status categories and colors.
+func WithStatusRegistry(registry *order.StatusRegistry) Option {
+	return func(a *Assembler) {
+		a.statuses = registry
+	}
 }
 
 func NewAssembler(
 	waitingTimeProvider order.WaitingTimeProvider,
 	statusTranslator order.StatusTranslator,
 	showOrderCode order.ShowOrderCodeProvider,
+	opts ...Option,
 ) *Assembler {
-	return &Assembler{
+	a := &Assembler{
 		waitingTimeProvider: waitingTimeProvider,
 		statusTranslator:    statusTranslator,
 		showOrderCode:       showOrderCode,
+		statuses:            order.DefaultStatusRegistry(),
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
 }
 
 func (a *Assembler) BuildOrderView(
@@ -48,7 +66,7 @@ func (a *Assembler) BuildOrderViews(
 	totalStarted := time.Now()
 	waitTimesStarted := time.Now()
 	waitTimes, err := a.getWorkerWaitingTimes(ctx, orders)
-	waitTimesMS := time.Since(waitTimesStarted).Milliseconds()
+	metrics.StageDuration.ObserveDuration(waitTimesStarted, "order_views", "wait_times")
 	if err != nil {
 		return nil, err
 	}
@@ -62,12 +80,10 @@ func (a *Assembler) BuildOrderViews(
 		}
 		result = append(result, prepared)
 	}
-	buildMS := time.Since(buildStarted).Milliseconds()
+	metrics.StageDuration.ObserveDuration(buildStarted, "order_views", "build_loop")
+	metrics.StageDuration.ObserveDuration(totalStarted, "order_views", "total")
 
-	logging.Info(ctx, "order views assembler timings",
-		"total_ms", time.Since(totalStarted).Milliseconds(),
-		"wait_times_ms", waitTimesMS,
-		"build_loop_ms", buildMS,
+	logging.Info(ctx, "order views assembler done",
 		"orders_count", len(orders),
 		"wait_times_count", len(waitTimes),
 		"bulk_wait_time", a.hasBulkWaitingTimeProvider(),
@@ -125,6 +141,7 @@ func (a *Assembler) buildOrderViewWithWaitTime(
 		OrderTime:    o.OrderTime - o.TimeOffset,
 		PositionID:   o.PositionID,
 		UnitQuantity: o.UnitQuantity,
+		Warnings:     o.Warnings,
 	}, nil
 }
 
@@ -141,8 +158,8 @@ func (a *Assembler) buildOrderStatusView(
 	return order.OrderStatusView{
 		StatusID: o.Status.StatusID,
 		Name:     translatedStatusName,
-		Category: order.GetCategory(o.StatusID),
-		Color:    order.GetColor(o.StatusID),
+		Category: a.statuses.Category(o.TenantID, o.StatusID),
+		Color:    a.statuses.Color(o.TenantID, o.StatusID),
 	}, nil
 }
 
@@ -166,7 +183,11 @@ func (a *Assembler) getWorkerWaitingTime(
 		return 0, nil
 	}
 
-	return a.waitingTimeProvider.GetWorkerWaitingTime(ctx, tenantID, orderID)
+	waitTime, err := a.waitingTimeProvider.GetWorkerWaitingTime(ctx, tenantID, orderID)
+	if err != nil && order.Degrade(ctx, order.DegradedWaitTimes, err) {
+		return 0, nil
+	}
+	return waitTime, err
 }
 
 func (a *Assembler) getWorkerWaitingTimes(
@@ -181,10 +202,10 @@ func (a *Assembler) getWorkerWaitingTimes(
 	bulkProvider, ok := a.waitingTimeProvider.(order.BulkWaitingTimeProvider)
 	if !ok {
 		for _, o := range orders {
-			if !needsWorkerWaitingTime(o) {
+			if !order.NeedsWorkerWaitingTime(o) {
 				continue
 			}
-			waitTime, err := a.waitingTimeProvider.GetWorkerWaitingTime(ctx, o.TenantID, o.OrderID)
+			waitTime, err := a.getWorkerWaitingTime(ctx, o.TenantID, o.OrderID)
 			if err != nil {
 				return nil, err
 			}
@@ -197,7 +218,7 @@ func (a *Assembler) getWorkerWaitingTimes(
 
 	ordersByTenant := make(map[int64][]int64)
 	for _, o := range orders {
-		if !needsWorkerWaitingTime(o) {
+		if !order.NeedsWorkerWaitingTime(o) {
 			continue
 		}
 		ordersByTenant[o.TenantID] = append(ordersByTenant[o.TenantID], o.OrderID)
@@ -206,6 +227,11 @@ func (a *Assembler) getWorkerWaitingTimes(
 	for tenantID, orderIDs := range ordersByTenant {
 		waitTimes, err := bulkProvider.GetWorkerWaitingTimes(ctx, tenantID, orderIDs)
 		if err != nil {
+			// Without Redis the waits stay zero, the rest of the view is
+			// still served.
+			if order.Degrade(ctx, order.DegradedWaitTimes, err) {
+				continue
+			}
 			return nil, err
 		}
 		for orderID, waitTime := range waitTimes {
@@ -216,12 +242,6 @@ func (a *Assembler) getWorkerWaitingTimes(
 	return result, nil
 }
 
-func needsWorkerWaitingTime(o order.FormattedOrder) bool {
-	const workerWaitingStatusID int64 = 26
-
-	return o.StatusID == workerWaitingStatusID && (o.WorkerID != nil || o.Worker.WorkerID > 0)
-}
-
 func (a *Assembler) hasBulkWaitingTimeProvider() bool {
 	if a.waitingTimeProvider == nil {
 		return false
@@ -0,0 +1,208 @@
+// Package responsecache keeps encoded responses of the list routes for a few
+// seconds, so dispatchers of a tenant polling with the same request share one
+// load. Identical requests in flight are merged, and a change of an active
+// order of the tenant drops its cached responses.
+package responsecache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"orders-service/internal/metrics"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const defaultMaxEntries = 1000
+
+// Source tells where a response came from.
+type Source string
+
+const (
+	// SourceHit is a response of the cache.
+	SourceHit Source = "HIT"
+	// SourceShared is a response loaded by an identical request in flight.
+	SourceShared Source = "SHARED"
+	// SourceMiss is a response the request loaded itself.
+	SourceMiss Source = "MISS"
+)
+
+type Option func(*Cache)
+
+// WithRouteTTL sets how long responses of route stay cached. Routes without
+// a TTL are not cached.
+func WithRouteTTL(route string, ttl time.Duration) Option {
+	return func(c *Cache) {
+		if ttl > 0 {
+			c.ttls[route] = ttl
+		}
+	}
+}
+
+// WithMaxEntries caps the cached responses, a full cache stores nothing new
+// until entries expire.
+func WithMaxEntries(limit int) Option {
+	return func(c *Cache) {
+		if limit > 0 {
+			c.maxEntries = limit
+		}
+	}
+}
+
+type entry struct {
+	tenantID int64
+	body     []byte
+	expires  time.Time
+}
+
+type Cache struct {
+	ttls       map[string]time.Duration
+	maxEntries int
+	group      singleflight.Group
+	now        func() time.Time
+
+	// suspended turns the cache off while nothing invalidates it, epoch
+	// discards the loads that started before a suspension.
+	suspended atomic.Bool
+
+	mu          sync.Mutex
+	entries     map[string]entry
+	generations map[int64]uint64
+	epoch       uint64
+}
+
+func New(opts ...Option) *Cache {
+	c := &Cache{
+		ttls:        make(map[string]time.Duration),
+		maxEntries:  defaultMaxEntries,
+		now:         time.Now,
+		entries:     make(map[string]entry),
+		generations: make(map[int64]uint64),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Enabled reports whether responses of route are cached.
+func (c *Cache) Enabled(route string) bool {
+	return c != nil && c.ttls[route] > 0 && !c.suspended.Load()
+}
+
+// Suspend drops every cached response and turns the cache off until Resume,
+// for while nothing would invalidate it. Loads in flight are not stored.
+func (c *Cache) Suspend() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.suspended.Store(true)
+	c.epoch++
+	c.entries = make(map[string]entry)
+}
+
+// Resume turns a suspended cache on again.
+func (c *Cache) Resume() {
+	c.suspended.Store(false)
+}
+
+// Get returns the response of key, loading it with load on a miss. Identical
+// requests share one load, which runs without the cancellation of the request
+// that started it, so the others still get its result. Errors are not cached,
+// neither are responses the load called SkipStore for.
+func (c *Cache) Get(
+	ctx context.Context,
+	route string,
+	tenantID int64,
+	key string,
+	load func(ctx context.Context) ([]byte, error),
+) ([]byte, Source, error) {
+	key = route + ":" + strconv.FormatInt(tenantID, 10) + ":" + key
+
+	c.mu.Lock()
+	if cached, ok := c.entries[key]; ok && c.now().Before(cached.expires) {
+		c.mu.Unlock()
+		metrics.ResponseCacheRequests.Inc(route, "hit")
+		return cached.body, SourceHit, nil
+	}
+	// A load that started before an invalidation may have read the old
+	// state, requests after it must not join it.
+	generation := c.generations[tenantID]
+	epoch := c.epoch
+	c.mu.Unlock()
+
+	flight := key + "#" + strconv.FormatUint(epoch, 10) + "." + strconv.FormatUint(generation, 10)
+	loaded := false
+	result := c.group.DoChan(flight, func() (any, error) {
+		loaded = true
+		var skip atomic.Bool
+		body, err := load(context.WithValue(context.WithoutCancel(ctx), skipStoreKey{}, &skip))
+		if err != nil {
+			return nil, err
+		}
+		if !skip.Load() {
+			c.store(key, tenantID, generation, epoch, body, c.ttls[route])
+		}
+		return body, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	case res := <-result:
+		source := SourceShared
+		if loaded {
+			source = SourceMiss
+		}
+		metrics.ResponseCacheRequests.Inc(route, string(source))
+		if res.Err != nil {
+			return nil, source, res.Err
+		}
+		return res.Val.([]byte), source, nil
+	}
+}
+
+type skipStoreKey struct{}
+
+// SkipStore keeps the response of the load running with ctx out of the cache,
+// only the requests sharing the load get it. Outside a load it does nothing.
+func SkipStore(ctx context.Context) {
+	if skip, ok := ctx.Value(skipStoreKey{}).(*atomic.Bool); ok {
+		skip.Store(true)
+	}
+}
+
+// Invalidate drops the cached responses of the tenant.
+func (c *Cache) Invalidate(tenantID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generations[tenantID]++
+	for key, cached := range c.entries {
+		if cached.tenantID == tenantID {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *Cache) store(key string, tenantID int64, generation, epoch uint64, body []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generations[tenantID] != generation || c.epoch != epoch || c.suspended.Load() {
+		return
+	}
+
+	now := c.now()
+	if len(c.entries) >= c.maxEntries {
+		for k, cached := range c.entries {
+			if !now.Before(cached.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			return
+		}
+	}
+	c.entries[key] = entry{tenantID: tenantID, body: body, expires: now.Add(ttl)}
+}
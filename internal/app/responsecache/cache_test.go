@@ -0,0 +1,172 @@
+package responsecache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_ServesHitsUntilTTL(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := New(WithRouteTTL("/orders", 2*time.Second))
+	c.now = func() time.Time { return now }
+
+	loads := 0
+	load := func(ctx context.Context) ([]byte, error) {
+		loads++
+		return []byte("body"), nil
+	}
+
+	body, source, err := c.Get(context.Background(), "/orders", 68, "req", load)
+	require.NoError(t, err)
+	require.Equal(t, SourceMiss, source)
+	require.Equal(t, "body", string(body))
+
+	_, source, err = c.Get(context.Background(), "/orders", 68, "req", load)
+	require.NoError(t, err)
+	require.Equal(t, SourceHit, source)
+
+	_, source, err = c.Get(context.Background(), "/orders", 70, "req", load)
+	require.NoError(t, err)
+	require.Equal(t, SourceMiss, source, "tenants never share responses")
+
+	now = now.Add(2 * time.Second)
+	_, source, err = c.Get(context.Background(), "/orders", 68, "req", load)
+	require.NoError(t, err)
+	require.Equal(t, SourceMiss, source)
+	require.Equal(t, 3, loads)
+}
+
+func TestCache_MergesIdenticalRequestsInFlight(t *testing.T) {
+	c := New(WithRouteTTL("/orders", time.Minute))
+	release := make(chan struct{})
+	var loads atomic.Int32
+	load := func(ctx context.Context) ([]byte, error) {
+		loads.Add(1)
+		<-release
+		return []byte("body"), nil
+	}
+
+	const callers = 5
+	sources := make(chan Source, callers)
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, source, err := c.Get(context.Background(), "/orders", 68, "req", load)
+			require.NoError(t, err)
+			sources <- source
+		}()
+	}
+	require.Eventually(t, func() bool { return loads.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(sources)
+
+	counts := map[Source]int{}
+	for source := range sources {
+		counts[source]++
+	}
+	require.Equal(t, int32(1), loads.Load())
+	require.Equal(t, 1, counts[SourceMiss])
+	require.Equal(t, callers-1, counts[SourceShared]+counts[SourceHit])
+}
+
+func TestCache_InvalidateDropsTenantAndDiscardsLoadsInFlight(t *testing.T) {
+	c := New(WithRouteTTL("/orders", time.Minute))
+	load := func(ctx context.Context) ([]byte, error) { return []byte("old"), nil }
+
+	_, _, err := c.Get(context.Background(), "/orders", 68, "req", load)
+	require.NoError(t, err)
+	_, _, err = c.Get(context.Background(), "/orders", 70, "req", load)
+	require.NoError(t, err)
+
+	c.Invalidate(68)
+
+	_, source, err := c.Get(context.Background(), "/orders", 70, "req", load)
+	require.NoError(t, err)
+	require.Equal(t, SourceHit, source)
+
+	// A load that read the state before the invalidation is not stored.
+	_, _, err = c.Get(context.Background(), "/orders", 68, "req", func(ctx context.Context) ([]byte, error) {
+		c.Invalidate(68)
+		return []byte("stale"), nil
+	})
+	require.NoError(t, err)
+
+	body, source, err := c.Get(context.Background(), "/orders", 68, "req",
+		func(ctx context.Context) ([]byte, error) { return []byte("new"), nil })
+	require.NoError(t, err)
+	require.Equal(t, SourceMiss, source)
+	require.Equal(t, "new", string(body))
+}
+
+func TestCache_DoesNotCacheErrors(t *testing.T) {
+	c := New(WithRouteTTL("/orders", time.Minute))
+	boom := errors.New("mysql down")
+
+	_, _, err := c.Get(context.Background(), "/orders", 68, "req",
+		func(ctx context.Context) ([]byte, error) { return nil, boom })
+	require.ErrorIs(t, err, boom)
+
+	_, source, err := c.Get(context.Background(), "/orders", 68, "req",
+		func(ctx context.Context) ([]byte, error) { return []byte("body"), nil })
+	require.NoError(t, err)
+	require.Equal(t, SourceMiss, source)
+}
+
+func TestCache_DoesNotStoreSkippedResponses(t *testing.T) {
+	c := New(WithRouteTTL("/orders", time.Minute))
+	SkipStore(context.Background())
+
+	for range 2 {
+		body, source, err := c.Get(context.Background(), "/orders", 68, "req",
+			func(ctx context.Context) ([]byte, error) {
+				SkipStore(ctx)
+				return []byte("partial"), nil
+			})
+		require.NoError(t, err)
+		require.Equal(t, SourceMiss, source)
+		require.Equal(t, "partial", string(body))
+	}
+}
+
+func TestCache_SuspendDropsResponsesUntilResume(t *testing.T) {
+	c := New(WithRouteTTL("/orders", time.Minute))
+	load := func(ctx context.Context) ([]byte, error) { return []byte("body"), nil }
+
+	_, _, err := c.Get(context.Background(), "/orders", 68, "req", load)
+	require.NoError(t, err)
+
+	// A load that read the state before the suspension is not stored.
+	_, _, err = c.Get(context.Background(), "/orders", 70, "req", func(ctx context.Context) ([]byte, error) {
+		c.Suspend()
+		return []byte("stale"), nil
+	})
+	require.NoError(t, err)
+	require.False(t, c.Enabled("/orders"))
+
+	c.Resume()
+	require.True(t, c.Enabled("/orders"))
+	for _, tenantID := range []int64{68, 70} {
+		_, source, err := c.Get(context.Background(), "/orders", tenantID, "req", load)
+		require.NoError(t, err)
+		require.Equal(t, SourceMiss, source)
+	}
+}
+
+func TestCache_EnabledOnlyForRoutesWithTTL(t *testing.T) {
+	var c *Cache
+	require.False(t, c.Enabled("/orders"))
+
+	c = New(WithRouteTTL("/orders", time.Second), WithRouteTTL("/orders/all", 0))
+	require.True(t, c.Enabled("/orders"))
+	require.False(t, c.Enabled("/orders/all"))
+}
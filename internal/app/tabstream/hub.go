@@ -0,0 +1,285 @@
+package tabstream
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"orders-service/internal/app/order"
+	"orders-service/internal/logging"
+)
+
+const defaultRefreshInterval = 3 * time.Second
+
+type TabsReader interface {
+	GetOrdersForTabs(ctx context.Context, f order.WarningFilter) (order.GroupOrdersResult, error)
+}
+
+// Snapshot is the state of the tabs for one tenant and filter. ID is derived
+// from the content, so a client that reconnects with the ID it has seen last
+// only gets an event when the tabs changed meanwhile, even if the refresher
+// was restarted in between.
+type Snapshot struct {
+	ID              string
+	GroupCounts     map[order.StatusGroup]int
+	OrdersForSignal map[order.StatusGroup][]int64
+}
+
+type Option func(*Hub)
+
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(h *Hub) {
+		if interval > 0 {
+			h.interval = interval
+		}
+	}
+}
+
+// Hub shares one refresher per tenant and filter between all subscribers. A
+// refresher polls the tabs while it has subscribers and stops with the last
+// one.
+type Hub struct {
+	reader   TabsReader
+	interval time.Duration
+
+	mu         sync.Mutex
+	refreshers map[string]*refresher
+}
+
+func NewHub(reader TabsReader, opts ...Option) *Hub {
+	h := &Hub{
+		reader:     reader,
+		interval:   defaultRefreshInterval,
+		refreshers: make(map[string]*refresher),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type Subscription struct {
+	C <-chan Snapshot
+
+	ch      chan Snapshot
+	release func()
+	once    sync.Once
+}
+
+func (s *Subscription) Close() {
+	s.once.Do(s.release)
+}
+
+// Subscribe starts receiving snapshots for the filter. The current snapshot,
+// when there is one, is delivered right away.
+func (h *Hub) Subscribe(f order.WarningFilter) *Subscription {
+	key := filterKey(f)
+	ch := make(chan Snapshot, 1)
+	sub := &Subscription{C: ch, ch: ch}
+
+	h.mu.Lock()
+	r, ok := h.refreshers[key]
+	if !ok {
+		r = newRefresher(h.reader, f, h.interval)
+		h.refreshers[key] = r
+		go r.run()
+	}
+	r.add(sub)
+	h.mu.Unlock()
+
+	sub.release = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if r.remove(sub) == 0 {
+			r.stop()
+			if h.refreshers[key] == r {
+				delete(h.refreshers, key)
+			}
+		}
+	}
+
+	return sub
+}
+
+// Close stops every refresher and closes the channels of open subscriptions,
+// so streams end on shutdown instead of holding the server open.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, r := range h.refreshers {
+		r.stop()
+		r.closeSubscribers()
+		delete(h.refreshers, key)
+	}
+}
+
+// Notify makes the refreshers of a tenant refresh now instead of waiting for
+// the next tick, e.g. after an active order of the tenant changed.
+func (h *Hub) Notify(tenantID int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.refreshers {
+		if r.filter.BaseFilter.TenantID != tenantID {
+			continue
+		}
+		select {
+		case r.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func filterKey(f order.WarningFilter) string {
+	payload, _ := json.Marshal(f)
+	return strconv.FormatInt(f.BaseFilter.TenantID, 10) + ":" + string(payload)
+}
+
+type refresher struct {
+	reader   TabsReader
+	filter   order.WarningFilter
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wake     chan struct{}
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	latest      *Snapshot
+	closed      bool
+}
+
+func newRefresher(reader TabsReader, f order.WarningFilter, interval time.Duration) *refresher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &refresher{
+		reader:      reader,
+		filter:      f,
+		interval:    interval,
+		ctx:         ctx,
+		cancel:      cancel,
+		wake:        make(chan struct{}, 1),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+func (r *refresher) add(sub *Subscription) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		close(sub.ch)
+		return
+	}
+	r.subscribers[sub] = struct{}{}
+	if r.latest != nil {
+		deliver(sub.ch, *r.latest)
+	}
+}
+
+func (r *refresher) remove(sub *Subscription) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.subscribers, sub)
+	return len(r.subscribers)
+}
+
+func (r *refresher) stop() {
+	r.cancel()
+}
+
+func (r *refresher) closeSubscribers() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for sub := range r.subscribers {
+		close(sub.ch)
+		delete(r.subscribers, sub)
+	}
+}
+
+func (r *refresher) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.refresh()
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+func (r *refresher) refresh() {
+	ctx, cancel := context.WithTimeout(r.ctx, r.interval)
+	defer cancel()
+
+	started := time.Now()
+	tabs, err := r.reader.GetOrdersForTabs(ctx, r.filter)
+	if err != nil {
+		if r.ctx.Err() == nil {
+			logging.Error(ctx, "tab stream refresh failed", err,
+				"duration_ms", time.Since(started).Milliseconds(),
+				"tenant_id", r.filter.BaseFilter.TenantID,
+			)
+		}
+		return
+	}
+
+	snapshot := newSnapshot(tabs)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || (r.latest != nil && r.latest.ID == snapshot.ID) {
+		return
+	}
+	r.latest = &snapshot
+	for sub := range r.subscribers {
+		deliver(sub.ch, snapshot)
+	}
+}
+
+// deliver keeps only the newest snapshot for a slow subscriber: tabs are
+// state, intermediate versions are not worth sending.
+func deliver(ch chan Snapshot, snapshot Snapshot) {
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- snapshot
+}
+
+func newSnapshot(tabs order.GroupOrdersResult) Snapshot {
+	signal := make(map[order.StatusGroup][]int64, len(tabs.OrdersForSignal))
+	for group, ids := range tabs.OrdersForSignal {
+		sorted := append([]int64{}, ids...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i] < sorted[j]
+		})
+		signal[group] = sorted
+	}
+
+	counts := make(map[order.StatusGroup]int, len(tabs.GroupCounts))
+	for group, count := range tabs.GroupCounts {
+		counts[group] = count
+	}
+
+	payload, _ := json.Marshal(struct {
+		Counts map[order.StatusGroup]int     `json:"c"`
+		Signal map[order.StatusGroup][]int64 `json:"s"`
+	}{counts, signal})
+	hash := fnv.New64a()
+	_, _ = hash.Write(payload)
+
+	return Snapshot{
+		ID:              hex.EncodeToString(hash.Sum(nil)),
+		GroupCounts:     counts,
+		OrdersForSignal: signal,
+	}
+}
@@ -0,0 +1,130 @@
+package tabstream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"orders-service/internal/app/order"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubTabsReader struct {
+	mu    sync.Mutex
+	calls atomic.Int64
+	tabs  order.GroupOrdersResult
+}
+
+func (s *stubTabsReader) GetOrdersForTabs(ctx context.Context, f order.WarningFilter) (order.GroupOrdersResult, error) {
+	s.calls.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tabs, nil
+}
+
+func (s *stubTabsReader) set(count int, signal ...int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tabs = order.GroupOrdersResult{
+		GroupCounts:     map[order.StatusGroup]int{order.StatusGroup0: count},
+		OrdersForSignal: map[order.StatusGroup][]int64{order.StatusGroup0: signal},
+	}
+}
+
+func receive(t *testing.T, sub *Subscription) Snapshot {
+	t.Helper()
+	select {
+	case snapshot := <-sub.C:
+		return snapshot
+	case <-time.After(time.Second):
+		t.Fatal("no snapshot received")
+		return Snapshot{}
+	}
+}
+
+func refresherCount(hub *Hub) int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return len(hub.refreshers)
+}
+
+func TestHub_SharesRefresherAndSendsOnlyChanges(t *testing.T) {
+	reader := &stubTabsReader{}
+	reader.set(2, 2, 1)
+	hub := NewHub(reader, WithRefreshInterval(10*time.Millisecond))
+	defer hub.Close()
+
+	f := order.WarningFilter{BaseFilter: order.BaseFilter{TenantID: 68}}
+	first := hub.Subscribe(f)
+	defer first.Close()
+	second := hub.Subscribe(f)
+	defer second.Close()
+
+	initial := receive(t, first)
+	require.Equal(t, []int64{1, 2}, initial.OrdersForSignal[order.StatusGroup0])
+	require.Equal(t, initial.ID, receive(t, second).ID)
+	require.Equal(t, 1, refresherCount(hub))
+
+	// Unchanged tabs are not sent again.
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case snapshot := <-first.C:
+		t.Fatalf("unexpected snapshot %s", snapshot.ID)
+	default:
+	}
+
+	reader.set(3, 1, 2, 3)
+	changed := receive(t, first)
+	require.NotEqual(t, initial.ID, changed.ID)
+	require.Equal(t, 3, changed.GroupCounts[order.StatusGroup0])
+}
+
+func TestHub_StopsRefresherWithLastSubscriber(t *testing.T) {
+	reader := &stubTabsReader{}
+	reader.set(1, 1)
+	hub := NewHub(reader, WithRefreshInterval(5*time.Millisecond))
+
+	sub := hub.Subscribe(order.WarningFilter{BaseFilter: order.BaseFilter{TenantID: 68}})
+	receive(t, sub)
+	sub.Close()
+
+	require.Zero(t, refresherCount(hub))
+	time.Sleep(20 * time.Millisecond)
+	calls := reader.calls.Load()
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, calls, reader.calls.Load())
+}
+
+func TestHub_CloseEndsSubscriptions(t *testing.T) {
+	reader := &stubTabsReader{}
+	reader.set(1, 1)
+	hub := NewHub(reader, WithRefreshInterval(5*time.Millisecond))
+
+	sub := hub.Subscribe(order.WarningFilter{BaseFilter: order.BaseFilter{TenantID: 68}})
+	receive(t, sub)
+	hub.Close()
+
+	_, ok := <-sub.C
+	require.False(t, ok)
+	sub.Close()
+}
+
+func TestHub_NotifyRefreshesTenantRightAway(t *testing.T) {
+	reader := &stubTabsReader{}
+	reader.set(1, 1)
+	hub := NewHub(reader, WithRefreshInterval(time.Hour))
+	defer hub.Close()
+
+	sub := hub.Subscribe(order.WarningFilter{BaseFilter: order.BaseFilter{TenantID: 68}})
+	defer sub.Close()
+	receive(t, sub)
+
+	reader.set(2, 1, 2)
+	hub.Notify(69)
+	hub.Notify(68)
+
+	require.Equal(t, 2, receive(t, sub).GroupCounts[order.StatusGroup0])
+}
@@ -0,0 +1,146 @@
+// Package apperror holds the error kinds the API reports to clients. Each kind
+// has a stable code and an HTTP status; the message of an Error is safe to
+// show, the wrapped cause is for the logs only.
+package apperror
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Kind classifies an error. Kinds are compared with errors.Is.
+type Kind struct {
+	code   string
+	status int
+}
+
+func (k *Kind) Error() string {
+	return k.code
+}
+
+// Code is the stable code clients switch on.
+func (k *Kind) Code() string {
+	return k.code
+}
+
+// Status is the HTTP status of the kind.
+func (k *Kind) Status() int {
+	return k.status
+}
+
+var (
+	ErrValidation          = &Kind{code: "validation_failed", status: http.StatusBadRequest}
+	ErrTooLarge            = &Kind{code: "request_too_large", status: http.StatusRequestEntityTooLarge}
+	ErrUnauthorized        = &Kind{code: "unauthorized", status: http.StatusUnauthorized}
+	ErrForbidden           = &Kind{code: "forbidden", status: http.StatusForbidden}
+	ErrNotFound            = &Kind{code: "not_found", status: http.StatusNotFound}
+	ErrNotImplemented      = &Kind{code: "not_implemented", status: http.StatusNotImplemented}
+	ErrUpstreamUnavailable = &Kind{code: "upstream_unavailable", status: http.StatusServiceUnavailable}
+	ErrTimeout             = &Kind{code: "timeout", status: http.StatusGatewayTimeout}
+	ErrInternal            = &Kind{code: "internal_error", status: http.StatusInternalServerError}
+)
+
+// Error is an error with a client safe message.
+type Error struct {
+	Kind *Kind
+	// Code overrides the code of the kind for a more specific one, e.g.
+	// forbidden_city.
+	Code    string
+	Message string
+	// Details is a map of the offending values or a []FieldError.
+	Details any
+	// Err is the cause, it is logged but never sent to the client.
+	Err error
+}
+
+func New(kind *Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap keeps cause for the logs behind a client safe message.
+func Wrap(kind *Kind, message string, cause error) *Error {
+	return &Error{Kind: kind, Message: message, Err: cause}
+}
+
+func Validation(message string) *Error {
+	return New(ErrValidation, message)
+}
+
+func NotFound(message string) *Error {
+	return New(ErrNotFound, message)
+}
+
+// FieldError is one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Fields is a validation error listing every invalid field.
+func Fields(errs []FieldError) *Error {
+	return Validation("request validation failed").WithDetails(errs)
+}
+
+// WithCode sets a code more specific than the one of the kind.
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+func (e *Error) WithDetails(details any) *Error {
+	e.Details = details
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// ErrorCode is the specific code if set and the code of the kind otherwise.
+func (e *Error) ErrorCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return e.Kind.Code()
+}
+
+// From returns err as an *Error. Errors without a kind are classified by their
+// cause: deadlines become ErrTimeout, connection failures of MySQL and Redis
+// ErrUpstreamUnavailable and anything else ErrInternal. The message of a
+// classified error never contains the text of err.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(ErrTimeout, "the request timed out", err)
+	case isUnavailable(err):
+		return Wrap(ErrUpstreamUnavailable, "a backing service is unavailable", err)
+	default:
+		return Wrap(ErrInternal, "internal error", err)
+	}
+}
+
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone)
+}
@@ -0,0 +1,45 @@
+package apperror
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrom_ClassifiesCauses(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind *Kind
+	}{
+		{"deadline", fmt.Errorf("select orders: %w", context.DeadlineExceeded), ErrTimeout},
+		{"bad connection", fmt.Errorf("select orders: %w", driver.ErrBadConn), ErrUpstreamUnavailable},
+		{"other", errors.New("Error 1054: Unknown column 'x'"), ErrInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+
+			require.Same(t, tt.kind, got.Kind)
+			require.ErrorIs(t, got, tt.kind)
+			require.ErrorIs(t, got, tt.err)
+			require.NotContains(t, got.Message, tt.err.Error())
+		})
+	}
+}
+
+func TestFrom_KeepsTypedErrors(t *testing.T) {
+	err := fmt.Errorf("scope: %w", New(ErrForbidden, "city not allowed").WithCode("forbidden_city"))
+
+	got := From(err)
+
+	require.Equal(t, http.StatusForbidden, got.Kind.Status())
+	require.Equal(t, "forbidden_city", got.ErrorCode())
+	require.Equal(t, "city not allowed", got.Message)
+	require.True(t, errors.Is(err, ErrForbidden))
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Principal is the caller of a request as stated by a verified token. Empty
+// CityIDs or Positions mean the caller is not limited to some of them.
+type Principal struct {
+	Subject   string
+	TenantID  int64
+	CityIDs   []int64
+	Positions []int64
+}
+
+func (p Principal) AllowsCity(cityID int64) bool {
+	return len(p.CityIDs) == 0 || slices.Contains(p.CityIDs, cityID)
+}
+
+func (p Principal) AllowsPosition(positionID int64) bool {
+	return len(p.Positions) == 0 || slices.Contains(p.Positions, positionID)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the caller put by the auth middleware. ok is
+// false when authentication is disabled.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+type VerifierOption func(*Verifier)
+
+func WithIssuer(issuer string) VerifierOption {
+	return func(v *Verifier) {
+		v.issuer = issuer
+	}
+}
+
+func WithAudience(audience string) VerifierOption {
+	return func(v *Verifier) {
+		v.audience = audience
+	}
+}
+
+// WithLeeway tolerates clock drift between the issuer and this service when
+// checking exp and nbf.
+func WithLeeway(leeway time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		if leeway >= 0 {
+			v.leeway = leeway
+		}
+	}
+}
+
+// Verifier checks HS256 and RS256 signed JWTs against a key set.
+type Verifier struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+func NewVerifier(keys *KeySet, opts ...VerifierOption) *Verifier {
+	v := &Verifier{
+		keys:   keys,
+		leeway: 30 * time.Second,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	TenantID  int64    `json:"tenant_id"`
+	CityIDs   []int64  `json:"city_ids"`
+	Positions []int64  `json:"positions"`
+}
+
+// audience accepts both forms of the aud claim, a string and a list.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Verify checks the signature and the registered claims of a compact JWT and
+// returns its principal. All failures wrap ErrInvalidToken or ErrTokenExpired.
+func (v *Verifier) Verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	key, ok := v.keys.lookup(header.KeyID, header.Algorithm)
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: unknown key %q for %s", ErrInvalidToken, header.KeyID, header.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	if err := verifySignature(key, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return Principal{}, err
+	}
+
+	return Principal{
+		Subject:   claims.Subject,
+		TenantID:  claims.TenantID,
+		CityIDs:   claims.CityIDs,
+		Positions: claims.Positions,
+	}, nil
+}
+
+func (v *Verifier) checkClaims(claims tokenClaims) error {
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != nil && now.Add(v.leeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return fmt.Errorf("%w: audience", ErrInvalidToken)
+	}
+	if claims.TenantID <= 0 {
+		return fmt.Errorf("%w: tenant_id is required", ErrInvalidToken)
+	}
+	return nil
+}
+
+func verifySignature(key Key, signingInput string, signature []byte) error {
+	switch key.Algorithm {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, key.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	case AlgRS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key.publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported algorithm %q", key.Algorithm)
+	}
+}
+
+func decodeSegment(segment string, target any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
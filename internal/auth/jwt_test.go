@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+var testNow = time.Unix(1774051200, 0)
+
+func encodeSegment(t *testing.T, value any) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, header, claims map[string]any, secret string) string {
+	t.Helper()
+	input := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, header, claims map[string]any, key *rsa.PrivateKey) string {
+	t.Helper()
+	input := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":       "dispatcher-7",
+		"iss":       "sso",
+		"aud":       []string{"orders"},
+		"exp":       testNow.Add(time.Hour).Unix(),
+		"tenant_id": 68,
+		"city_ids":  []int64{26068},
+		"positions": []int64{1, 2},
+	}
+}
+
+func newTestVerifier(t *testing.T, keys *KeySet) *Verifier {
+	t.Helper()
+	v := NewVerifier(keys, WithIssuer("sso"), WithAudience("orders"))
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func TestVerifier_AcceptsHS256AndRS256(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	file, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kid": "main", "alg": AlgHS256, "secret": testSecret},
+		{"kid": "sso", "alg": AlgRS256, "public_key": string(publicPEM)},
+	}})
+	require.NoError(t, err)
+	keys, err := ParseKeySet(file)
+	require.NoError(t, err)
+	v := newTestVerifier(t, keys)
+
+	principal, err := v.Verify(signHS256(t, map[string]any{"alg": AlgHS256, "kid": "main"}, validClaims(), testSecret))
+	require.NoError(t, err)
+	require.Equal(t, Principal{
+		Subject:   "dispatcher-7",
+		TenantID:  68,
+		CityIDs:   []int64{26068},
+		Positions: []int64{1, 2},
+	}, principal)
+
+	principal, err = v.Verify(signRS256(t, map[string]any{"alg": AlgRS256, "kid": "sso"}, validClaims(), rsaKey))
+	require.NoError(t, err)
+	require.Equal(t, int64(68), principal.TenantID)
+
+	// Without kid the only key of the algorithm is used.
+	_, err = v.Verify(signRS256(t, map[string]any{"alg": AlgRS256}, validClaims(), rsaKey))
+	require.NoError(t, err)
+}
+
+func TestVerifier_RejectsInvalidTokens(t *testing.T) {
+	keys := NewKeySet()
+	require.NoError(t, keys.AddHMAC("main", []byte(testSecret)))
+	v := newTestVerifier(t, keys)
+	header := map[string]any{"alg": AlgHS256, "kid": "main"}
+
+	expired := validClaims()
+	expired["exp"] = testNow.Add(-time.Hour).Unix()
+	_, err := v.Verify(signHS256(t, header, expired, testSecret))
+	require.ErrorIs(t, err, ErrTokenExpired)
+
+	tests := map[string]string{
+		"wrong secret": signHS256(t, header, validClaims(), testSecret+"x"),
+		"alg none":     signHS256(t, map[string]any{"alg": "none", "kid": "main"}, validClaims(), testSecret),
+		"alg mismatch": signHS256(t, map[string]any{"alg": AlgRS256, "kid": "main"}, validClaims(), testSecret),
+		"unknown kid":  signHS256(t, map[string]any{"alg": AlgHS256, "kid": "old"}, validClaims(), testSecret),
+		"malformed":    "not-a-token",
+	}
+	for name, claim := range map[string]string{"iss": "other", "aud": "billing"} {
+		claims := validClaims()
+		claims[name] = claim
+		tests["wrong "+name] = signHS256(t, header, claims, testSecret)
+	}
+	noTenant := validClaims()
+	delete(noTenant, "tenant_id")
+	tests["no tenant"] = signHS256(t, header, noTenant, testSecret)
+	noExp := validClaims()
+	delete(noExp, "exp")
+	tests["no exp"] = signHS256(t, header, noExp, testSecret)
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.Verify(token)
+			require.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestParseKeySet_RejectsWeakOrUnknownKeys(t *testing.T) {
+	_, err := ParseKeySet([]byte(`{"keys":[{"kid":"a","alg":"HS256","secret":"short"}]}`))
+	require.Error(t, err)
+
+	_, err = ParseKeySet([]byte(`{"keys":[{"kid":"a","alg":"ES256","secret":"x"}]}`))
+	require.Error(t, err)
+
+	_, err = ParseKeySet([]byte(`{"keys":[]}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// Key verifies the signatures of one algorithm. A key only accepts tokens
+// signed with its own algorithm, so an RSA public key can never be used as an
+// HMAC secret.
+type Key struct {
+	ID        string
+	Algorithm string
+	secret    []byte
+	publicKey *rsa.PublicKey
+}
+
+// KeySet holds the keys tokens may be signed with, by key id.
+type KeySet struct {
+	keys map[string]Key
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{keys: make(map[string]Key)}
+}
+
+func (s *KeySet) AddHMAC(id string, secret []byte) error {
+	if len(secret) < 32 {
+		return fmt.Errorf("hmac key %q: secret must be at least 32 bytes", id)
+	}
+	return s.add(Key{ID: id, Algorithm: AlgHS256, secret: secret})
+}
+
+func (s *KeySet) AddRSA(id string, publicKey *rsa.PublicKey) error {
+	if publicKey == nil {
+		return fmt.Errorf("rsa key %q: public key is empty", id)
+	}
+	return s.add(Key{ID: id, Algorithm: AlgRS256, publicKey: publicKey})
+}
+
+func (s *KeySet) add(key Key) error {
+	if _, ok := s.keys[key.ID]; ok {
+		return fmt.Errorf("duplicate key id %q", key.ID)
+	}
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *KeySet) Len() int {
+	return len(s.keys)
+}
+
+// lookup finds the key of a token header. A token without kid is accepted
+// only when a single key of its algorithm is configured.
+func (s *KeySet) lookup(id, algorithm string) (Key, bool) {
+	if id != "" {
+		key, ok := s.keys[id]
+		return key, ok && key.Algorithm == algorithm
+	}
+
+	var found Key
+	matches := 0
+	for _, key := range s.keys {
+		if key.Algorithm == algorithm {
+			found = key
+			matches++
+		}
+	}
+	return found, matches == 1
+}
+
+type keySetFile struct {
+	Keys []struct {
+		ID        string `json:"kid"`
+		Algorithm string `json:"alg"`
+		Secret    string `json:"secret"`
+		PublicKey string `json:"public_key"`
+	} `json:"keys"`
+}
+
+// LoadKeySetFile reads a key set like
+//
+//	{"keys": [
+//	  {"kid": "main", "alg": "HS256", "secret": "..."},
+//	  {"kid": "sso", "alg": "RS256", "public_key": "-----BEGIN PUBLIC KEY-----..."}
+//	]}
+func LoadKeySetFile(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key set: %w", err)
+	}
+	return ParseKeySet(data)
+}
+
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var file keySetFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode key set: %w", err)
+	}
+	if len(file.Keys) == 0 {
+		return nil, errors.New("key set has no keys")
+	}
+
+	set := NewKeySet()
+	for _, entry := range file.Keys {
+		var err error
+		switch entry.Algorithm {
+		case AlgHS256:
+			err = set.AddHMAC(entry.ID, []byte(entry.Secret))
+		case AlgRS256:
+			var publicKey *rsa.PublicKey
+			publicKey, err = parseRSAPublicKey(entry.PublicKey)
+			if err == nil {
+				err = set.AddRSA(entry.ID, publicKey)
+			}
+		default:
+			err = fmt.Errorf("key %q: unsupported algorithm %q", entry.ID, entry.Algorithm)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+func parseRSAPublicKey(raw string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(raw))
+	if block == nil {
+		return nil, errors.New("rsa public key is not PEM encoded")
+	}
+
+	if parsed, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		publicKey, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("public key is not an RSA key")
+		}
+		return publicKey, nil
+	}
+	return x509.ParsePKCS1PublicKey(block.Bytes)
+}
@@ -31,6 +31,9 @@ func NewRedisActiveOrders() (*redis.Client, error) {
 		DialTimeout:  cfg.ConnectionTimeout,
 		ReadTimeout:  cfg.DataTimeout,
 		WriteTimeout: cfg.DataTimeout,
+		// Request deadlines and the command timeout of the active orders
+		// repository bound the socket reads, not only the pool wait.
+		ContextTimeoutEnabled: true,
 	})
 
 	return client, nil
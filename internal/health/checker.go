@@ -0,0 +1,177 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"orders-service/internal/logging"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+	StatusDraining = "draining"
+
+	defaultTimeout = 2 * time.Second
+)
+
+// Check returns an error when a dependency cannot serve requests. It must
+// give up when ctx is done.
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name     string
+	check    Check
+	optional bool
+}
+
+type Option func(*Checker)
+
+// WithTimeout bounds every check of a probe.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// Checker runs the dependency checks behind the liveness and readiness
+// probes.
+type Checker struct {
+	timeout  time.Duration
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+func NewChecker(opts ...Option) *Checker {
+	c := &Checker{timeout: defaultTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Add registers a dependency check; call it before serving probes.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// AddOptional registers a dependency the service can serve without. Its
+// failure is reported and degrades the report but keeps readiness up.
+func (c *Checker) AddOptional(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check, optional: true})
+}
+
+// Drain makes readiness fail from now on, so the load balancer stops sending
+// traffic before the server shuts down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Run executes all checks in parallel, each with the checker timeout. The
+// error text stays in the logs, the report only says why a check failed.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(c.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			started := time.Now()
+			err := check.check(checkCtx)
+			result := CheckResult{
+				Status:    StatusUp,
+				LatencyMS: float64(time.Since(started).Microseconds()) / 1000,
+			}
+			if err != nil {
+				logging.Warn(ctx, "health check failed", "check", check.name, "error", err.Error())
+				result.Status = StatusDown
+				result.Error = "unavailable"
+				if errors.Is(err, context.DeadlineExceeded) || errors.Is(checkCtx.Err(), context.DeadlineExceeded) {
+					result.Error = "timeout"
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.name] = result
+			switch {
+			case result.Status == StatusUp:
+			case !check.optional:
+				report.Status = StatusDown
+			case report.Status == StatusUp:
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// LiveHandler answers 200 while the process can serve HTTP. Dependencies are
+// reported but do not fail the probe, a restart does not fix an unreachable
+// database.
+func (c *Checker) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+		report.Status = StatusUp
+		writeReport(w, http.StatusOK, report)
+	})
+}
+
+// ReadyHandler answers 503 when a required dependency is down or the server
+// drains. A degraded report is still ready.
+func (c *Checker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.draining.Load() {
+			writeReport(w, http.StatusServiceUnavailable, Report{
+				Status: StatusDraining,
+				Checks: map[string]CheckResult{},
+			})
+			return
+		}
+
+		report := c.Run(r.Context())
+		status := http.StatusOK
+		if report.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		writeReport(w, status, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, handler http.Handler) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestChecker_ReadyReportsEveryDependency(t *testing.T) {
+	c := NewChecker(WithTimeout(20 * time.Millisecond))
+	c.Add("mysql", func(ctx context.Context) error { return nil })
+	c.Add("redis", func(ctx context.Context) error { return errors.New("dial tcp 10.0.0.1:6379: refused") })
+	c.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	code, report := serve(t, c.ReadyHandler())
+
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, StatusUp, report.Checks["mysql"].Status)
+	require.Equal(t, CheckResult{Status: StatusDown, LatencyMS: report.Checks["redis"].LatencyMS, Error: "unavailable"}, report.Checks["redis"])
+	require.Equal(t, "timeout", report.Checks["slow"].Error)
+	require.GreaterOrEqual(t, report.Checks["slow"].LatencyMS, float64(20))
+}
+
+func TestChecker_OptionalDependencyDegradesReadiness(t *testing.T) {
+	c := NewChecker()
+	c.Add("mysql", func(ctx context.Context) error { return nil })
+	c.AddOptional("redis", func(ctx context.Context) error { return errors.New("refused") })
+
+	code, report := serve(t, c.ReadyHandler())
+
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusDegraded, report.Status)
+	require.Equal(t, StatusDown, report.Checks["redis"].Status)
+
+	c.Add("broken", func(ctx context.Context) error { return errors.New("down") })
+	code, report = serve(t, c.ReadyHandler())
+
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, StatusDown, report.Status)
+}
+
+func TestChecker_LiveIgnoresDependencies(t *testing.T) {
+	c := NewChecker()
+	c.Add("mysql", func(ctx context.Context) error { return errors.New("down") })
+
+	code, report := serve(t, c.LiveHandler())
+
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusUp, report.Status)
+	require.Equal(t, StatusDown, report.Checks["mysql"].Status)
+}
+
+func TestChecker_DrainFailsReadiness(t *testing.T) {
+	c := NewChecker()
+	c.Add("mysql", func(ctx context.Context) error { return nil })
+
+	code, _ := serve(t, c.ReadyHandler())
+	require.Equal(t, http.StatusOK, code)
+
+	c.Drain()
+
+	code, report := serve(t, c.ReadyHandler())
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, StatusDraining, report.Status)
+
+	code, _ = serve(t, c.LiveHandler())
+	require.Equal(t, http.StatusOK, code)
+}
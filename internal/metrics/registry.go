@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector writes its samples in the Prometheus text format.
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds the collectors exposed by one /metrics endpoint.
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default is the registry the package level metrics are registered in.
+var Default = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.name()]; ok {
+		panic("metrics: duplicate metric " + c.name())
+	}
+	r.collectors[c.name()] = c
+}
+
+// WriteTo writes every collector sorted by metric name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].name() < collectors[j].name()
+	})
+
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+	for _, c := range collectors {
+		c.write(buffered)
+	}
+	err := buffered.Flush()
+	return counter.n, err
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.ReplaceAll(help, "\n", " "))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// writeSample writes one line; extra is an already formatted label like le.
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string, extra string, value float64) {
+	w.WriteString(name)
+	if len(labelNames) > 0 || extra != "" {
+		w.WriteByte('{')
+		for i, label := range labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabel(labelValues[i]))
+			w.WriteByte('"')
+		}
+		if extra != "" {
+			if len(labelNames) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extra)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// labelKey joins label values into a map key; \xff never occurs in labels
+// this service produces.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func checkLabels(name string, labelNames, values []string) {
+	if len(labelNames) != len(values) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(labelNames), len(values)))
+	}
+}
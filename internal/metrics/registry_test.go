@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WritesPrometheusText(t *testing.T) {
+	r := NewRegistry()
+	h := NewHistogramVec(r, "test_duration_seconds", "Test durations.", []float64{0.1, 1}, "stage")
+	c := NewCounterVec(r, "test_skipped_total", "Skipped items.", "reader")
+	NewGaugeFunc(r, "test_open", "Open things.", func() []Sample {
+		return []Sample{{LabelValues: []string{`a"b`}, Value: 3}}
+	}, "name")
+
+	h.Observe(0.05, "fetch")
+	h.Observe(0.5, "fetch")
+	h.Observe(2, "fetch")
+	c.Inc("hvals")
+	c.Add(2, "hvals")
+
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	require.NoError(t, err)
+
+	require.Equal(t, strings.Join([]string{
+		`# HELP test_duration_seconds Test durations.`,
+		`# TYPE test_duration_seconds histogram`,
+		`test_duration_seconds_bucket{stage="fetch",le="0.1"} 1`,
+		`test_duration_seconds_bucket{stage="fetch",le="1"} 2`,
+		`test_duration_seconds_bucket{stage="fetch",le="+Inf"} 3`,
+		`test_duration_seconds_sum{stage="fetch"} 2.55`,
+		`test_duration_seconds_count{stage="fetch"} 3`,
+		`# HELP test_open Open things.`,
+		`# TYPE test_open gauge`,
+		`test_open{name="a\"b"} 3`,
+		`# HELP test_skipped_total Skipped items.`,
+		`# TYPE test_skipped_total counter`,
+		`test_skipped_total{reader="hvals"} 3`,
+		``,
+	}, "\n"), buf.String())
+}
+
+func TestRegistry_PanicsOnDuplicateOrWrongLabels(t *testing.T) {
+	r := NewRegistry()
+	c := NewCounterVec(r, "test_total", "Test.", "kind")
+
+	require.Panics(t, func() { NewCounterVec(r, "test_total", "Test.", "kind") })
+	require.Panics(t, func() { c.Inc() })
+}
+
+func TestRegisterDBStats_ExportsPoolGauges(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(7)
+
+	RegisterDBStats("test", db)
+
+	var buf bytes.Buffer
+	_, err = Default.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `orders_db_max_open_connections{db="test"} 7`)
+	require.Contains(t, buf.String(), "# TYPE orders_db_wait_count_total counter")
+}
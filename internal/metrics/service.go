@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"database/sql"
+	"sync"
+)
+
+var (
+	HTTPRequestDuration = NewHistogramVec(Default,
+		"orders_http_request_duration_seconds",
+		"Duration of HTTP requests by route pattern, method and status code.",
+		nil, "route", "method", "status")
+
+	// StageDuration replaces the *_ms fields of the timing log lines, pipeline
+	// is the use case and stage one step of it.
+	StageDuration = NewHistogramVec(Default,
+		"orders_stage_duration_seconds",
+		"Duration of the steps of the order service pipelines.",
+		nil, "pipeline", "stage")
+
+	MySQLQueryDuration = NewHistogramVec(Default,
+		"orders_mysql_query_duration_seconds",
+		"Duration of MySQL queries including the row scan, by query kind.",
+		nil, "query")
+
+	RedisCommandDuration = NewHistogramVec(Default,
+		"orders_redis_command_duration_seconds",
+		"Latency of Redis commands on the active orders hashes.",
+		nil, "command")
+
+	RedisSkippedPayloads = NewCounterVec(Default,
+		"orders_redis_skipped_payloads_total",
+		"Active order payloads skipped because they could not be decoded.",
+		"reader")
+
+	RedisPayloadMissingFields = NewCounterVec(Default,
+		"orders_redis_payload_missing_fields_total",
+		"Active order payload shapes without the key of an order field, by field.",
+		"field")
+
+	ResponseCacheRequests = NewCounterVec(Default,
+		"orders_response_cache_requests_total",
+		"Requests of cached routes by whether the response came from the cache, a shared load or a new one.",
+		"route", "result")
+
+	DegradedResponses = NewCounterVec(Default,
+		"orders_degraded_total",
+		"Requests served without a component because Redis failed, by component.",
+		"component")
+
+	GetAllDuplicates = NewCounterVec(Default,
+		"orders_get_all_duplicates_total",
+		"Orders the all-orders search found in both MySQL and Redis, by the source whose record was kept.",
+		"kept")
+
+	RedisBreakerTransitions = NewCounterVec(Default,
+		"orders_redis_breaker_transitions_total",
+		"State changes of the Redis circuit breaker, by the new state.",
+		"state")
+)
+
+var (
+	dbStatsOnce sync.Once
+	dbStatsMu   sync.Mutex
+	dbStats     = map[string]*sql.DB{}
+)
+
+// RegisterDBStats exports the connection pool stats of db under the db label.
+func RegisterDBStats(name string, db *sql.DB) {
+	dbStatsMu.Lock()
+	dbStats[name] = db
+	dbStatsMu.Unlock()
+
+	dbStatsOnce.Do(registerDBStatsMetrics)
+}
+
+func registerDBStatsMetrics() {
+	gauges := []struct {
+		name  string
+		help  string
+		value func(sql.DBStats) float64
+	}{
+		{"orders_db_max_open_connections", "Maximum number of open connections to the database.",
+			func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+		{"orders_db_open_connections", "Established connections, in use and idle.",
+			func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+		{"orders_db_in_use_connections", "Connections currently in use.",
+			func(s sql.DBStats) float64 { return float64(s.InUse) }},
+		{"orders_db_idle_connections", "Idle connections.",
+			func(s sql.DBStats) float64 { return float64(s.Idle) }},
+	}
+	counters := []struct {
+		name  string
+		help  string
+		value func(sql.DBStats) float64
+	}{
+		{"orders_db_wait_count_total", "Connections waited for.",
+			func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+		{"orders_db_wait_duration_seconds_total", "Time blocked waiting for a new connection.",
+			func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+		{"orders_db_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.",
+			func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+		{"orders_db_max_idle_time_closed_total", "Connections closed due to SetConnMaxIdleTime.",
+			func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+		{"orders_db_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.",
+			func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+	}
+
+	for _, gauge := range gauges {
+		NewGaugeFunc(Default, gauge.name, gauge.help, collectDBStats(gauge.value), "db")
+	}
+	for _, counter := range counters {
+		NewCounterFunc(Default, counter.name, counter.help, collectDBStats(counter.value), "db")
+	}
+}
+
+func collectDBStats(value func(sql.DBStats) float64) func() []Sample {
+	return func() []Sample {
+		dbStatsMu.Lock()
+		defer dbStatsMu.Unlock()
+
+		samples := make([]Sample, 0, len(dbStats))
+		for name, db := range dbStats {
+			samples = append(samples, Sample{LabelValues: []string{name}, Value: value(db.Stats())})
+		}
+		return samples
+	}
+}
@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets cover the latencies of this service, from a Redis call to a
+// slow all-orders search, in seconds.
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	labels []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	metricName string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+func NewHistogramVec(r *Registry, name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	h := &HistogramVec{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*histogram),
+	}
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	checkLabels(h.metricName, h.labelNames, labelValues)
+	key := labelKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogram{
+			labels: append([]string(nil), labelValues...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = series
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.sum += value
+	series.count++
+}
+
+// ObserveDuration records time.Since(started) in seconds and returns it.
+func (h *HistogramVec) ObserveDuration(started time.Time, labelValues ...string) time.Duration {
+	elapsed := time.Since(started)
+	h.Observe(elapsed.Seconds(), labelValues...)
+	return elapsed
+}
+
+func (h *HistogramVec) name() string {
+	return h.metricName
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	writeHeader(w, h.metricName, h.help, "histogram")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		for i, bound := range h.buckets {
+			writeSample(w, h.metricName+"_bucket", h.labelNames, series.labels, `le="`+formatFloat(bound)+`"`, float64(series.counts[i]))
+		}
+		writeSample(w, h.metricName+"_bucket", h.labelNames, series.labels, `le="+Inf"`, float64(series.count))
+		writeSample(w, h.metricName+"_sum", h.labelNames, series.labels, "", series.sum)
+		writeSample(w, h.metricName+"_count", h.labelNames, series.labels, "", float64(series.count))
+	}
+}
+
+type counter struct {
+	labels []string
+	value  float64
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	metricName string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	series map[string]*counter
+}
+
+func NewCounterVec(r *Registry, name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+		series:     make(map[string]*counter),
+	}
+	r.register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	checkLabels(c.metricName, c.labelNames, labelValues)
+	if delta < 0 {
+		return
+	}
+	key := labelKey(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	series, ok := c.series[key]
+	if !ok {
+		series = &counter{labels: append([]string(nil), labelValues...)}
+		c.series[key] = series
+	}
+	series.value += delta
+}
+
+func (c *CounterVec) name() string {
+	return c.metricName
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	writeHeader(w, c.metricName, c.help, "counter")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		series := c.series[key]
+		writeSample(w, c.metricName, c.labelNames, series.labels, "", series.value)
+	}
+}
+
+// Sample is one value of a function metric.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// FuncMetric reads its values at scrape time, e.g. from sql.DB.Stats.
+type FuncMetric struct {
+	metricName string
+	help       string
+	kind       string
+	labelNames []string
+	collect    func() []Sample
+}
+
+func NewGaugeFunc(r *Registry, name, help string, collect func() []Sample, labelNames ...string) *FuncMetric {
+	return newFuncMetric(r, name, help, "gauge", collect, labelNames)
+}
+
+// NewCounterFunc is for totals that only grow and are kept elsewhere.
+func NewCounterFunc(r *Registry, name, help string, collect func() []Sample, labelNames ...string) *FuncMetric {
+	return newFuncMetric(r, name, help, "counter", collect, labelNames)
+}
+
+func newFuncMetric(r *Registry, name, help, kind string, collect func() []Sample, labelNames []string) *FuncMetric {
+	m := &FuncMetric{
+		metricName: name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		collect:    collect,
+	}
+	r.register(m)
+	return m
+}
+
+func (m *FuncMetric) name() string {
+	return m.metricName
+}
+
+func (m *FuncMetric) write(w *bufio.Writer) {
+	writeHeader(w, m.metricName, m.help, m.kind)
+	for _, sample := range m.collect() {
+		if len(sample.LabelValues) != len(m.labelNames) || math.IsNaN(sample.Value) {
+			continue
+		}
+		writeSample(w, m.metricName, m.labelNames, sample.LabelValues, "", sample.Value)
+	}
+}
+
+func sortedKeys[T any](series map[string]T) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,163 +4,51 @@ import (
 	"context"
 	"orders-service/internal/app/order"
 	"orders-service/internal/logging"
+	"regexp"
 	"strings"
 	"time"
 )
 
-func (r *OrdersRepository) FetchAllOrdersForGetAll(
+func (r *OrdersRepository) CountAllOrdersForGetAll(
 	ctx context.Context,
 	f order.GetAllOrdersFilter,
-) ([]order.FullOrder, error) {
+) (int64, error) {
 	var sb strings.Builder
-	var args []any
+	sb.WriteString("SELECT COUNT(*)")
+	sb.WriteString(fullOrderFrom)
+	args := writeGetAllWhere(&sb, f)
 
-	sb.WriteString(`
-SELECT
-    o.order_id,
-    o.tenant_id,
-    o.worker_id,
-    o.car_id,
-    o.city_id,
-    o.tariff_id,
-    o.user_create,
-    o.status_id,
-    o.user_modifed,
-    o.company_id,
-    o.parking_id,
-    o.address,
-    o.comment,
-    o.predv_price,
-    o.predv_price_no_discount,
-    o.device,
-    o.order_number,
-    o.payment,
-    o.show_phone,
-    o.create_time,
-    o.status_time,
-    o.time_to_client,
-    o.client_device_token,
-    o.app_id,
-    o.order_time,
-    o.predv_distance,
-    o.predv_time,
-    o.call_warning_id,
-    o.phone,
-    o.client_id,
-    o.bonus_payment,
-    o.currency_id,
-    o.time_offset,
-    o.is_fix,
-    o.update_time,
-    o.deny_refuse_order,
-    o.position_id,
-    o.promo_code_id,
-    o.tenant_company_id,
-    o.mark,
-    o.processed_exchange_program_id,
-    o.client_passenger_id,
-    o.client_passenger_phone,
-    o.active,
-    o.is_pre_order,
-    o.app_version,
-    o.agent_commission,
-    o.is_fix_by_dispatcher,
-    o.finish_time,
-    o.comment_for_dispatcher,
-    o.worker_manual_surcharge,
-    o.realtime_price,
-    o.unit_quantity,
-    o.shop_id,
-    o.require_prepayment,
-    o.order_code,
-    o.client_offered_price,
-    o.idempotent_key,
-    o.additional_tariff_id,
-    o.initial_price,
-    o.time_to_order,
-    o.sort,
-    d.summary_cost,
-    d.summary_cost_no_discount,
-    s.status_id AS status_status_id,
-    s.name AS status_name,
-    w.worker_id,
-    w.callsign,
-    w.name,
-    w.last_name,
-    w.second_name,
-    w.phone,
-    cl.client_id,
-    cl.phone,
-    cl.name,
-    cl.last_name,
-    cl.second_name,
-    car.car_id,
-    car.name,
-    car.color,
-    car.gos_number,
-    t.tariff_id,
-    t.tariff_type,
-    t.name,
-    t.quantitative_title,
-    t.price_for_unit,
-    t.unit_name,
-    u.user_id,
-    u.name,
-    u.last_name,
-    u.second_name,
-    curr.name,
-    curr.code,
-    curr.symbol
-FROM tbl_order o
-LEFT JOIN tbl_client cl ON o.client_id = cl.client_id
-LEFT JOIN tbl_order_status s ON o.status_id = s.status_id
-LEFT JOIN tbl_worker w ON o.worker_id = w.worker_id
-LEFT JOIN tbl_car car ON o.car_id = car.car_id
-LEFT JOIN tbl_taxi_tariff t ON o.tariff_id = t.tariff_id
-LEFT JOIN tbl_order_detail_cost d ON o.order_id = d.order_id
-LEFT JOIN tbl_user u ON o.user_create = u.user_id
-LEFT JOIN tbl_currency curr ON o.currency_id = curr.currency_id
-WHERE o.tenant_id = ?
-  AND o.active = 1
-`)
-	args = append(args, f.TenantID)
-
-	if len(f.CityIDs) > 0 {
-		sb.WriteString(" AND o.city_id IN (")
-		for i, id := range f.CityIDs {
-			if i > 0 {
-				sb.WriteString(",")
-			}
-			sb.WriteString("?")
-			args = append(args, id)
-		}
-		sb.WriteString(")\n")
+	started := time.Now()
+	defer observeQuery("get_all_count", started)
+	var count int64
+	if err := r.db.QueryRowContext(ctx, sb.String(), args...).Scan(&count); err != nil {
+		logging.Error(ctx, "mysql getAll count failed", err,
+			"query_ms", time.Since(started).Milliseconds(),
+			"tenant_id", f.TenantID,
+		)
+		return 0, err
 	}
 
-	if len(f.ShopIDs) > 0 {
-		sb.WriteString(" AND o.shop_id IN (")
-		for i, id := range f.ShopIDs {
-			if i > 0 {
-				sb.WriteString(",")
-			}
-			sb.WriteString("?")
-			args = append(args, id)
-		}
-		sb.WriteString(")\n")
-	}
+	return count, nil
+}
 
-	if from, to, ok := getAllDayRange(f.Date); ok {
-		sb.WriteString(`
- AND (
-      (o.create_time BETWEEN ? AND ?)
-   OR (o.order_time BETWEEN ? AND ?)
-   OR (o.finish_time BETWEEN ? AND ?)
- )
-`)
-		args = append(args, from, to, from, to, from, to)
-	}
+func (r *OrdersRepository) FetchAllOrdersForGetAll(
+	ctx context.Context,
+	f order.GetAllOrdersFilter,
+	offset, limit int,
+) ([]order.FullOrder, error) {
+	var sb strings.Builder
+
+	sb.WriteString(fullOrderSelect)
+	args := writeGetAllWhere(&sb, f)
+	appendCursorCondition(&sb, &args, f.Cursor)
+
+	writeOrderBy(&sb, &args, order.GetAllSort(f.BaseFilter), f.WaitTimes)
+	sb.WriteString("LIMIT ? OFFSET ?\n")
+	args = append(args, limit, offset)
 
 	totalStarted := time.Now()
+	defer observeQuery("get_all_fetch", totalStarted)
 	queryStarted := time.Now()
 	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
 	queryMS := time.Since(queryStarted).Milliseconds()
@@ -173,103 +61,7 @@ WHERE o.tenant_id = ?
 	scanStarted := time.Now()
 	var result []order.FullOrder
 	for rows.Next() {
-		var o order.FullOrder
-		err = rows.Scan(
-			&o.OrderID,
-			&o.TenantID,
-			&o.WorkerID,
-			&o.CarID,
-			&o.CityID,
-			&o.TariffID,
-			&o.UserCreate,
-			&o.StatusID,
-			&o.UserModified,
-			&o.CompanyID,
-			&o.ParkingID,
-			&o.Address,
-			&o.Comment,
-			&o.PredvPrice,
-			&o.PredvPriceNoDiscount,
-			&o.Device,
-			&o.OrderNumber,
-			&o.Payment,
-			&o.ShowPhone,
-			&o.CreateTime,
-			&o.StatusTime,
-			&o.TimeToClient,
-			&o.ClientDeviceToken,
-			&o.AppID,
-			&o.OrderTime,
-			&o.PredvDistance,
-			&o.PredvTime,
-			&o.CallWarningID,
-			&o.Phone,
-			&o.ClientID,
-			&o.BonusPayment,
-			&o.CurrencyID,
-			&o.TimeOffset,
-			&o.IsFix,
-			&o.UpdateTime,
-			&o.DenyRefuseOrder,
-			&o.PositionID,
-			&o.PromoCodeID,
-			&o.TenantCompanyID,
-			&o.Mark,
-			&o.ProcessedExchangeProgramID,
-			&o.ClientPassengerID,
-			&o.ClientPassengerPhone,
-			&o.Active,
-			&o.IsPreOrder,
-			&o.AppVersion,
-			&o.AgentCommission,
-			&o.IsFixByDispatcher,
-			&o.FinishTime,
-			&o.CommentForDispatcher,
-			&o.WorkerManualSurcharge,
-			&o.RealtimePrice,
-			&o.UnitQuantity,
-			&o.ShopID,
-			&o.RequirePrepayment,
-			&o.OrderCode,
-			&o.ClientOfferedPrice,
-			&o.IdempotentKey,
-			&o.AdditionalTariffID,
-			&o.InitialPrice,
-			&o.TimeToOrder,
-			&o.Sort,
-			&o.SummaryCost,
-			&o.SummaryCostNoDiscount,
-			&o.StatusStatusID,
-			&o.StatusName,
-			&o.WorkerWorkerID,
-			&o.WorkerCallsign,
-			&o.WorkerName,
-			&o.WorkerLastName,
-			&o.WorkerSecondName,
-			&o.WorkerPhone,
-			&o.ClientClientID,
-			&o.ClientPhone,
-			&o.ClientName,
-			&o.ClientLastName,
-			&o.ClientSecondName,
-			&o.CarCarID,
-			&o.CarName,
-			&o.CarColor,
-			&o.CarGosNumber,
-			&o.TariffTariffID,
-			&o.TariffType,
-			&o.TariffName,
-			&o.TariffQuantitativeTitle,
-			&o.TariffPriceForUnit,
-			&o.TariffUnitName,
-			&o.UserUserID,
-			&o.UserName,
-			&o.UserLastName,
-			&o.UserSecondName,
-			&o.CurrencyName,
-			&o.CurrencyCode,
-			&o.CurrencySymbol,
-		)
+		o, err := scanFullOrder(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -288,33 +80,168 @@ WHERE o.tenant_id = ?
 		return nil, err
 	}
 
-	logging.Info(ctx, "mysql getAll timings",
-		"query_ms", queryMS,
-		"scan_ms", scanMS,
-		"total_ms", totalMS,
-		"row_count", len(result),
-		"tenant_id", f.TenantID,
-		"city_count", len(f.CityIDs),
-		"shop_count", len(f.ShopIDs),
-		"date_filter", f.Date != nil && *f.Date != "",
-	)
-
 	return result, nil
 }
 
-func getAllDayRange(value *string) (int64, int64, bool) {
-	if value == nil || *value == "" {
-		return 0, 0, false
+// writeGetAllWhere writes the WHERE clause shared by the getAll count and page
+// queries from the filter spec the Redis orders are matched with, so both
+// sources keep the same orders.
+func writeGetAllWhere(sb *strings.Builder, f order.GetAllOrdersFilter) []any {
+	var args []any
+	spec := order.GetAllFilterSpec(f)
+
+	sb.WriteString(`WHERE o.tenant_id = ?
+  AND o.active = 1
+`)
+	args = append(args, f.TenantID)
+
+	args = writeInt64In(sb, " AND o.order_id IN (", spec.OrderIDs, args)
+	args = writeInt64In(sb, " AND o.order_id NOT IN (", spec.ExcludedOrderIDs, args)
+	args = writeInt64In(sb, " AND o.city_id IN (", spec.CityIDs, args)
+	args = writeInt64In(sb, " AND o.shop_id IN (", spec.ShopIDs, args)
+	args = writeInt64In(sb, " AND o.tariff_id IN (", spec.Tariffs, args)
+	args = writeInt64In(sb, " AND o.position_id IN (", spec.PositionIDs, args)
+	args = writeInt64In(sb, " AND o.status_id IN (", spec.Status, args)
+
+	if spec.OrderTime != nil {
+		sb.WriteString(" AND o.order_time BETWEEN ? AND ?\n")
+		args = append(args, spec.OrderTime.From, spec.OrderTime.To)
+	}
+	if spec.Period != nil {
+		args = writeGetAllPeriod(sb, *spec.Period, args)
 	}
 
-	for _, layout := range []string{"2006-01-02", "02.01.2006", "2006-01-02 15:04:05"} {
-		parsed, err := time.Parse(layout, *value)
-		if err == nil {
-			start := time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.Local).Unix()
-			end := time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 23, 59, 59, 0, time.Local).Unix()
-			return start, end, true
+	for _, term := range spec.Searches {
+		args = writeSearchTerm(sb, term, args)
+	}
+
+	return args
+}
+
+// getAllPeriodColumns are the wall clock values order.DatePeriod.MatchesOrder
+// checks, create and finish time are shifted by the order's time offset.
+var getAllPeriodColumns = []string{
+	"o.order_time",
+	"o.create_time + COALESCE(o.time_offset, 0)",
+	"o.finish_time + COALESCE(o.time_offset, 0)",
+}
+
+func writeGetAllPeriod(sb *strings.Builder, period order.DatePeriod, args []any) []any {
+	sb.WriteString(" AND (")
+	for i, column := range getAllPeriodColumns {
+		if i > 0 {
+			sb.WriteString(" OR ")
+		}
+		sb.WriteString("((" + column + ") BETWEEN ? AND ?")
+		args = append(args, period.From, period.To)
+		if period.HasWindow() {
+			clock := "MOD(" + column + ", 86400)"
+			if period.CrossesMidnight() {
+				sb.WriteString(" AND (" + clock + " >= ? OR " + clock + " < ?)")
+			} else {
+				sb.WriteString(" AND " + clock + " >= ? AND " + clock + " < ?")
+			}
+			args = append(args, period.WindowFrom, period.WindowTo)
+		}
+		sb.WriteString(")")
+	}
+	sb.WriteString(")\n")
+
+	return args
+}
+
+// searchColumns holds the SQL value of every order.SearchField. o.address is
+// the serialized PHP array, addressValuePattern searches its parts.
+var searchColumns = map[string]string{
+	"OrderNumber":       "CAST(o.order_number AS CHAR)",
+	"OrderCode":         "o.order_code",
+	"Address":           "LOWER(o.address)",
+	"Comment":           "o.comment",
+	"Client.LastName":   "cl.last_name",
+	"Client.Name":       "cl.name",
+	"Client.SecondName": "cl.second_name",
+	"Client.Phone":      "cl.phone",
+	"Worker.LastName":   "w.last_name",
+	"Worker.Name":       "w.name",
+	"Worker.SecondName": "w.second_name",
+	"Worker.Callsign":   "CAST(w.callsign AS CHAR)",
+	"Car.GosNumber":     "car.gos_number",
+}
+
+// writeSearchTerm matches the word against the fields of its attribute like
+// order.FilterSpec does: case-insensitive, phones without formatting.
+func writeSearchTerm(sb *strings.Builder, term order.SearchTerm, args []any) []any {
+	var conditions []string
+	for _, field := range order.SearchAttributeFields(term.Attribute) {
+		needle := field.Needle(term.Word)
+		if needle == "" {
+			continue
+		}
+		switch {
+		case field.Field == "Address":
+			conditions = append(conditions, searchColumns[field.Field]+" REGEXP ?")
+			args = append(args, addressValuePattern(needle))
+		case field.Phone:
+			conditions = append(conditions, normalizePhoneColumn(searchColumns[field.Field])+" LIKE ?")
+			args = append(args, likePattern(needle))
+		default:
+			conditions = append(conditions, "LOWER("+searchColumns[field.Field]+") LIKE ?")
+			args = append(args, likePattern(needle))
 		}
 	}
 
-	return 0, 0, false
+	if len(conditions) == 0 {
+		sb.WriteString(" AND 1 = 0\n")
+		return args
+	}
+	sb.WriteString(" AND (" + strings.Join(conditions, " OR ") + ")\n")
+	return args
+}
+
+// addressPartKeys are the keys of the address parts the legacy address
+// parser reads and order.FilterSpec joins into the searched address text.
+var addressPartKeys = []string{"city", "street", "label", "house", "apt", "parking"}
+
+// addressValuePattern matches needle within the value of an address part of
+// the serialized address, never within its keys, length prefixes or other
+// entries like city_id. String values end at their closing quote, numbers
+// at the semicolon.
+func addressValuePattern(needle string) string {
+	word := regexp.QuoteMeta(needle)
+	return `s:[0-9]+:"(` + strings.Join(addressPartKeys, "|") + `)";` +
+		`(s:[0-9]+:"[^"]*` + word + `[^"]*"|[id]:[^;]*` + word + `[^;]*;)`
+}
+
+func writeInt64In(sb *strings.Builder, prefix string, values []int64, args []any) []any {
+	if len(values) == 0 {
+		return args
+	}
+
+	sb.WriteString(prefix)
+	for i, id := range values {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("?")
+		args = append(args, id)
+	}
+	sb.WriteString(")\n")
+
+	return args
+}
+
+// likePattern wraps value into a substring LIKE pattern, escaping the
+// wildcard characters so user input is matched literally.
+func likePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(value) + "%"
+}
+
+// normalizePhoneColumn strips the characters order.SearchField drops from
+// phone numbers.
+func normalizePhoneColumn(column string) string {
+	for _, char := range []string{"+", "(", ")", "-", " "} {
+		column = "REPLACE(" + column + ", '" + char + "', '')"
+	}
+	return column
 }
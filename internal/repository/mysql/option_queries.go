@@ -39,6 +39,7 @@ func (r *OrdersRepository) GetOptionsForOrders(
 	`
 
 	totalStarted := time.Now()
+	defer observeQuery("options", totalStarted)
 	queryStarted := time.Now()
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	queryMS := time.Since(queryStarted).Milliseconds()
@@ -86,14 +87,5 @@ func (r *OrdersRepository) GetOptionsForOrders(
 		return nil, err
 	}
 
-	logging.Info(ctx, "mysql options timings",
-		"query_ms", queryMS,
-		"scan_ms", scanMS,
-		"total_ms", totalMS,
-		"order_ids_count", len(orderIDs),
-		"orders_with_options_count", len(result),
-		"row_count", rowCount,
-	)
-
 	return result, nil
 }
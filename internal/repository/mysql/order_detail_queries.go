@@ -0,0 +1,38 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"orders-service/internal/app/order"
+	"orders-service/internal/logging"
+	"time"
+)
+
+func (r *OrdersRepository) FetchOrderByID(
+	ctx context.Context,
+	tenantID, orderID int64,
+) (order.FullOrder, bool, error) {
+	query := fullOrderSelect + `WHERE o.tenant_id = ?
+  AND o.order_id = ?
+  AND o.active = 1
+LIMIT 1
+`
+
+	started := time.Now()
+	defer observeQuery("order_detail", started)
+	o, err := scanFullOrder(r.db.QueryRowContext(ctx, query, tenantID, orderID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return order.FullOrder{}, false, nil
+	}
+	if err != nil {
+		logging.Error(ctx, "mysql order detail query failed", err,
+			"duration_ms", time.Since(started).Milliseconds(),
+			"tenant_id", tenantID,
+			"order_id", orderID,
+		)
+		return order.FullOrder{}, false, err
+	}
+
+	return o, true, nil
+}
@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"orders-service/internal/app/order"
+	"orders-service/internal/logging"
+	"time"
+)
+
+// orderChangeSelect reads the changes of one order oldest first. Changes of
+// the same second are ordered by field and value, so pages never overlap.
+const orderChangeSelect = `
+SELECT c.change_field, c.change_val, c.change_time, COALESCE(s.name, '')
+FROM tbl_order_change_data c
+LEFT JOIN tbl_order_status s
+	ON c.change_field = 'status_id'
+	AND s.status_id = c.change_val
+WHERE c.order_id = ?
+`
+
+const orderChangeOrderBy = `ORDER BY c.change_time, c.change_field, c.change_val
+`
+
+// FetchOrderHistoryHead checks the order belongs to the tenant, which
+// tbl_order_change_data does not record, and counts its changes.
+func (r *OrdersRepository) FetchOrderHistoryHead(
+	ctx context.Context,
+	tenantID, orderID int64,
+) (order.OrderHistoryHead, bool, error) {
+	query := `
+SELECT o.city_id, o.position_id, o.status_id, COUNT(c.order_id)
+FROM tbl_order o
+LEFT JOIN tbl_order_change_data c ON c.order_id = o.order_id
+WHERE o.tenant_id = ?
+  AND o.order_id = ?
+  AND o.active = 1
+GROUP BY o.order_id, o.city_id, o.position_id, o.status_id
+`
+
+	started := time.Now()
+	defer observeQuery("order_history_head", started)
+	var head order.OrderHistoryHead
+	err := r.db.QueryRowContext(ctx, query, tenantID, orderID).
+		Scan(&head.CityID, &head.PositionID, &head.StatusID, &head.ChangeCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return order.OrderHistoryHead{}, false, nil
+	}
+	if err != nil {
+		logging.Error(ctx, "mysql order history head query failed", err,
+			"duration_ms", time.Since(started).Milliseconds(),
+			"tenant_id", tenantID,
+			"order_id", orderID,
+		)
+		return order.OrderHistoryHead{}, false, err
+	}
+
+	return head, true, nil
+}
+
+func (r *OrdersRepository) FetchOrderChanges(
+	ctx context.Context,
+	orderID int64,
+	offset, limit int,
+) ([]order.OrderChange, error) {
+	query := orderChangeSelect + orderChangeOrderBy + `LIMIT ? OFFSET ?
+`
+
+	defer observeQuery("order_history_changes", time.Now())
+	return r.queryOrderChanges(ctx, query, orderID, limit, offset)
+}
+
+// FetchOrderStatusChanges reads every status change of the order, the status
+// durations need the next status even when it is on a later page.
+func (r *OrdersRepository) FetchOrderStatusChanges(
+	ctx context.Context,
+	orderID int64,
+) ([]order.OrderChange, error) {
+	query := orderChangeSelect + `  AND c.change_field = 'status_id'
+` + orderChangeOrderBy
+
+	defer observeQuery("order_history_status_changes", time.Now())
+	return r.queryOrderChanges(ctx, query, orderID)
+}
+
+func (r *OrdersRepository) queryOrderChanges(
+	ctx context.Context,
+	query string,
+	args ...any,
+) ([]order.OrderChange, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []order.OrderChange
+	for rows.Next() {
+		var change order.OrderChange
+		if err := rows.Scan(&change.Field, &change.Value, &change.ChangeTime, &change.StatusName); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
@@ -6,16 +6,12 @@ import (
 	"fmt"
 	"orders-service/internal/app/order"
 	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
+	"slices"
 	"strings"
 	"time"
 )
 
-var allowedSortFields = map[string]string{
-	"o.status_time": "o.status_time",
-	"o.order_id":    "o.order_id",
-	"o.order_time":  "o.order_time",
-}
-
 type OrdersRepository struct {
 	db *sql.DB
 }
@@ -96,20 +92,109 @@ func (r *OrdersRepository) buildBaseQuery(sb *strings.Builder, args *[]any, f or
 	}
 }
 
+// appendOrderBy sorts by the requested keys and breaks ties by order_id, so
+// the order is stable enough for keyset pagination.
 func (r *OrdersRepository) appendOrderBy(sb *strings.Builder, f order.BaseFilter) {
+	writeOrderBy(sb, nil, order.ListSort(f), nil)
+}
+
+// sortColumns maps the sort fields of the order package to SQL. Joined values
+// are read with subqueries, so the id queries on tbl_order alone can use them
+// too. NULL sorts first like a missing value in order.OrdersLess.
+var sortColumns = map[string]string{
+	order.SortFieldStatusTime:     "o.status_time",
+	order.SortFieldOrderID:        "o.order_id",
+	order.SortFieldOrderTime:      "o.order_time",
+	order.SortFieldSummaryCost:    "(SELECT CAST(sc.summary_cost AS DECIMAL(20,4)) FROM tbl_order_detail_cost sc WHERE sc.order_id = o.order_id LIMIT 1)",
+	order.SortFieldTariffID:       "o.tariff_id",
+	order.SortFieldCityID:         "o.city_id",
+	order.SortFieldWorkerCallsign: "(SELECT sw.callsign FROM tbl_worker sw WHERE sw.worker_id = o.worker_id LIMIT 1)",
+	order.SortFieldStatusID:       "o.status_id",
+}
+
+// writeOrderBy writes the ORDER BY of keys. Wait times only live in the
+// active orders, a wait time key sorts by waitTimes and is skipped without
+// any.
+func writeOrderBy(sb *strings.Builder, args *[]any, keys []order.SortKey, waitTimes map[int64]int64) {
 	sb.WriteString("ORDER BY ")
-	sb.WriteString(normalizeSortField(f.SortField))
-	sb.WriteString(" ")
-	sb.WriteString(normalizeSortDirection(f.SortOrder))
+	written := 0
+	for _, key := range keys {
+		column, ok := sortColumns[key.Field]
+		if key.Field == order.SortFieldWaitTime && len(waitTimes) > 0 {
+			column, ok = waitTimeColumn(args, waitTimes), true
+		}
+		if !ok {
+			continue
+		}
+		if written > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(column)
+		sb.WriteString(" ")
+		sb.WriteString(sortDirection(key.Desc))
+		written++
+	}
+	if !order.HasSortField(keys, order.SortFieldOrderID) {
+		if written > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("o.order_id ")
+		sb.WriteString(sortDirection(order.TieBreakDesc(keys)))
+	}
 	sb.WriteString("\n")
 }
 
-func normalizeSortField(value string) string {
-	if normalized, ok := allowedSortFields[value]; ok {
-		return normalized
+// waitTimeColumn looks the wait time of an order up in waitTimes where
+// order.WorkerWaitingTime applies, a worker waiting for the client.
+func waitTimeColumn(args *[]any, waitTimes map[int64]int64) string {
+	orderIDs := make([]int64, 0, len(waitTimes))
+	for orderID := range waitTimes {
+		orderIDs = append(orderIDs, orderID)
+	}
+	slices.Sort(orderIDs)
+
+	var sb strings.Builder
+	sb.WriteString("(CASE WHEN o.status_id = 26 AND o.worker_id IS NOT NULL THEN CASE o.order_id")
+	for _, orderID := range orderIDs {
+		sb.WriteString(" WHEN ? THEN ?")
+		*args = append(*args, orderID, waitTimes[orderID])
+	}
+	sb.WriteString(" ELSE 0 END ELSE 0 END)")
+	return sb.String()
+}
+
+func sortDirection(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// appendCursorCondition limits the rows to the ones after the cursor in the
+// (field, order_id) order. The field comes from a validated cursor.
+func appendCursorCondition(sb *strings.Builder, args *[]any, c *order.PageCursor) {
+	if c == nil {
+		return
+	}
+
+	op := ">"
+	if c.Desc {
+		op = "<"
+	}
+	field := order.NormalizeSortField(c.SortField)
+
+	if field == "o.order_id" {
+		sb.WriteString(" AND o.order_id " + op + " ?\n")
+		*args = append(*args, c.OrderID)
+		return
 	}
 
-	return "o.status_time"
+	sb.WriteString(" AND (" + field + " " + op + " ? OR (" + field + " = ? AND o.order_id " + op + " ?))\n")
+	*args = append(*args, c.Value, c.Value, c.OrderID)
+}
+
+func normalizeSortField(value string) string {
+	return order.NormalizeSortField(value)
 }
 
 func normalizeSortDirection(value string) string {
@@ -122,28 +207,53 @@ func normalizeSortDirection(value string) string {
 
 func (r *OrdersRepository) executeQuery(
 	ctx context.Context,
-	sql string,
+	kind string,
+	query string,
 	args []any,
 ) ([]int64, error) {
+	var ids []int64
+	err := r.executeScan(ctx, kind, query, args, func(rows *sql.Rows) error {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
 
+// executeScan runs the query and calls scan for every row. kind labels the
+// query duration metric.
+func (r *OrdersRepository) executeScan(
+	ctx context.Context,
+	kind string,
+	query string,
+	args []any,
+	scan func(rows *sql.Rows) error,
+) error {
 	totalStarted := time.Now()
+	defer observeQuery(kind, totalStarted)
 	queryStarted := time.Now()
-	rows, err := r.db.QueryContext(ctx, sql, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	queryMS := time.Since(queryStarted).Milliseconds()
 	if err != nil {
 		logging.Error(ctx, "mysql id query failed", err, "query_ms", queryMS)
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
 	scanStarted := time.Now()
-	var ids []int64
+	rowCount := 0
 	for rows.Next() {
-		var id int64
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
+		if err := scan(rows); err != nil {
+			return err
 		}
-		ids = append(ids, id)
+		rowCount++
 	}
 	scanMS := time.Since(scanStarted).Milliseconds()
 	totalMS := time.Since(totalStarted).Milliseconds()
@@ -153,19 +263,17 @@ func (r *OrdersRepository) executeQuery(
 			"query_ms", queryMS,
 			"scan_ms", scanMS,
 			"total_ms", totalMS,
-			"row_count", len(ids),
+			"row_count", rowCount,
 		)
-		return nil, err
+		return err
 	}
 
-	logging.Info(ctx, "mysql id query timings",
-		"query_ms", queryMS,
-		"scan_ms", scanMS,
-		"total_ms", totalMS,
-		"row_count", len(ids),
-	)
+	return nil
+}
 
-	return ids, nil
+// observeQuery records the duration of a query, row scan included.
+func observeQuery(kind string, started time.Time) {
+	metrics.MySQLQueryDuration.ObserveDuration(started, kind)
 }
 
 func formatArg(a any) string {
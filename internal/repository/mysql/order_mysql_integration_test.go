@@ -83,7 +83,7 @@ func TestOrdersRepository_FetchBadReview(t *testing.T) {
 	})
 
 	require.NoError(t, err)
-	require.Equal(t, []int64{2004, 2005}, got)
+	require.Equal(t, []int64{2004, 2005}, badReviewOrderIDs(got))
 }
 
 func TestOrdersRepository_FetchExceededPrice(t *testing.T) {
@@ -103,7 +103,7 @@ func TestOrdersRepository_FetchExceededPrice(t *testing.T) {
 	})
 
 	require.NoError(t, err)
-	require.Equal(t, []int64{2001, 2004, 2011}, got)
+	require.Equal(t, []int64{2001, 2004, 2011}, exceededPriceOrderIDs(got))
 }
 
 func TestOrdersRepository_CountOrdersWithWarning_UsesBaseFilter(t *testing.T) {
@@ -603,3 +603,19 @@ func baseWarningFilter() order.BaseFilter {
 		SortOrder:      "asc",
 	}
 }
+
+func badReviewOrderIDs(rows []order.BadReviewWarning) []int64 {
+	ids := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.OrderID)
+	}
+	return ids
+}
+
+func exceededPriceOrderIDs(rows []order.ExceededPriceWarning) []int64 {
+	ids := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.OrderID)
+	}
+	return ids
+}
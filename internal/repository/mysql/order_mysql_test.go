@@ -1,11 +1,16 @@
 package mysql
 
 import (
+	"context"
+	"database/sql"
+	"regexp"
 	"strings"
 	"testing"
 
 	"orders-service/internal/app/order"
+	"orders-service/internal/legacy/address"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,5 +37,263 @@ func TestOrdersRepository_AppendOrderBySanitizesInput(t *testing.T) {
 		SortOrder: "asc; DROP TABLE tbl_order;",
 	})
 
-	require.Equal(t, "ORDER BY o.status_time DESC\n", sb.String())
+	require.Equal(t, "ORDER BY o.status_time DESC, o.order_id DESC\n", sb.String())
+}
+
+func TestWriteGetAllWhere_PushesFiltersIntoSQL(t *testing.T) {
+	var sb strings.Builder
+	date := "2026-04-28"
+
+	args := writeGetAllWhere(&sb, order.GetAllOrdersFilter{
+		BaseFilter: order.BaseFilter{
+			TenantID: 68,
+			Tariffs:  []int64{3},
+			Status:   []int64{37, 38},
+			Date:     &date,
+		},
+		SearchStatus: "completed",
+		Attributes: []order.SearchAttribute{
+			{Attribute: "comment", SearchString: "50% off"},
+		},
+	})
+
+	query := sb.String()
+	require.Contains(t, query, " AND o.tariff_id IN (?)")
+	require.Contains(t, query, " AND o.status_id IN (?,?)")
+	require.Contains(t, query, " AND o.order_time BETWEEN ? AND ?")
+	require.Equal(t, 2, strings.Count(query, "LOWER(o.comment) LIKE ?"))
+	require.Equal(t, int64(68), args[0])
+	require.Contains(t, args, `%50\%%`)
+	require.Contains(t, args, "%off%")
+	require.Contains(t, args, int64(1777334400))
+	require.Contains(t, args, int64(1777420799))
+}
+
+func TestWriteGetAllWhere_SearchesLikeTheFilterSpec(t *testing.T) {
+	for _, attribute := range []string{"number", "address", "comment", "client", "worker"} {
+		for _, field := range order.SearchAttributeFields(attribute) {
+			require.Contains(t, searchColumns, field.Field)
+		}
+	}
+
+	var sb strings.Builder
+	args := writeGetAllWhere(&sb, order.GetAllOrdersFilter{
+		BaseFilter:   order.BaseFilter{TenantID: 68},
+		ShopIDs:      []int64{5},
+		Attributes:   []order.SearchAttribute{{Attribute: "unknown", SearchString: "x"}},
+		SearchString: map[string]string{"client": "+7(999)", "worker": "-"},
+	})
+
+	query := sb.String()
+	require.Contains(t, query, " AND o.shop_id IN (?)")
+	require.Contains(t, query, "REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(cl.phone, '+', ''), '(', ''), ')', ''), '-', ''), ' ', '') LIKE ?")
+	require.Contains(t, query, "LOWER(CAST(w.callsign AS CHAR)) LIKE ?")
+	require.NotContains(t, query, "unknown")
+	require.Equal(t, []any{int64(68), int64(5), "%+7(999)%", "%+7(999)%", "%+7(999)%", "%7999%"}, args[:6])
+}
+
+func TestWriteGetAllWhere_ExcludesOrdersKeptFromRedis(t *testing.T) {
+	var sb strings.Builder
+
+	args := writeGetAllWhere(&sb, order.GetAllOrdersFilter{
+		BaseFilter:       order.BaseFilter{TenantID: 68},
+		ExcludedOrderIDs: []int64{10, 11},
+	})
+
+	require.Contains(t, sb.String(), " AND o.order_id NOT IN (?,?)")
+	require.Equal(t, []any{int64(68), int64(10), int64(11)}, args)
+}
+
+func TestWriteGetAllWhere_LimitsPositions(t *testing.T) {
+	var sb strings.Builder
+
+	args := writeGetAllWhere(&sb, order.GetAllOrdersFilter{
+		BaseFilter: order.BaseFilter{TenantID: 68, UserPositions: []int64{1, 2}},
+	})
+
+	require.Contains(t, sb.String(), " AND o.position_id IN (?,?)")
+	require.Equal(t, []any{int64(68), int64(1), int64(2)}, args)
+}
+
+func TestWriteGetAllWhere_SearchesAddressPartsLikeRedis(t *testing.T) {
+	raw := `a:2:{` +
+		`i:1;a:4:{s:7:"city_id";s:5:"26068";s:4:"city";s:7:"Izhevsk";s:6:"street";s:13:"Lenina street";s:5:"house";i:12;}` +
+		`i:2;a:2:{s:4:"city";s:6:"Moscow";s:5:"label";s:11:"Airport (B)";}` +
+		`}`
+	addresses, err := address.NewParser().ParseAddress(raw)
+	require.NoError(t, err)
+	active := order.FormattedOrder{Address: addresses}
+
+	for _, word := range []string{"Lenina", "street", "izh", "12", "(b)", "Moscow", "26068", "city", "house", "s:6", "a:2"} {
+		f := order.GetAllOrdersFilter{
+			BaseFilter: order.BaseFilter{TenantID: 68},
+			Attributes: []order.SearchAttribute{{Attribute: "address", SearchString: word}},
+		}
+		var sb strings.Builder
+		args := writeGetAllWhere(&sb, f)
+		require.Contains(t, sb.String(), "LOWER(o.address) REGEXP ?")
+
+		pattern := regexp.MustCompile(args[len(args)-1].(string))
+		require.Equal(t, order.GetAllFilterSpec(f).Matches(active), pattern.MatchString(strings.ToLower(raw)), word)
+	}
+}
+
+func TestAppendCursorCondition_UsesOrderIDTieBreaker(t *testing.T) {
+	var sb strings.Builder
+	var args []any
+
+	appendCursorCondition(&sb, &args, &order.PageCursor{
+		SortField: "o.status_time",
+		Desc:      true,
+		Value:     1777409100,
+		OrderID:   42,
+	})
+
+	require.Equal(t, " AND (o.status_time < ? OR (o.status_time = ? AND o.order_id < ?))\n", sb.String())
+	require.Equal(t, []any{int64(1777409100), int64(1777409100), int64(42)}, args)
+
+	sb.Reset()
+	args = nil
+	appendCursorCondition(&sb, &args, &order.PageCursor{SortField: "o.order_id", OrderID: 42})
+
+	require.Equal(t, " AND o.order_id > ?\n", sb.String())
+	require.Equal(t, []any{int64(42)}, args)
+}
+
+func TestWriteGetAllWhere_PeriodChecksEveryOrderTime(t *testing.T) {
+	var sb strings.Builder
+
+	args := writeGetAllWhere(&sb, order.GetAllOrdersFilter{
+		BaseFilter: order.BaseFilter{TenantID: 68},
+		Period: &order.DatePeriod{
+			From:       1775001600,
+			To:         1776297599,
+			WindowFrom: 20 * 3600,
+			WindowTo:   8 * 3600,
+		},
+	})
+
+	query := sb.String()
+	require.Contains(t, query, "((o.order_time) BETWEEN ? AND ? AND (MOD(o.order_time, 86400) >= ? OR MOD(o.order_time, 86400) < ?))")
+	require.Contains(t, query, "((o.create_time + COALESCE(o.time_offset, 0)) BETWEEN ? AND ?")
+	require.Contains(t, query, "((o.finish_time + COALESCE(o.time_offset, 0)) BETWEEN ? AND ?")
+	require.Len(t, args, 1+3*4)
+	require.Equal(t, []any{int64(1775001600), int64(1776297599), int64(72000), int64(28800)}, args[1:5])
+}
+
+func TestWriteOrderBy_WritesEveryKeyAndOrderIDTieBreaker(t *testing.T) {
+	var sb strings.Builder
+
+	writeOrderBy(&sb, nil, []order.SortKey{
+		{Field: order.SortFieldStatusID},
+		{Field: order.SortFieldOrderTime, Desc: true},
+	}, nil)
+	require.Equal(t, "ORDER BY o.status_id ASC, o.order_time DESC, o.order_id ASC\n", sb.String())
+
+	sb.Reset()
+	writeOrderBy(&sb, nil, []order.SortKey{{Field: order.SortFieldTariffID, Desc: true}, {Field: order.SortFieldOrderID}}, nil)
+	require.Equal(t, "ORDER BY o.tariff_id DESC, o.order_id ASC\n", sb.String())
+
+	for _, field := range order.SortFields() {
+		if field == order.SortFieldWaitTime {
+			continue
+		}
+		require.Contains(t, sortColumns, field)
+	}
+}
+
+func TestWriteOrderBy_SortsByActiveWaitTimes(t *testing.T) {
+	keys := []order.SortKey{{Field: order.SortFieldWaitTime, Desc: true}}
+
+	var sb strings.Builder
+	var args []any
+	writeOrderBy(&sb, &args, keys, map[int64]int64{12: 300, 11: 600})
+	require.Equal(t, "ORDER BY (CASE WHEN o.status_id = 26 AND o.worker_id IS NOT NULL THEN CASE o.order_id"+
+		" WHEN ? THEN ? WHEN ? THEN ? ELSE 0 END ELSE 0 END) DESC, o.order_id DESC\n", sb.String())
+	require.Equal(t, []any{int64(11), int64(600), int64(12), int64(300)}, args)
+
+	sb.Reset()
+	args = nil
+	writeOrderBy(&sb, &args, keys, nil)
+	require.Equal(t, "ORDER BY o.order_id DESC\n", sb.String())
+	require.Empty(t, args)
+}
+
+func TestFetchOrderHistoryHead_ChecksTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo, err := NewOrdersRepository(db)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`FROM tbl_order o\s+LEFT JOIN tbl_order_change_data c .*WHERE o.tenant_id = \?\s+AND o.order_id = \?`).
+		WithArgs(int64(70), int64(12)).
+		WillReturnError(sql.ErrNoRows)
+
+	_, found, err := repo.FetchOrderHistoryHead(context.Background(), 70, 12)
+
+	require.NoError(t, err)
+	require.False(t, found)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFetchOrderChanges_PagesOldestFirstWithStatusNames(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo, err := NewOrdersRepository(db)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`LEFT JOIN tbl_order_status s\s+ON c.change_field = 'status_id'.*ORDER BY c.change_time, c.change_field, c.change_val\s+LIMIT \? OFFSET \?`).
+		WithArgs(int64(12), 50, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"change_field", "change_val", "change_time", "name"}).
+			AddRow("status_id", 17, 1711065700, "Executing").
+			AddRow("worker_id", 77, 1711065800, ""))
+
+	got, err := repo.FetchOrderChanges(context.Background(), 12, 100, 50)
+
+	require.NoError(t, err)
+	require.Equal(t, []order.OrderChange{
+		{Field: "status_id", Value: "17", ChangeTime: 1711065700, StatusName: "Executing"},
+		{Field: "worker_id", Value: "77", ChangeTime: 1711065800},
+	}, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAggregateOrders_GroupsByKeyAndStatusWithGetAllFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo, err := NewOrdersRepository(db)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`SELECT FLOOR\(MOD\(o.order_time, 86400\) / 3600\) AS group_key, o.status_id, COUNT\(\*\), `+
+		`COALESCE\(SUM\(CAST\(d.summary_cost AS DECIMAL\(20,4\)\)\), 0\).* `+
+		`FROM tbl_order o .*LEFT JOIN tbl_order_detail_cost d .*`+
+		`WHERE o.tenant_id = \? AND o.active = 1 AND o.city_id IN \(\?\) `+
+		`GROUP BY group_key, o.status_id`).
+		WithArgs(int64(68), int64(26068)).
+		WillReturnRows(sqlmock.NewRows([]string{"group_key", "status_id", "count", "cost", "distance", "distance_count", "time", "time_count"}).
+			AddRow("20", 37, 3, "450.5000", 12.5, 3, 1800, 3))
+
+	got, err := repo.AggregateOrders(context.Background(), order.OrderStatsFilter{
+		GetAllOrdersFilter: order.GetAllOrdersFilter{BaseFilter: order.BaseFilter{TenantID: 68, CityIDs: []int64{26068}}},
+		GroupBy:            order.StatsGroupHour,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []order.OrderStatsRow{{
+		Key:           "20",
+		StatusID:      37,
+		Count:         3,
+		SummaryCost:   450.5,
+		DistanceSum:   12.5,
+		DistanceCount: 3,
+		TimeSum:       1800,
+		TimeCount:     3,
+	}}, got)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"orders-service/internal/app/order"
+	"orders-service/internal/logging"
+	"strings"
+	"time"
+)
+
+// statsGroupColumns are the group keys of the order statistics. The category
+// grouping groups by status, the order package folds statuses into the
+// categories of the tenant. The hour is the one of the city wall clock
+// order_time is stored in.
+var statsGroupColumns = map[string]string{
+	order.StatsGroupCategory:   "o.status_id",
+	order.StatsGroupTariff:     "o.tariff_id",
+	order.StatsGroupCity:       "o.city_id",
+	order.StatsGroupDevice:     "COALESCE(o.device, '')",
+	order.StatsGroupHour:       "FLOOR(MOD(o.order_time, 86400) / 3600)",
+	order.StatsGroupDispatcher: "COALESCE(o.user_create, 0)",
+}
+
+// AggregateOrders aggregates the rows of the all-orders search by group key
+// and status. The WHERE clause is the one of CountAllOrdersForGetAll, so the
+// counts add up to its total.
+func (r *OrdersRepository) AggregateOrders(
+	ctx context.Context,
+	f order.OrderStatsFilter,
+) ([]order.OrderStatsRow, error) {
+	column, ok := statsGroupColumns[f.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown stats group %q", f.GroupBy)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`SELECT
+    ` + column + ` AS group_key,
+    o.status_id,
+    COUNT(*),
+    COALESCE(SUM(CAST(d.summary_cost AS DECIMAL(20,4))), 0),
+    COALESCE(SUM(o.predv_distance), 0),
+    COUNT(o.predv_distance),
+    COALESCE(SUM(o.predv_time), 0),
+    COUNT(o.predv_time)
+`)
+	sb.WriteString(fullOrderFrom)
+	args := writeGetAllWhere(&sb, f.GetAllOrdersFilter)
+	sb.WriteString("GROUP BY group_key, o.status_id\n")
+
+	started := time.Now()
+	defer observeQuery("order_stats", started)
+	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		logging.Error(ctx, "mysql order stats query failed", err,
+			"query_ms", time.Since(started).Milliseconds(),
+			"tenant_id", f.TenantID,
+			"group_by", f.GroupBy,
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []order.OrderStatsRow
+	for rows.Next() {
+		var (
+			row order.OrderStatsRow
+			key sql.NullString
+		)
+		if err := rows.Scan(
+			&key,
+			&row.StatusID,
+			&row.Count,
+			&row.SummaryCost,
+			&row.DistanceSum,
+			&row.DistanceCount,
+			&row.TimeSum,
+			&row.TimeCount,
+		); err != nil {
+			return nil, err
+		}
+		row.Key = key.String
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
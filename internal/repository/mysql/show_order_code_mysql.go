@@ -86,6 +86,7 @@ WHERE tenant_id = ?
 
 	query += " LIMIT 1"
 
+	defer observeQuery("show_order_code", time.Now())
 	var value string
 	err := p.db.QueryRowContext(ctx, query, args...).Scan(&value)
 	if err == sql.ErrNoRows {
@@ -108,6 +109,7 @@ WHERE name = ?
 LIMIT 1
 `
 
+	defer observeQuery("show_order_code_default", time.Now())
 	var value string
 	err := p.db.QueryRowContext(ctx, query, settingShowOrderCode).Scan(&value)
 	if err == sql.ErrNoRows {
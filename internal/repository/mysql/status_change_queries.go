@@ -4,6 +4,7 @@ import (
 	"context"
 	"orders-service/internal/app/order"
 	"strings"
+	"time"
 )
 
 func (r *OrdersRepository) GetStatusChangeTimes(
@@ -31,6 +32,7 @@ func (r *OrdersRepository) GetStatusChangeTimes(
 		  AND (order_id, change_val) IN (` + strings.Join(values, ",") + `)
 	`
 
+	defer observeQuery("status_changes", time.Now())
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
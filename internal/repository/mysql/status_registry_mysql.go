@@ -0,0 +1,99 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"orders-service/internal/app/order"
+	"strconv"
+)
+
+// StatusRegistryLoader reads the status registry from tbl_order_status_group.
+// Rows with tenant_id = 0 form the default registry, rows of a tenant replace
+// the default categories of the same name for that tenant.
+type StatusRegistryLoader struct {
+	db *sql.DB
+}
+
+func NewStatusRegistryLoader(db *sql.DB) *StatusRegistryLoader {
+	return &StatusRegistryLoader{db: db}
+}
+
+func (l *StatusRegistryLoader) Load(ctx context.Context) (*order.StatusRegistry, error) {
+	cfg, err := l.LoadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return order.NewStatusRegistry(cfg)
+}
+
+func (l *StatusRegistryLoader) LoadConfig(ctx context.Context) (order.StatusRegistryConfig, error) {
+	const query = `
+SELECT tenant_id, category, status_id, is_red
+FROM tbl_order_status_group
+ORDER BY tenant_id, sort, category, status_id
+`
+
+	rows, err := l.db.QueryContext(ctx, query)
+	if err != nil {
+		return order.StatusRegistryConfig{}, err
+	}
+	defer rows.Close()
+
+	cfg := order.StatusRegistryConfig{
+		Tenants: map[string]order.StatusOverrideConfig{},
+	}
+	for rows.Next() {
+		var (
+			tenantID int64
+			category string
+			statusID int64
+			isRed    bool
+		)
+		if err := rows.Scan(&tenantID, &category, &statusID, &isRed); err != nil {
+			return order.StatusRegistryConfig{}, err
+		}
+
+		if tenantID == 0 {
+			cfg.Categories = appendStatusToCategory(cfg.Categories, category, statusID)
+			if isRed {
+				cfg.RedStatuses = append(cfg.RedStatuses, statusID)
+			}
+			continue
+		}
+
+		key := strconv.FormatInt(tenantID, 10)
+		override := cfg.Tenants[key]
+		override.Categories = appendStatusToCategory(override.Categories, category, statusID)
+		// A tenant replaces the default red statuses only by marking its own,
+		// regrouping a status keeps the defaults.
+		if isRed {
+			override.RedStatuses = append(override.RedStatuses, statusID)
+		}
+		cfg.Tenants[key] = override
+	}
+
+	if err := rows.Err(); err != nil {
+		return order.StatusRegistryConfig{}, err
+	}
+
+	return cfg, nil
+}
+
+func appendStatusToCategory(
+	categories []order.StatusCategoryConfig,
+	name string,
+	statusID int64,
+) []order.StatusCategoryConfig {
+	for i := range categories {
+		if categories[i].Name == name {
+			categories[i].Statuses = append(categories[i].Statuses, statusID)
+			return categories
+		}
+	}
+
+	return append(categories, order.StatusCategoryConfig{
+		Name:     name,
+		Statuses: []int64{statusID},
+	})
+}
@@ -0,0 +1,59 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"orders-service/internal/app/order"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusRegistryLoader_BuildsDefaultAndTenantOverrides(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"tenant_id", "category", "status_id", "is_red"})
+	for _, category := range []string{"new", "works", "warning", "pre_order", "completed", "rejected"} {
+		rows.AddRow(0, category, int64(len(category)), category == "warning")
+	}
+	rows.AddRow(68, "works", 200, true)
+
+	mock.ExpectQuery("FROM tbl_order_status_group").WillReturnRows(rows)
+
+	registry, err := NewStatusRegistryLoader(db).Load(context.Background())
+
+	require.NoError(t, err)
+	require.Equal(t, "works", registry.Category(68, 200))
+	require.Equal(t, "", registry.Category(1, 200))
+	require.Equal(t, "#cc1919", registry.Color(68, 200))
+	require.Equal(t, "#088142", registry.Color(68, 7))
+	require.Equal(t, "#cc1919", registry.Color(1, 7))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStatusRegistryLoader_RegroupingKeepsDefaultRedStatuses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"tenant_id", "category", "status_id", "is_red"})
+	for _, category := range []string{"new", "works", "warning", "pre_order", "completed", "rejected"} {
+		rows.AddRow(0, category, int64(len(category)), category == "warning")
+	}
+	rows.AddRow(68, "works", 200, false)
+
+	mock.ExpectQuery("FROM tbl_order_status_group").WillReturnRows(rows)
+
+	cfg, err := NewStatusRegistryLoader(db).LoadConfig(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, cfg.Tenants["68"].RedStatuses)
+
+	registry, err := order.NewStatusRegistry(cfg)
+	require.NoError(t, err)
+	require.Equal(t, "works", registry.Category(68, 200))
+	require.Equal(t, "#cc1919", registry.Color(68, 7))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
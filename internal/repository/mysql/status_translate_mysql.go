@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"strings"
 	"sync"
+	"time"
 )
 
 type StatusTranslator struct {
@@ -57,6 +58,7 @@ WHERE sm.category = 'order_status'
 LIMIT 1
 `
 
+	defer observeQuery("status_translation", time.Now())
 	var translated string
 	err := t.db.QueryRowContext(ctx, query, language, name).Scan(&translated)
 	if err == sql.ErrNoRows {
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 	"orders-service/internal/app/order"
 	"orders-service/internal/logging"
 	"strings"
@@ -28,18 +29,18 @@ WHERE ( 1=1
 
 	r.appendOrderBy(&sb, f.BaseFilter)
 
-	return r.executeQuery(ctx, sb.String(), args)
+	return r.executeQuery(ctx, "warning_unpaid", sb.String(), args)
 }
 
 func (r *OrdersRepository) FetchBadReview(
 	ctx context.Context,
 	f order.BadReviewFilter,
-) ([]int64, error) {
+) ([]order.BadReviewWarning, error) {
 	var sb strings.Builder
 	args := []any{}
 
 	sb.WriteString(`
-SELECT o.order_id
+SELECT o.order_id, cr.rating
 FROM tbl_order o
 LEFT JOIN tbl_client_review cr ON o.order_id = cr.order_id
 WHERE ( 1=1
@@ -52,18 +53,31 @@ WHERE ( 1=1
 
 	r.appendOrderBy(&sb, f.BaseFilter)
 
-	return r.executeQuery(ctx, sb.String(), args)
+	var result []order.BadReviewWarning
+	err := r.executeScan(ctx, "warning_bad_review", sb.String(), args, func(rows *sql.Rows) error {
+		var row order.BadReviewWarning
+		if err := rows.Scan(&row.OrderID, &row.Rating); err != nil {
+			return err
+		}
+		result = append(result, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 func (r *OrdersRepository) FetchExceededPrice(
 	ctx context.Context,
 	f order.ExceededPriceFilter,
-) ([]int64, error) {
+) ([]order.ExceededPriceWarning, error) {
 	var sb strings.Builder
 	var args []any
 
 	sb.WriteString(`
-SELECT o.order_id
+SELECT o.order_id, o.realtime_price
 FROM tbl_order o
 WHERE ( 1=1
 `)
@@ -87,7 +101,79 @@ WHERE ( 1=1
 
 	r.appendOrderBy(&sb, f.BaseFilter)
 
-	return r.executeQuery(ctx, sb.String(), args)
+	var result []order.ExceededPriceWarning
+	err := r.executeScan(ctx, "warning_exceeded_price", sb.String(), args, func(rows *sql.Rows) error {
+		var row order.ExceededPriceWarning
+		if err := rows.Scan(&row.OrderID, &row.RealtimePrice); err != nil {
+			return err
+		}
+		result = append(result, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FetchWarningCandidates returns the orders a time based warning rule checks.
+// The time comparison itself stays in the rule so it can use the tenant
+// thresholds and a single "now".
+func (r *OrdersRepository) FetchWarningCandidates(
+	ctx context.Context,
+	f order.WarningCandidateFilter,
+) ([]order.WarningCandidate, error) {
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString(`
+SELECT o.order_id, o.status_id, o.status_time, o.create_time, o.order_time, o.time_offset, o.time_to_client
+FROM tbl_order o
+WHERE ( 1=1
+`)
+
+	r.buildBaseQuery(&sb, &args, f.BaseFilter, true)
+	sb.WriteString(") ")
+	args = writeInt64In(&sb, "  AND o.status_id IN (", f.StatusIDs, args)
+	if f.WithoutWorker {
+		sb.WriteString("  AND o.worker_id IS NULL\n")
+	}
+
+	r.appendOrderBy(&sb, f.BaseFilter)
+
+	var result []order.WarningCandidate
+	err := r.executeScan(ctx, "warning_candidates", sb.String(), args, func(rows *sql.Rows) error {
+		var (
+			row          order.WarningCandidate
+			createTime   sql.NullInt64
+			orderTime    sql.NullInt64
+			timeOffset   sql.NullInt64
+			timeToClient sql.NullInt64
+		)
+		if err := rows.Scan(
+			&row.OrderID,
+			&row.StatusID,
+			&row.StatusTime,
+			&createTime,
+			&orderTime,
+			&timeOffset,
+			&timeToClient,
+		); err != nil {
+			return err
+		}
+		row.CreateTime = createTime.Int64
+		row.OrderTime = orderTime.Int64
+		row.TimeOffset = timeOffset.Int64
+		row.TimeToClient = timeToClient.Int64
+		result = append(result, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 func (r *OrdersRepository) CountOrdersWithWarning(
@@ -119,6 +205,7 @@ WHERE ( 1=1
 	}
 
 	started := time.Now()
+	defer observeQuery("orders_by_group_count", started)
 	row := r.db.QueryRowContext(ctx, sb.String(), args...)
 
 	var cnt int64
@@ -131,12 +218,5 @@ WHERE ( 1=1
 		return 0, err
 	}
 
-	logging.Info(ctx, "mysql refresh count timings",
-		"duration_ms", time.Since(started).Milliseconds(),
-		"count", cnt,
-		"group", f.Group,
-		"warning_ids_count", len(warningIDs),
-	)
-
 	return cnt, nil
 }
@@ -0,0 +1,130 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"orders-service/internal/app/order"
+	"sync"
+	"time"
+)
+
+// settingWarningRules holds a JSON object keyed by rule code, e.g.
+// {"driver_late": {"enabled": true, "threshold_minutes": 7}}.
+const settingWarningRules = "ORDER_WARNING_RULES"
+const warningSettingsCacheTTL = 30 * time.Second
+
+type warningSettingsCacheEntry struct {
+	value     order.WarningRuleSettings
+	expiresAt time.Time
+}
+
+// WarningSettingsProvider reads the warning rule configuration of a tenant
+// from tbl_tenant_setting, falling back to tbl_default_settings.
+type WarningSettingsProvider struct {
+	db    *sql.DB
+	cache sync.Map
+}
+
+func NewWarningSettingsProvider(db *sql.DB) *WarningSettingsProvider {
+	return &WarningSettingsProvider{
+		db: db,
+	}
+}
+
+func (p *WarningSettingsProvider) GetWarningRuleSettings(
+	ctx context.Context,
+	tenantID int64,
+) (order.WarningRuleSettings, error) {
+	if cached, ok := p.cache.Load(tenantID); ok {
+		entry := cached.(warningSettingsCacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			return entry.value, nil
+		}
+		p.cache.Delete(tenantID)
+	}
+
+	value, found, err := p.loadTenantSetting(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		value, err = p.loadDefaultSetting(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	settings, err := parseWarningRuleSettings(value)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %d: %w", tenantID, err)
+	}
+
+	p.cache.Store(tenantID, warningSettingsCacheEntry{
+		value:     settings,
+		expiresAt: time.Now().Add(warningSettingsCacheTTL),
+	})
+	return settings, nil
+}
+
+func parseWarningRuleSettings(value string) (order.WarningRuleSettings, error) {
+	if value == "" {
+		return order.WarningRuleSettings{}, nil
+	}
+
+	var settings order.WarningRuleSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", settingWarningRules, err)
+	}
+
+	return settings, nil
+}
+
+func (p *WarningSettingsProvider) loadTenantSetting(
+	ctx context.Context,
+	tenantID int64,
+) (string, bool, error) {
+	const query = `
+SELECT value
+FROM tbl_tenant_setting
+WHERE tenant_id = ?
+  AND name = ?
+LIMIT 1
+`
+
+	defer observeQuery("warning_settings", time.Now())
+	var value string
+	err := p.db.QueryRowContext(ctx, query, tenantID, settingWarningRules).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+func (p *WarningSettingsProvider) loadDefaultSetting(
+	ctx context.Context,
+) (string, error) {
+	const query = `
+SELECT value
+FROM tbl_default_settings
+WHERE name = ?
+LIMIT 1
+`
+
+	defer observeQuery("warning_settings_default", time.Now())
+	var value string
+	err := p.db.QueryRowContext(ctx, query, settingWarningRules).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
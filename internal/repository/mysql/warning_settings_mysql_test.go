@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarningSettingsProvider_ParsesAndCachesTenantSetting(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	provider := NewWarningSettingsProvider(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT value
+FROM tbl_tenant_setting
+WHERE tenant_id = ?
+  AND name = ?
+LIMIT 1
+`)).
+		WithArgs(int64(68), settingWarningRules).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow(`{"driver_late":{"enabled":true,"threshold_minutes":7},"unpaid":{"enabled":false}}`))
+
+	got, err := provider.GetWarningRuleSettings(context.Background(), 68)
+	require.NoError(t, err)
+	require.True(t, got["driver_late"].IsEnabled())
+	require.Equal(t, int64(7), *got["driver_late"].ThresholdMinutes)
+	require.False(t, got["unpaid"].IsEnabled())
+	require.NotNil(t, got["unpaid"].Enabled)
+
+	cached, err := provider.GetWarningRuleSettings(context.Background(), 68)
+	require.NoError(t, err)
+	require.Equal(t, got, cached)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWarningSettingsProvider_FallsBackToDefaultSetting(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	provider := NewWarningSettingsProvider(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM tbl_tenant_setting`)).
+		WithArgs(int64(68), settingWarningRules).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM tbl_default_settings`)).
+		WithArgs(settingWarningRules).
+		WillReturnError(sql.ErrNoRows)
+
+	got, err := provider.GetWarningRuleSettings(context.Background(), 68)
+
+	require.NoError(t, err)
+	require.Empty(t, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,33 +12,59 @@ import (
 	legacyaddress "orders-service/internal/legacy/address"
 	"orders-service/internal/legacy/phpdata"
 	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type ActiveOrdersRepository struct {
-	client *redis.Client
-	parser *legacyaddress.Parser
+	client  *redis.Client
+	parser  *legacyaddress.Parser
+	timeout time.Duration
+	breaker *breaker
 }
 
-func NewActiveOrdersRepository(client *redis.Client) *ActiveOrdersRepository {
-	return &ActiveOrdersRepository{
+type ActiveOrdersOption func(*ActiveOrdersRepository)
+
+// WithCommandTimeout bounds every command, so a hung Redis costs a request
+// the timeout instead of its whole deadline.
+func WithCommandTimeout(timeout time.Duration) ActiveOrdersOption {
+	return func(r *ActiveOrdersRepository) {
+		if timeout > 0 {
+			r.timeout = timeout
+		}
+	}
+}
+
+// WithCircuitBreaker fails commands fast for cooldown after failures
+// consecutive commands failed.
+func WithCircuitBreaker(failures int, cooldown time.Duration) ActiveOrdersOption {
+	return func(r *ActiveOrdersRepository) {
+		if failures > 0 && cooldown > 0 {
+			r.breaker = newBreaker(failures, cooldown)
+		}
+	}
+}
+
+func NewActiveOrdersRepository(client *redis.Client, opts ...ActiveOrdersOption) *ActiveOrdersRepository {
+	r := &ActiveOrdersRepository{
 		client: client,
 		parser: legacyaddress.NewParser(),
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *ActiveOrdersRepository) GetWorkerWaitingTime(
 	ctx context.Context,
 	tenantID, orderID int64,
 ) (int64, error) {
-	raw, err := r.client.HGet(
-		ctx,
-		strconv.FormatInt(tenantID, 10),
-		strconv.FormatInt(orderID, 10),
-	).Bytes()
+	raw, err := r.hget(ctx, tenantID, orderID)
 	if err == redis.Nil {
 		return 0, nil
 	}
@@ -63,10 +90,13 @@ func (r *ActiveOrdersRepository) GetWorkerWaitingTimes(
 		fields = append(fields, strconv.FormatInt(orderID, 10))
 	}
 
-	totalStarted := time.Now()
 	hmgetStarted := time.Now()
-	values, err := r.client.HMGet(ctx, strconv.FormatInt(tenantID, 10), fields...).Result()
-	hmgetMS := time.Since(hmgetStarted).Milliseconds()
+	var values []any
+	err := r.do(ctx, func(ctx context.Context) (err error) {
+		values, err = r.client.HMGet(ctx, strconv.FormatInt(tenantID, 10), fields...).Result()
+		return err
+	})
+	hmgetMS := metrics.RedisCommandDuration.ObserveDuration(hmgetStarted, "hmget").Milliseconds()
 	if err != nil {
 		logging.Error(ctx, "redis wait times hmget failed", err,
 			"hmget_ms", hmgetMS,
@@ -76,7 +106,6 @@ func (r *ActiveOrdersRepository) GetWorkerWaitingTimes(
 		return nil, err
 	}
 
-	parseStarted := time.Now()
 	for i, value := range values {
 		if value == nil {
 			continue
@@ -100,21 +129,26 @@ func (r *ActiveOrdersRepository) GetWorkerWaitingTimes(
 			result[orderIDs[i]] = waitTime
 		}
 	}
-	parseMS := time.Since(parseStarted).Milliseconds()
-
-	logging.Info(ctx, "redis wait times timings",
-		"total_ms", time.Since(totalStarted).Milliseconds(),
-		"hmget_ms", hmgetMS,
-		"parse_ms", parseMS,
-		"tenant_id", tenantID,
-		"order_ids_count", len(orderIDs),
-		"values_count", len(values),
-		"wait_times_count", len(result),
-	)
 
 	return result, nil
 }
 
+// hget reads the payload of one active order.
+func (r *ActiveOrdersRepository) hget(ctx context.Context, tenantID, orderID int64) ([]byte, error) {
+	started := time.Now()
+	var raw []byte
+	err := r.do(ctx, func(ctx context.Context) (err error) {
+		raw, err = r.client.HGet(
+			ctx,
+			strconv.FormatInt(tenantID, 10),
+			strconv.FormatInt(orderID, 10),
+		).Bytes()
+		return err
+	})
+	metrics.RedisCommandDuration.ObserveDuration(started, "hget")
+	return raw, err
+}
+
 func parseWorkerWaitingTime(raw []byte) (int64, error) {
 	payload, err := maybeGunzip(raw)
 	if err != nil {
@@ -199,177 +233,169 @@ func (r *ActiveOrdersRepository) GetFormattedActiveOrders(
 	ctx context.Context,
 	tenantID int64,
 ) ([]order.FormattedOrder, error) {
-	values, err := r.client.HVals(ctx, strconv.FormatInt(tenantID, 10)).Result()
+	started := time.Now()
+	var values []string
+	err := r.do(ctx, func(ctx context.Context) (err error) {
+		values, err = r.client.HVals(ctx, strconv.FormatInt(tenantID, 10)).Result()
+		return err
+	})
+	metrics.RedisCommandDuration.ObserveDuration(started, "hvals")
 	if err != nil {
 		return nil, err
 	}
 
 	result := make([]order.FormattedOrder, 0, len(values))
 	for _, raw := range values {
-		payload, err := maybeGunzip([]byte(raw))
-		if err != nil {
-			log.Printf("skip active order payload: gunzip failed: %v", err)
-			continue
-		}
-
-		value, err := phpdata.Unmarshal(payload)
-		if err != nil {
-			log.Printf("skip active order payload: phpdata unmarshal failed: %v", err)
-			continue
-		}
-
-		orderData, ok := value.(map[string]any)
-		if !ok {
-			log.Printf("skip active order payload: unexpected top-level type %T", value)
-			continue
-		}
-
-		formatted, ok, err := r.mapActiveOrder(orderData)
+		formatted, err := r.decodeActiveOrder([]byte(raw))
 		if err != nil {
+			metrics.RedisSkippedPayloads.Inc("active_orders")
 			log.Printf("skip active order payload: %v", err)
 			continue
 		}
-		if !ok {
-			log.Printf("skip active order payload: missing required fields")
-			continue
-		}
 		result = append(result, formatted)
 	}
 
 	return result, nil
 }
 
+func (r *ActiveOrdersRepository) GetFormattedActiveOrder(
+	ctx context.Context,
+	tenantID, orderID int64,
+) (order.FormattedOrder, bool, error) {
+	raw, err := r.hget(ctx, tenantID, orderID)
+	if err == redis.Nil {
+		return order.FormattedOrder{}, false, nil
+	}
+	if err != nil {
+		return order.FormattedOrder{}, false, err
+	}
+
+	formatted, err := r.decodeActiveOrder(raw)
+	if err != nil {
+		metrics.RedisSkippedPayloads.Inc("active_order")
+		log.Printf("skip active order payload: %v", err)
+		return order.FormattedOrder{}, false, nil
+	}
+
+	return formatted, true, nil
+}
+
+func (r *ActiveOrdersRepository) decodeActiveOrder(raw []byte) (order.FormattedOrder, error) {
+	payload, err := maybeGunzip(raw)
+	if err != nil {
+		return order.FormattedOrder{}, fmt.Errorf("gunzip failed: %w", err)
+	}
+
+	value, err := phpdata.Unmarshal(payload)
+	if err != nil {
+		return order.FormattedOrder{}, fmt.Errorf("phpdata unmarshal failed: %w", err)
+	}
+
+	orderData, ok := value.(map[string]any)
+	if !ok {
+		return order.FormattedOrder{}, fmt.Errorf("unexpected top-level type %T", value)
+	}
+
+	formatted, ok, err := r.mapActiveOrder(orderData)
+	if err != nil {
+		return order.FormattedOrder{}, err
+	}
+	if !ok {
+		return order.FormattedOrder{}, errors.New("missing required fields")
+	}
+
+	return formatted, nil
+}
+
 func (r *ActiveOrdersRepository) mapActiveOrder(value map[string]any) (order.FormattedOrder, bool, error) {
-	orderID, ok := phpdata.CoerceInt64(value["order_id"])
+	row := payloadRow(value)
+	orderID, ok := row.Int64("order_id")
 	if !ok {
 		return order.FormattedOrder{}, false, nil
 	}
 
-	orderTime, _ := phpdata.CoerceInt64(value["order_time"])
-	statusID, _ := phpdata.CoerceInt64(value["status_id"])
-	tenantID, _ := phpdata.CoerceInt64(value["tenant_id"])
-	cityID, _ := phpdata.CoerceInt64(value["city_id"])
-	tariffID, _ := phpdata.CoerceInt64(value["tariff_id"])
-	positionID, _ := phpdata.CoerceInt64(value["position_id"])
-	clientID, _ := phpdata.CoerceInt64(value["client_id"])
-	statusTime, _ := phpdata.CoerceInt64(value["status_time"])
-	createTime, _ := phpdata.CoerceInt64(value["create_time"])
-	orderNumber, _ := phpdata.CoerceInt64(value["order_number"])
-	timeOffset, _ := phpdata.CoerceInt64(value["time_offset"])
-	unitQuantity := coerceOptionalFloat64(value["unit_quantity"])
-	timeToClient := coerceOptionalInt64(value["time_to_client"])
-
 	addresses, err := r.parseAddressValue(value["address"], orderID)
 	if err != nil {
 		return order.FormattedOrder{}, false, err
 	}
 
-	statusStatusID, _ := phpdata.CoerceInt64(value["status_status_id"])
-	if statusStatusID == 0 {
-		statusStatusID = statusID
-	}
-	statusName := phpdata.CoerceString(value["status_name"])
-
-	formatted := order.FormattedOrder{
-		OrderID:               orderID,
-		TenantID:              tenantID,
-		CityID:                cityID,
-		TariffID:              tariffID,
-		StatusID:              statusID,
-		Address:               addresses,
-		Comment:               coerceOptionalString(value["comment"]),
-		PredvPrice:            coerceFloat64(value["predv_price"]),
-		PredvPriceNoDiscount:  coerceFloat64(value["predv_price_no_discount"]),
-		Device:                phpdata.CoerceString(value["device"]),
-		OrderNumber:           orderNumber,
-		CreateTime:            createTime,
-		StatusTime:            statusTime,
-		TimeToClient:          timeToClient,
-		OrderTime:             orderTime,
-		Phone:                 phpdata.CoerceString(value["phone"]),
-		ClientID:              clientID,
-		TimeOffset:            timeOffset,
-		PositionID:            positionID,
-		UnitQuantity:          unitQuantity,
-		ShopID:                coerceOptionalInt64(value["shop_id"]),
-		OrderCode:             phpdata.CoerceString(value["order_code"]),
-		SummaryCost:           coerceOptionalString(value["summary_cost"]),
-		SummaryCostNoDiscount: coerceOptionalString(value["summary_cost_no_discount"]),
-		StatusStatusID:        statusStatusID,
-		StatusName:            statusName,
-		Callsign:              coerceOptionalInt64(value["callsign"]),
-		WName:                 coerceOptionalString(value["wName"]),
-		WLastName:             coerceOptionalString(value["wLastName"]),
-		WSecondName:           coerceOptionalString(value["wSecondName"]),
-		WPhone:                coerceOptionalString(value["wPhone"]),
-		CPhone:                coerceOptionalString(value["cPhone"]),
-		CName:                 coerceOptionalString(value["cName"]),
-		CLastName:             coerceOptionalString(value["cLastName"]),
-		CSecondName:           coerceOptionalString(value["cSecondName"]),
-		CarName:               coerceOptionalString(value["car_name"]),
-		CarColor:              coerceOptionalString(value["car_color"]),
-		CarGosNumber:          coerceOptionalString(value["car_gos_number"]),
-		TariffType:            phpdata.CoerceString(value["tariff_type"]),
-		TName:                 phpdata.CoerceString(value["tName"]),
-		QuantitativeTitle:     phpdata.CoerceString(value["quantitative_title"]),
-		PriceForUnit:          coerceFloat64(value["price_for_unit"]),
-		UnitName:              phpdata.CoerceString(value["unit_name"]),
-		UserID:                coerceInt64(value["user_id"]),
-		UName:                 phpdata.CoerceString(value["uName"]),
-		ULastName:             phpdata.CoerceString(value["uLastName"]),
-		USecondName:           coerceOptionalString(value["uSecondName"]),
-		CurrencyName:          phpdata.CoerceString(value["currency_name"]),
-		CurrencyCode:          phpdata.CoerceString(value["currency_code"]),
-		Symbol:                phpdata.CoerceString(value["symbol"]),
-		Status: order.StatusDTO{
-			StatusID: statusStatusID,
-			Name:     statusName,
-		},
-		Client: order.ClientDTO{
-			ClientID:   clientID,
-			Phone:      coerceOptionalString(value["cPhone"]),
-			Name:       coerceOptionalString(value["cName"]),
-			LastName:   coerceOptionalString(value["cLastName"]),
-			SecondName: coerceOptionalString(value["cSecondName"]),
-		},
-		UserCreated: order.UserDTO{
-			UserID:     coerceInt64(value["user_id"]),
-			Name:       phpdata.CoerceString(value["uName"]),
-			LastName:   phpdata.CoerceString(value["uLastName"]),
-			SecondName: coerceOptionalString(value["uSecondName"]),
-		},
-		Worker: order.WorkerDTO{
-			WorkerID:   coerceInt64(value["worker_id"]),
-			Callsign:   coerceOptionalInt64(value["callsign"]),
-			Name:       coerceOptionalString(value["wName"]),
-			LastName:   coerceOptionalString(value["wLastName"]),
-			SecondName: coerceOptionalString(value["wSecondName"]),
-			Phone:      coerceOptionalString(value["wPhone"]),
-		},
-		Car: order.CarDTO{
-			CarID:     coerceInt64(value["car_id"]),
-			Name:      coerceOptionalString(value["car_name"]),
-			Color:     coerceOptionalInt64(value["car_color"]),
-			GosNumber: coerceOptionalString(value["car_gos_number"]),
-		},
-		Tariff: order.TariffDTO{
-			TariffID:          tariffID,
-			TariffType:        phpdata.CoerceString(value["tariff_type"]),
-			Name:              phpdata.CoerceString(value["tName"]),
-			QuantitativeTitle: phpdata.CoerceString(value["quantitative_title"]),
-			PriceForUnit:      coerceFloat64(value["price_for_unit"]),
-			UnitName:          phpdata.CoerceString(value["unit_name"]),
-		},
-		Currency: order.CurrencyDTO{
-			Name:   phpdata.CoerceString(value["currency_name"]),
-			Code:   phpdata.CoerceString(value["currency_code"]),
-			Symbol: phpdata.CoerceString(value["symbol"]),
-		},
+	reportMissingFields(row)
+	formatted := order.FormatOrderRow(row)
+	formatted.Address = addresses
+	// The payload keeps the worker waiting time in minutes.
+	if waitTime, ok := phpdata.CoerceInt64(value["wait_time"]); ok {
+		formatted.WaitTime = waitTime * 60
 	}
-
 	return formatted, true, nil
 }
 
+// reportedPayloadShapes holds the key sets already checked for missing
+// fields and reportedMissingFields the fields already logged, so the check
+// runs once per payload shape instead of on every decode.
+var (
+	reportedPayloadShapes sync.Map
+	reportedMissingFields sync.Map
+)
+
+// reportMissingFields is the parity check against MySQL: every order field
+// the payload has no key for stays empty in the active order. The counter
+// counts the payload shapes missing a field.
+func reportMissingFields(row payloadRow) {
+	if _, checked := reportedPayloadShapes.LoadOrStore(payloadShape(row), struct{}{}); checked {
+		return
+	}
+	for _, field := range order.MissingOrderFields(row) {
+		metrics.RedisPayloadMissingFields.Inc(field)
+		if _, logged := reportedMissingFields.LoadOrStore(field, struct{}{}); !logged {
+			logging.Warn(context.Background(), "active order payload misses an order field", "field", field)
+		}
+	}
+}
+
+// payloadShape sums the FNV-1a hashes of the keys of row, so it does not
+// depend on the key order and allocates nothing.
+func payloadShape(row payloadRow) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	shape := uint64(len(row))
+	for key := range row {
+		h := uint64(offset64)
+		for i := 0; i < len(key); i++ {
+			h ^= uint64(key[i])
+			h *= prime64
+		}
+		shape += h
+	}
+	return shape
+}
+
+// payloadRow reads a decoded PHP payload with the coercions of the legacy
+// mapping: empty strings and NULL are missing, numbers may be strings.
+type payloadRow map[string]any
+
+func (p payloadRow) Has(key string) bool {
+	_, ok := p[key]
+	return ok
+}
+
+func (p payloadRow) Int64(key string) (int64, bool) {
+	return phpdata.CoerceInt64(p[key])
+}
+
+func (p payloadRow) Float64(key string) (float64, bool) {
+	if p[key] == nil {
+		return 0, false
+	}
+	return coerceFloat64(p[key]), true
+}
+
+func (p payloadRow) String(key string) (string, bool) {
+	s := phpdata.CoerceString(p[key])
+	return s, s != ""
+}
+
 func (r *ActiveOrdersRepository) parseAddressValue(value any, orderID int64) ([]order.AddressView, error) {
 	switch raw := value.(type) {
 	case string:
@@ -423,19 +449,6 @@ func coerceOptionalString(value any) *string {
 	return &s
 }
 
-func coerceInt64(value any) int64 {
-	v, _ := phpdata.CoerceInt64(value)
-	return v
-}
-
-func coerceOptionalInt64(value any) *int64 {
-	v, ok := phpdata.CoerceInt64(value)
-	if !ok {
-		return nil
-	}
-	return &v
-}
-
 func coerceFloat64(value any) float64 {
 	switch v := value.(type) {
 	case float64:
@@ -455,15 +468,6 @@ func coerceFloat64(value any) float64 {
 	}
 }
 
-func coerceOptionalFloat64(value any) *float64 {
-	switch value.(type) {
-	case nil:
-		return nil
-	}
-	v := coerceFloat64(value)
-	return &v
-}
-
 func maybeGunzip(raw []byte) ([]byte, error) {
 	if len(raw) < 2 || raw[0] != 0x1f || raw[1] != 0x8b {
 		return raw, nil
@@ -4,7 +4,12 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
+	"net"
 	"testing"
+	"time"
+
+	"orders-service/internal/app/order"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
@@ -157,6 +162,98 @@ func TestGetWorkerWaitingTimes(t *testing.T) {
 	}, got)
 }
 
+func TestGetFormattedActiveOrder(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	repo := NewActiveOrdersRepository(client)
+
+	payload := `a:4:{s:8:"order_id";i:11;s:9:"tenant_id";i:68;s:9:"status_id";i:26;s:9:"wait_time";s:1:"5";}`
+	mr.HSet("68", "11", string(gzipBytes(t, []byte(payload))))
+	mr.HSet("68", "12", `a:1:{`)
+
+	t.Run("found", func(t *testing.T) {
+		got, found, err := repo.GetFormattedActiveOrder(ctx, 68, 11)
+
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, int64(11), got.OrderID)
+		require.Equal(t, int64(26), got.StatusID)
+		require.Equal(t, int64(26), got.Status.StatusID)
+		require.Equal(t, int64(300), got.WaitTime)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, found, err := repo.GetFormattedActiveOrder(ctx, 68, 13)
+
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+
+	t.Run("other tenant", func(t *testing.T) {
+		_, found, err := repo.GetFormattedActiveOrder(ctx, 69, 11)
+
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+
+	t.Run("broken payload is skipped", func(t *testing.T) {
+		_, found, err := repo.GetFormattedActiveOrder(ctx, 68, 12)
+
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+}
+
+func TestMapActiveOrder_FillsFieldsOfTheTable(t *testing.T) {
+	repo := NewActiveOrdersRepository(nil)
+	payload := map[string]any{
+		"order_id":     int64(11),
+		"tenant_id":    int64(68),
+		"status_id":    int64(26),
+		"worker_id":    "7",
+		"car_id":       int64(9),
+		"payment":      "CASH",
+		"is_pre_order": int64(1),
+		"finish_time":  nil,
+		"wName":        "Ivan",
+		"wLastName":    "Petrov",
+		"car_color":    "3",
+	}
+
+	got, ok, err := repo.mapActiveOrder(payload)
+
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(7), *got.WorkerID)
+	require.Equal(t, int64(9), *got.CarID)
+	require.Equal(t, "CASH", got.Payment)
+	require.Equal(t, int64(1), got.IsPreOrder)
+	require.Nil(t, got.FinishTime)
+	require.Equal(t, int64(26), got.Status.StatusID)
+	require.Equal(t, "3", *got.CarColor)
+	require.Equal(t, int64(3), *got.Car.Color)
+	require.NotNil(t, order.BuildWorker(got))
+	require.NotNil(t, order.BuildCar(got))
+
+	missing := order.MissingOrderFields(payloadRow(payload))
+	require.NotContains(t, missing, "WorkerID")
+	require.NotContains(t, missing, "FinishTime", "a NULL value is still carried by the payload")
+	require.Contains(t, missing, "CompanyID")
+}
+
+func TestPayloadShape_DependsOnKeySetOnly(t *testing.T) {
+	shape := payloadShape(payloadRow{"order_id": 1, "status_id": 17})
+	require.Equal(t, shape, payloadShape(payloadRow{"status_id": "26", "order_id": 2}))
+	require.NotEqual(t, shape, payloadShape(payloadRow{"order_id": 1}))
+	require.NotEqual(t, shape, payloadShape(payloadRow{"order_id": 1, "tariff_id": 17}))
+}
+
 func gzipBytes(t *testing.T, payload []byte) []byte {
 	t.Helper()
 
@@ -168,3 +265,98 @@ func gzipBytes(t *testing.T, payload []byte) []byte {
 
 	return buf.Bytes()
 }
+
+func TestCircuitBreaker_FailsFastUntilProbeSucceeds(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	repo := NewActiveOrdersRepository(client, WithCircuitBreaker(2, time.Minute))
+	now := time.Unix(1000, 0)
+	repo.breaker.now = func() time.Time { return now }
+
+	_, err := repo.GetWorkerWaitingTime(ctx, 68, 100)
+	require.NoError(t, err, "a missing key is not a failure")
+
+	mr.SetError("LOADING")
+	for range 2 {
+		_, err = repo.GetFormattedActiveOrders(ctx, 68)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+
+	mr.SetError("")
+	_, err = repo.GetFormattedActiveOrders(ctx, 68)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	_, err = repo.GetWorkerWaitingTimes(ctx, 68, []int64{100})
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	_, err = repo.GetFormattedActiveOrders(ctx, 68)
+	require.NoError(t, err, "the probe after the cooldown closes the breaker")
+	_, err = repo.GetFormattedActiveOrders(ctx, 68)
+	require.NoError(t, err)
+}
+
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	b := newBreaker(1, time.Minute)
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+
+	require.NoError(t, b.allow())
+	b.record(errors.New("i/o timeout"))
+	require.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, b.allow())
+	require.ErrorIs(t, b.allow(), ErrCircuitOpen, "one probe at a time")
+	b.record(errors.New("i/o timeout"))
+
+	now = now.Add(30 * time.Second)
+	require.ErrorIs(t, b.allow(), ErrCircuitOpen)
+	now = now.Add(30 * time.Second)
+	require.NoError(t, b.allow())
+	b.release()
+	require.NoError(t, b.allow(), "a probe cancelled by its caller is retried")
+}
+
+func TestCommandTimeout_BoundsHungRedis(t *testing.T) {
+	// A server that accepts connections and never answers.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() {
+				_ = conn.Close()
+			})
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{
+		Addr:                  listener.Addr().String(),
+		MaxRetries:            -1,
+		ReadTimeout:           5 * time.Second,
+		ContextTimeoutEnabled: true,
+	})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	repo := NewActiveOrdersRepository(client, WithCommandTimeout(20*time.Millisecond))
+	started := time.Now()
+	_, err = repo.GetFormattedActiveOrders(context.Background(), 68)
+
+	require.Error(t, err)
+	require.Less(t, time.Since(started), 500*time.Millisecond)
+}
@@ -0,0 +1,138 @@
+package redisactive
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"orders-service/internal/apperror"
+	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCircuitOpen is returned without calling Redis while the breaker is open.
+var ErrCircuitOpen = apperror.New(apperror.ErrUpstreamUnavailable, "redis circuit breaker is open").
+	WithCode("redis_unavailable")
+
+type breakerState string
+
+const (
+	breakerClosed   breakerState = "closed"
+	breakerOpen     breakerState = "open"
+	breakerHalfOpen breakerState = "half_open"
+)
+
+// breaker opens after threshold consecutive failed commands and fails every
+// command fast for the cooldown. After it one command probes Redis: success
+// closes the breaker, failure opens it for another cooldown.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     breakerClosed,
+	}
+}
+
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.transition(breakerHalfOpen)
+		return nil
+	case breakerHalfOpen:
+		// The probe is in flight.
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		if b.state != breakerClosed {
+			b.transition(breakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		if b.state != breakerOpen {
+			b.transition(breakerOpen)
+		}
+	}
+}
+
+// release ends a probe that did not reach a verdict.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+		b.openedAt = b.now().Add(-b.cooldown)
+	}
+}
+
+func (b *breaker) transition(state breakerState) {
+	b.state = state
+	metrics.RedisBreakerTransitions.Inc(string(state))
+	logging.Warn(context.Background(), "redis circuit breaker "+string(state), "failures", b.failures)
+}
+
+// do runs command within the command timeout and behind the breaker. A
+// missing key is an answer of Redis, a cancellation of the caller says
+// nothing about Redis; neither counts as a failure.
+func (r *ActiveOrdersRepository) do(ctx context.Context, command func(ctx context.Context) error) error {
+	if r.breaker != nil {
+		if err := r.breaker.allow(); err != nil {
+			return err
+		}
+	}
+
+	callCtx := ctx
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	err := command(callCtx)
+	if r.breaker != nil {
+		switch {
+		case errors.Is(err, redis.Nil):
+			r.breaker.record(nil)
+		case err != nil && ctx.Err() != nil:
+			// Neither a success nor a failure, a half open breaker probes
+			// again with the next command.
+			r.breaker.release()
+		default:
+			r.breaker.record(err)
+		}
+	}
+	return err
+}
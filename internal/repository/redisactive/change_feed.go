@@ -0,0 +1,362 @@
+package redisactive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"orders-service/internal/app/order"
+	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	changeFeedMinBackoff = time.Second
+	changeFeedMaxBackoff = 30 * time.Second
+)
+
+// hashEvents are the keyspace events that change a tenant hash. Expired and
+// evicted keys need the x and e classes of notify-keyspace-events.
+var hashEvents = map[string]struct{}{
+	"hset":    {},
+	"hdel":    {},
+	"del":     {},
+	"expired": {},
+	"evicted": {},
+}
+
+type ChangeFeedOption func(*ChangeFeed)
+
+// WithNotificationConfig makes Run enable hash keyspace notifications with
+// CONFIG SET. Managed Redis usually forbids it, there they are configured on
+// the server instead, with at least the classes Khgxe.
+func WithNotificationConfig() ChangeFeedOption {
+	return func(f *ChangeFeed) {
+		f.configure = true
+	}
+}
+
+// WithAvailability calls available with true once the feed publishes every
+// change and with false when it loses the subscription, so what relies on its
+// events, like a response cache, can stop trusting it.
+func WithAvailability(available func(bool)) ChangeFeedOption {
+	return func(f *ChangeFeed) {
+		f.available = available
+	}
+}
+
+// ChangeFeed turns Redis keyspace notifications on tenant hashes into
+// OrderChanged events. A notification only names the key, so the feed keeps
+// the raw fields of every tenant hash and diffs them after each change.
+type ChangeFeed struct {
+	client     *redis.Client
+	repo       *ActiveOrdersRepository
+	publisher  order.OrderChangePublisher
+	configure  bool
+	available  func(bool)
+	ready      chan struct{}
+	primed     bool
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	snapshots map[int64]map[string]string
+}
+
+func NewChangeFeed(
+	client *redis.Client,
+	publisher order.OrderChangePublisher,
+	opts ...ChangeFeedOption,
+) *ChangeFeed {
+	f := &ChangeFeed{
+		client:     client,
+		repo:       NewActiveOrdersRepository(client),
+		publisher:  publisher,
+		ready:      make(chan struct{}),
+		minBackoff: changeFeedMinBackoff,
+		maxBackoff: changeFeedMaxBackoff,
+		snapshots:  make(map[int64]map[string]string),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Run subscribes to the keyspace channel of the client database and publishes
+// events until ctx is done. A lost subscription is set up again with backoff,
+// and the changes made while it was down are published as one diff.
+func (f *ChangeFeed) Run(ctx context.Context) {
+	backoff := f.minBackoff
+	for {
+		synced, err := f.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if synced {
+			backoff = f.minBackoff
+		}
+		logging.Error(ctx, "active order change feed interrupted", err, "retry_in_ms", backoff.Milliseconds())
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, f.maxBackoff)
+	}
+}
+
+// session runs one subscription until it fails and reports whether it got
+// in sync with the hashes.
+func (f *ChangeFeed) session(ctx context.Context) (bool, error) {
+	defer f.setAvailable(false)
+
+	if f.configure {
+		if err := f.client.ConfigSet(ctx, "notify-keyspace-events", "Khgxe").Err(); err != nil {
+			return false, fmt.Errorf("enable keyspace notifications: %w", err)
+		}
+	}
+
+	prefix := fmt.Sprintf("__keyspace@%d__:", f.client.Options().DB)
+	pubsub := f.client.PSubscribe(ctx, prefix+"*")
+	defer pubsub.Close()
+
+	// Wait for the subscription before syncing, so no change between the
+	// snapshot and the first notification is lost.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return false, fmt.Errorf("subscribe keyspace notifications: %w", err)
+	}
+	if err := f.sync(ctx); err != nil {
+		return false, err
+	}
+	f.setAvailable(true)
+
+	messages := pubsub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case msg, ok := <-messages:
+			if !ok {
+				return true, errors.New("keyspace subscription closed")
+			}
+
+			tenants := map[int64]struct{}{}
+			resubscribed := collectTenant(tenants, prefix, msg)
+			// Coalesce the notifications already queued, a burst of HSETs on
+			// a tenant needs a single diff.
+			for drained := false; !drained; {
+				select {
+				case next, ok := <-messages:
+					if ok {
+						resubscribed = collectTenant(tenants, prefix, next) || resubscribed
+					} else {
+						drained = true
+					}
+				default:
+					drained = true
+				}
+			}
+
+			// The client resubscribes on its own after a reconnect, the
+			// notifications in between are lost.
+			if resubscribed {
+				logging.Warn(ctx, "active order change feed resubscribed")
+				if err := f.sync(ctx); err != nil {
+					return true, err
+				}
+				continue
+			}
+
+			for tenantID := range tenants {
+				if err := f.refreshTenant(ctx, tenantID); err != nil && ctx.Err() == nil {
+					logging.Error(ctx, "active order change feed refresh failed", err, "tenant_id", tenantID)
+				}
+			}
+		}
+	}
+}
+
+// Ready is closed once Run has subscribed and loaded the current hashes.
+func (f *ChangeFeed) Ready() <-chan struct{} {
+	return f.ready
+}
+
+func (f *ChangeFeed) setAvailable(available bool) {
+	if f.available != nil {
+		f.available(available)
+	}
+}
+
+// collectTenant adds the tenant a notification names and reports whether msg
+// is a repeated subscription of the client.
+func collectTenant(tenants map[int64]struct{}, prefix string, msg any) bool {
+	switch msg := msg.(type) {
+	case *redis.Subscription:
+		return msg.Kind == "psubscribe"
+	case *redis.Message:
+		if _, ok := hashEvents[msg.Payload]; !ok {
+			return false
+		}
+		tenantID, err := strconv.ParseInt(strings.TrimPrefix(msg.Channel, prefix), 10, 64)
+		if err == nil && tenantID > 0 {
+			tenants[tenantID] = struct{}{}
+		}
+	}
+	return false
+}
+
+// sync loads the current hash of every tenant. The first sync only primes the
+// snapshots, later ones publish what changed while the feed was not
+// subscribed.
+func (f *ChangeFeed) sync(ctx context.Context) error {
+	if !f.primed {
+		if err := f.prime(ctx); err != nil {
+			return err
+		}
+		f.primed = true
+		logging.Info(ctx, "active order change feed started", "tenants", len(f.snapshots))
+		close(f.ready)
+		return nil
+	}
+
+	tenants := make(map[int64]struct{}, len(f.snapshots))
+	for tenantID := range f.snapshots {
+		tenants[tenantID] = struct{}{}
+	}
+	if err := f.scanTenants(ctx, func(tenantID int64) error {
+		tenants[tenantID] = struct{}{}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for tenantID := range tenants {
+		if err := f.refreshTenant(ctx, tenantID); err != nil {
+			return fmt.Errorf("resync tenant %d: %w", tenantID, err)
+		}
+	}
+	logging.Info(ctx, "active order change feed resynced", "tenants", len(tenants))
+	return nil
+}
+
+// scanTenants calls fn with the id of every tenant hash until it fails.
+func (f *ChangeFeed) scanTenants(ctx context.Context, fn func(tenantID int64) error) error {
+	iter := f.client.Scan(ctx, 0, "*", 500).Iterator()
+	for iter.Next(ctx) {
+		tenantID, err := strconv.ParseInt(iter.Val(), 10, 64)
+		if err != nil || tenantID <= 0 {
+			continue
+		}
+		if err := fn(tenantID); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("scan active orders: %w", err)
+	}
+	return nil
+}
+
+// prime loads the current hash of every tenant without publishing events.
+func (f *ChangeFeed) prime(ctx context.Context) error {
+	return f.scanTenants(ctx, func(tenantID int64) error {
+		fields, err := f.client.HGetAll(ctx, strconv.FormatInt(tenantID, 10)).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logging.Warn(ctx, "skip tenant in change feed", "tenant_id", tenantID, "error", err.Error())
+			return nil
+		}
+		f.snapshots[tenantID] = fields
+		return nil
+	})
+}
+
+func (f *ChangeFeed) refreshTenant(ctx context.Context, tenantID int64) error {
+	started := time.Now()
+	current, err := f.client.HGetAll(ctx, strconv.FormatInt(tenantID, 10)).Result()
+	metrics.RedisCommandDuration.ObserveDuration(started, "hgetall")
+	if err != nil {
+		return err
+	}
+
+	previous := f.snapshots[tenantID]
+	f.snapshots[tenantID] = current
+
+	events := 0
+	for field, raw := range current {
+		before, ok := previous[field]
+		if ok && before == raw {
+			continue
+		}
+		if f.publish(ctx, tenantID, field, before, ok, raw, true) {
+			events++
+		}
+	}
+	for field, raw := range previous {
+		if _, ok := current[field]; ok {
+			continue
+		}
+		if f.publish(ctx, tenantID, field, raw, true, "", false) {
+			events++
+		}
+	}
+
+	logging.Info(ctx, "active order change feed refreshed",
+		"tenant_id", tenantID,
+		"fields", len(current),
+		"events", events,
+	)
+	return nil
+}
+
+func (f *ChangeFeed) publish(
+	ctx context.Context,
+	tenantID int64,
+	field string,
+	beforeRaw string, hasBefore bool,
+	afterRaw string, hasAfter bool,
+) bool {
+	orderID, err := strconv.ParseInt(field, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	event := order.OrderChanged{TenantID: tenantID, OrderID: orderID}
+	if hasBefore {
+		event.Before = f.decode(ctx, tenantID, orderID, beforeRaw)
+	}
+	if hasAfter {
+		event.After = f.decode(ctx, tenantID, orderID, afterRaw)
+	}
+	// A payload that cannot be decoded is skipped like in the readers, it must
+	// not look like a removed order.
+	if (hasAfter && event.After == nil) || (event.Before == nil && event.After == nil) {
+		return false
+	}
+
+	f.publisher.PublishOrderChanged(ctx, event)
+	return true
+}
+
+func (f *ChangeFeed) decode(ctx context.Context, tenantID, orderID int64, raw string) *order.FormattedOrder {
+	formatted, err := f.repo.decodeActiveOrder([]byte(raw))
+	if err != nil {
+		metrics.RedisSkippedPayloads.Inc("change_feed")
+		logging.Warn(ctx, "skip active order payload in change feed",
+			"tenant_id", tenantID,
+			"order_id", orderID,
+			"error", err.Error(),
+		)
+		return nil
+	}
+	return &formatted
+}
@@ -0,0 +1,155 @@
+package redisactive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"orders-service/internal/app/order"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+type capturePublisher struct {
+	events chan order.OrderChanged
+}
+
+func (p capturePublisher) PublishOrderChanged(ctx context.Context, event order.OrderChanged) {
+	p.events <- event
+}
+
+func (p capturePublisher) next(t *testing.T) order.OrderChanged {
+	t.Helper()
+	select {
+	case event := <-p.events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no order event published")
+		return order.OrderChanged{}
+	}
+}
+
+func activeOrderPayload(t *testing.T, statusID string) string {
+	return string(gzipBytes(t, []byte(
+		`a:3:{s:8:"order_id";i:11;s:9:"tenant_id";i:68;s:9:"status_id";i:`+statusID+`;}`,
+	)))
+}
+
+func TestChangeFeed_PublishesDiffOfTenantHash(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	mr.HSet("68", "11", activeOrderPayload(t, "17"))
+	mr.Set("config", "not a tenant")
+
+	publisher := capturePublisher{events: make(chan order.OrderChanged, 10)}
+	feed := NewChangeFeed(client, publisher)
+
+	runChangeFeed(t, feed)
+
+	select {
+	case <-feed.Ready():
+	case <-time.After(2 * time.Second):
+		t.Fatal("change feed not ready")
+	}
+
+	// Miniredis does not emit keyspace notifications, they are published by
+	// hand after each change.
+	mr.HSet("68", "11", activeOrderPayload(t, "26"))
+	mr.Publish("__keyspace@0__:68", "hset")
+
+	changed := publisher.next(t)
+	require.Equal(t, int64(68), changed.TenantID)
+	require.Equal(t, int64(11), changed.OrderID)
+	require.Equal(t, int64(17), changed.Before.StatusID)
+	require.Equal(t, int64(26), changed.After.StatusID)
+
+	mr.HSet("68", "12", `a:1:{`)
+	mr.Publish("__keyspace@0__:68", "hset")
+	mr.HDel("68", "11")
+	mr.Publish("__keyspace@0__:68", "hdel")
+
+	removed := publisher.next(t)
+	require.Equal(t, int64(11), removed.OrderID)
+	require.Equal(t, int64(26), removed.Before.StatusID)
+	require.Nil(t, removed.After)
+
+	select {
+	case event := <-publisher.events:
+		t.Fatalf("unexpected event for order %d", event.OrderID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestChangeFeed_ReportsAvailability(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	availability := make(chan bool, 2)
+	feed := NewChangeFeed(client, capturePublisher{events: make(chan order.OrderChanged, 1)},
+		WithAvailability(func(available bool) { availability <- available }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		feed.Run(ctx)
+		close(done)
+	}()
+
+	require.True(t, <-availability)
+	cancel()
+	<-done
+	require.False(t, <-availability)
+}
+
+func TestChangeFeed_PublishesChangesMissedWhileDisconnected(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	mr.HSet("68", "11", activeOrderPayload(t, "17"))
+
+	publisher := capturePublisher{events: make(chan order.OrderChanged, 10)}
+	feed := NewChangeFeed(client, publisher)
+	feed.minBackoff = 10 * time.Millisecond
+	runChangeFeed(t, feed)
+
+	select {
+	case <-feed.Ready():
+	case <-time.After(2 * time.Second):
+		t.Fatal("change feed not ready")
+	}
+
+	// Nothing notifies about the change made while the server is down.
+	mr.Close()
+	mr.HSet("68", "11", activeOrderPayload(t, "26"))
+	require.NoError(t, mr.Restart())
+
+	changed := publisher.next(t)
+	require.Equal(t, int64(11), changed.OrderID)
+	require.Equal(t, int64(17), changed.Before.StatusID)
+	require.Equal(t, int64(26), changed.After.StatusID)
+}
+
+func runChangeFeed(t *testing.T, feed *ChangeFeed) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		feed.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
@@ -0,0 +1,116 @@
+package orderhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"orders-service/internal/apperror"
+	"orders-service/internal/auth"
+	"orders-service/internal/logging"
+)
+
+// TokenVerifier turns a bearer token into the caller of the request.
+type TokenVerifier interface {
+	Verify(token string) (auth.Principal, error)
+}
+
+// AuthMiddleware rejects requests without a valid bearer token and puts the
+// principal of the token into the request context.
+func AuthMiddleware(verifier TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r.Header.Get("Authorization"))
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				writeError(w, r, apperror.New(apperror.ErrUnauthorized, "bearer token required"))
+				return
+			}
+
+			principal, err := verifier.Verify(token)
+			if err != nil {
+				logging.Warn(r.Context(), "token rejected", "error", err.Error())
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeError(w, r, apperror.Wrap(apperror.ErrUnauthorized, "invalid bearer token", err))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// authorizeScope checks the tenant, cities and positions of a request against
+// the principal of the request. Omitted cities and positions are clamped to
+// the ones the token allows, any other value out of scope is rejected.
+// Without a principal authentication is disabled and the request is kept.
+func authorizeScope(r *http.Request, req *OrderBaseRequest) *apperror.Error {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+
+	if req.TenantID == 0 {
+		req.TenantID = principal.TenantID
+	}
+	if req.TenantID != principal.TenantID {
+		return apperror.New(apperror.ErrForbidden, fmt.Sprintf("tenant %d is not allowed", req.TenantID)).
+			WithCode("forbidden_tenant").
+			WithDetails(map[string]any{"tenant_id": req.TenantID})
+	}
+
+	cityIDs, denied := clampScope(req.CityIDs, principal.CityIDs)
+	if len(denied) > 0 {
+		return apperror.New(apperror.ErrForbidden, "city_ids contain cities that are not allowed").
+			WithCode("forbidden_city").
+			WithDetails(map[string]any{"city_ids": denied})
+	}
+	req.CityIDs = cityIDs
+
+	positions, denied := clampScope(req.UserPositions, principal.Positions)
+	if len(denied) > 0 {
+		return apperror.New(apperror.ErrForbidden, "user_positions contain positions that are not allowed").
+			WithCode("forbidden_position").
+			WithDetails(map[string]any{"user_positions": denied})
+	}
+	req.UserPositions = positions
+
+	return nil
+}
+
+// clampScope returns the allowed ids for an empty request list and the ids
+// outside of allowed otherwise. An empty allowed list allows everything.
+func clampScope(requested, allowed []int64) ([]int64, []int64) {
+	if len(allowed) == 0 {
+		return requested, nil
+	}
+	if len(requested) == 0 {
+		return append([]int64(nil), allowed...), nil
+	}
+
+	var denied []int64
+	for _, id := range requested {
+		if !containsID(allowed, id) {
+			denied = append(denied, id)
+		}
+	}
+	return requested, denied
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, value := range ids {
+		if value == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package orderhttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"orders-service/internal/app/order"
+	"orders-service/internal/app/responsecache"
+)
+
+// cacheHeader tells the client whether the response came from the response
+// cache (HIT), from an identical request in flight (SHARED) or was loaded for
+// it (MISS).
+const cacheHeader = "X-Cache"
+
+// WithResponseCache serves the list routes with a TTL in the cache from it.
+func WithResponseCache(cache *responsecache.Cache) Option {
+	return func(h *Handler) {
+		h.cache = cache
+	}
+}
+
+// writeCached writes the payload of load as JSON. When the route is cached
+// the payload is shared by every identical request of the tenant: req is the
+// decoded request after authorizeScope, so its encoding is the normalized
+// request with the scope of the caller. The context of load records the
+// components the payload was served without, see order.Degraded; such a
+// payload is not cached, the next request loads again.
+func (h *Handler) writeCached(
+	w http.ResponseWriter,
+	r *http.Request,
+	route string,
+	tenantID int64,
+	req any,
+	load func(ctx context.Context) (any, error),
+) {
+	if !h.cache.Enabled(route) {
+		payload, err := load(order.WithDegradation(r.Context()))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, payload)
+		return
+	}
+
+	normalized, err := json.Marshal(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	sum := sha256.Sum256(normalized)
+
+	body, source, err := h.cache.Get(r.Context(), route, tenantID, hex.EncodeToString(sum[:]),
+		func(ctx context.Context) ([]byte, error) {
+			ctx = order.WithDegradation(ctx)
+			payload, err := load(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if len(order.Degraded(ctx)) > 0 {
+				responsecache.SkipStore(ctx)
+			}
+			// The trailing newline matches the bodies of writeJSON.
+			body, err := json.Marshal(payload)
+			return append(body, '\n'), err
+		})
+	if source != "" {
+		w.Header().Set(cacheHeader, string(source))
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
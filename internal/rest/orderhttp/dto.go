@@ -15,6 +15,16 @@ type OrderBaseRequest struct {
 	UserPositions  []int64 `json:"user_positions"`
 	SortField      string  `json:"sort_field"`
 	SortOrder      string  `json:"sort_order"`
+	// Sort replaces sort_field and sort_order with several keys.
+	Sort   []SortKeyRequest `json:"sort"`
+	Cursor string           `json:"cursor"`
+}
+
+// SortKeyRequest is one key of the sort form, order is asc or desc and
+// defaults to asc.
+type SortKeyRequest struct {
+	Field string `json:"field"`
+	Order string `json:"order"`
 }
 
 type WarningFullRequest struct {
@@ -27,6 +37,14 @@ type WarningFullRequest struct {
 	Page                   int     `json:"page"`
 	PageSize               int     `json:"page_size"`
 	Group                  string  `json:"group"`
+	// WarningRules overrides the tenant warning rule settings by rule code.
+	WarningRules map[string]WarningRuleRequest `json:"warning_rules"`
+}
+
+type WarningRuleRequest struct {
+	Enabled          *bool   `json:"enabled"`
+	ThresholdMinutes *int64  `json:"threshold_minutes"`
+	StatusIDs        []int64 `json:"status_ids"`
 }
 
 type SearchAttributeRequest struct {
@@ -63,4 +81,26 @@ type GetAllOrdersRequest struct {
 	Attributes   []SearchAttributeRequest `json:"attributes"`
 	SearchString SearchStringMap          `json:"search_string"`
 	ShopIDs      []int64                  `json:"shop_ids"`
+	// DateFrom and DateTo select whole days in the city time of the order,
+	// TimeFrom and TimeTo ("20:00", "08:00") narrow them to a time window.
+	DateFrom string `json:"date_from"`
+	DateTo   string `json:"date_to"`
+	TimeFrom string `json:"time_from"`
+	TimeTo   string `json:"time_to"`
+}
+
+// OrderStatsRequest is the all-orders search of POST /orders/stats grouped by
+// group_by. Page, page_size, cursor and sort are ignored.
+type OrderStatsRequest struct {
+	GetAllOrdersRequest
+	GroupBy string `json:"group_by"`
+}
+
+// ExportOrdersRequest is the all-orders search of POST /orders/all/export.
+// Page, page_size and cursor are ignored, the export walks every page.
+type ExportOrdersRequest struct {
+	GetAllOrdersRequest
+	Columns  []string `json:"columns"`
+	Timezone string   `json:"timezone"`
+	Limit    int      `json:"limit"`
 }
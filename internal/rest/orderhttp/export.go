@@ -0,0 +1,126 @@
+package orderhttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"orders-service/internal/app/order"
+	"orders-service/internal/app/orderexport"
+	"orders-service/internal/apperror"
+	"orders-service/internal/logging"
+)
+
+const defaultExportRowLimit = 10000
+
+// AllOrdersExport streams the all-orders search as a CSV or XLSX file. The
+// format comes from ?format=, the body is the all-orders request plus the
+// columns, the time zone of the dates and an optional row limit.
+func (h *Handler) AllOrdersExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = orderexport.FormatCSV
+	}
+	if format != orderexport.FormatCSV && format != orderexport.FormatXLSX {
+		writeError(w, r, apperror.Validation("format must be csv or xlsx"))
+		return
+	}
+
+	var req ExportOrdersRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := authorizeScope(r, &req.OrderBaseRequest); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := validateExportOrdersRequest(req, h.maxDateRangeDays(req.TenantID)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	columns, err := orderexport.ResolveColumns(req.Columns)
+	if err != nil {
+		writeError(w, r, invalidRequest(err))
+		return
+	}
+	dates, err := orderexport.NewDateFormatter(req.Language, req.Timezone)
+	if err != nil {
+		writeError(w, r, invalidRequest(err))
+		return
+	}
+
+	req.Cursor = ""
+	f, err := buildGetAllOrdersFilter(req.GetAllOrdersRequest)
+	if err != nil {
+		writeError(w, r, invalidRequest(err))
+		return
+	}
+
+	limit := h.exportRowLimit
+	if req.Limit > 0 && req.Limit < limit {
+		limit = req.Limit
+	}
+
+	rc := http.NewResponseController(w)
+	// Large exports take longer than the server write timeout.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logging.Warn(r.Context(), "order export write deadline not cleared", "error", err.Error())
+	}
+
+	var (
+		writer orderexport.RowWriter
+		rows   int
+	)
+	err = h.service.ExportAllOrders(r.Context(), f, limit, func(page order.GetAllOrdersResult) error {
+		if writer == nil {
+			// Headers go out with the first page, errors before it still get
+			// a JSON response.
+			w.Header().Set("Content-Type", orderexport.ContentType(format))
+			w.Header().Set("Content-Disposition", `attachment; filename="orders.`+format+`"`)
+			w.Header().Set("X-Export-Total", strconv.FormatInt(page.OrderTotalCount, 10))
+			if page.OrderTotalCount > int64(limit) {
+				w.Header().Set("X-Export-Truncated", "true")
+			}
+			w.WriteHeader(http.StatusOK)
+
+			created, err := orderexport.NewWriter(format, w)
+			if err != nil {
+				return err
+			}
+			writer = created
+			if err := writer.WriteHeader(orderexport.Titles(columns)); err != nil {
+				return err
+			}
+		}
+
+		for _, view := range page.Orders {
+			if err := writer.WriteRow(orderexport.Row(view, columns, dates)); err != nil {
+				return err
+			}
+			rows++
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+	if err == nil && writer != nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		logging.Error(r.Context(), "order export failed", err,
+			"format", format,
+			"rows", rows,
+		)
+		if writer == nil {
+			writeError(w, r, err)
+			return
+		}
+		// The status is already sent, abort so the client sees a broken
+		// download instead of a truncated file.
+		panic(http.ErrAbortHandler)
+	}
+}
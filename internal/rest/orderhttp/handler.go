@@ -1,36 +1,129 @@
 package orderhttp
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"orders-service/internal/app/order"
+	"orders-service/internal/app/responsecache"
+	"orders-service/internal/app/tabstream"
+	"orders-service/internal/apperror"
+	"orders-service/internal/auth"
+	"orders-service/internal/health"
 	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
 
+	"github.com/go-chi/chi/v5"
 	"golang.org/x/sync/errgroup"
 )
 
 type Handler struct {
-	service order.Service
+	service        order.Service
+	tabs           *tabstream.Hub
+	heartbeat      time.Duration
+	exportRowLimit int
+	maxRangeDays   int
+	tenantRanges   map[int64]int
+	verifier       TokenVerifier
+	health         *health.Checker
+	cache          *responsecache.Cache
+	warnings       *order.WarningCache
 }
 
-func NewHandler(service order.Service) *Handler {
-	return &Handler{
-		service: service,
+type Option func(*Handler)
+
+// WithTabStream enables GET /orders/stream backed by the shared hub.
+func WithTabStream(hub *tabstream.Hub) Option {
+	return func(h *Handler) {
+		h.tabs = hub
+	}
+}
+
+func WithStreamHeartbeat(interval time.Duration) Option {
+	return func(h *Handler) {
+		if interval > 0 {
+			h.heartbeat = interval
+		}
+	}
+}
+
+// WithAuthentication requires a bearer token on the order routes and limits
+// the request filters to the tenant, cities and positions of the token.
+func WithAuthentication(verifier TokenVerifier) Option {
+	return func(h *Handler) {
+		h.verifier = verifier
+	}
+}
+
+// WithExportRowLimit caps the rows of one export, a request may only ask
+// for fewer.
+func WithExportRowLimit(limit int) Option {
+	return func(h *Handler) {
+		if limit > 0 {
+			h.exportRowLimit = limit
+		}
+	}
+}
+
+// WithHealth serves /healthz/live and /healthz/ready from the checker.
+func WithHealth(checker *health.Checker) Option {
+	return func(h *Handler) {
+		h.health = checker
+	}
+}
+
+// WithMaxDateRange caps the days between date_from and date_to of the
+// all-orders search, byTenant overrides the cap for single tenants.
+func WithMaxDateRange(days int, byTenant map[int64]int) Option {
+	return func(h *Handler) {
+		if days > 0 {
+			h.maxRangeDays = days
+		}
+		h.tenantRanges = byTenant
+	}
+}
+
+func NewHandler(service order.Service, opts ...Option) *Handler {
+	h := &Handler{
+		service:        service,
+		heartbeat:      defaultStreamHeartbeat,
+		exportRowLimit: defaultExportRowLimit,
+		maxRangeDays:   defaultMaxDateRangeDays,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 func (h *Handler) Orders(w http.ResponseWriter, r *http.Request) {
 	var req WarningFullRequest
 	start := time.Now()
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := authorizeScope(r, &req.OrderBaseRequest); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := validateWarningFullRequest(req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	f := buildWarningFilter(req)
+	cursor, err := parseCursor(req.Cursor, order.ListSort(f.BaseFilter))
+	if err != nil {
+		writeError(w, r, invalidRequest(err))
+		return
+	}
+	f.BaseFilter.Cursor = cursor
 	page := req.Page
 	if page < 0 {
 		page = 0
@@ -40,11 +133,26 @@ func (h *Handler) Orders(w http.ResponseWriter, r *http.Request) {
 		pageSize = 50
 	}
 
-	ctx := r.Context()
+	h.writeCached(w, r, "/orders", req.TenantID, req, func(ctx context.Context) (any, error) {
+		resp, err := h.loadOrders(ctx, f, page, pageSize)
+		if err != nil {
+			logging.Error(ctx, "orders failed", err)
+			return nil, err
+		}
+		metrics.StageDuration.ObserveDuration(start, "orders_request", "total")
+		return resp, nil
+	})
+}
 
+func (h *Handler) loadOrders(
+	ctx context.Context,
+	f order.WarningFilter,
+	page, pageSize int,
+) (ordersResponse, error) {
 	var (
 		totalCount int64
 		prepared   []order.OrderView
+		nextCursor *order.PageCursor
 		tabs       order.GroupOrdersResult
 	)
 
@@ -66,8 +174,11 @@ func (h *Handler) Orders(w http.ResponseWriter, r *http.Request) {
 
 		totalCount = count
 		prepared = p
+		if field, desc, ok := order.CursorSort(order.ListSort(f.BaseFilter)); ok {
+			nextCursor = order.NextPageCursor(formatted, field, desc, pageSize)
+		}
 
-		logging.Info(gctx, "orders branch done", "duration_ms", time.Since(t0).Milliseconds())
+		metrics.StageDuration.ObserveDuration(t0, "orders_request", "orders_branch")
 		return nil
 	})
 
@@ -82,28 +193,113 @@ func (h *Handler) Orders(w http.ResponseWriter, r *http.Request) {
 
 		tabs = res
 
-		logging.Info(gctx, "tabs branch done", "duration_ms", time.Since(t0).Milliseconds())
+		metrics.StageDuration.ObserveDuration(t0, "orders_request", "tabs_branch")
 		return nil
 	})
 
 	if err := g.Wait(); err != nil {
-		http.Error(w, "internal error: "+err.Error(), http.StatusInternalServerError)
-		return
+		return ordersResponse{}, err
 	}
 
-	resp := buildOrdersResponse(totalCount, pageSize, tabs, prepared)
-
-	logging.Info(ctx, "orders request done", "duration_ms", time.Since(start).Milliseconds())
-	writeJSON(w, http.StatusOK, resp)
+	resp := buildOrdersResponse(totalCount, pageSize, tabs, prepared, nextCursor)
+	resp.Degraded = order.Degraded(ctx)
+	return resp, nil
 }
 
 func (h *Handler) AllOrders(w http.ResponseWriter, r *http.Request) {
 	var req GetAllOrdersRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := authorizeScope(r, &req.OrderBaseRequest); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := validateGetAllOrdersRequest(req, h.maxDateRangeDays(req.TenantID)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	f, err := buildGetAllOrdersFilter(req)
+	if err != nil {
+		writeError(w, r, invalidRequest(err))
+		return
+	}
+
+	h.writeCached(w, r, "/orders/all", req.TenantID, req, func(ctx context.Context) (any, error) {
+		result, err := h.service.GetAllOrders(ctx, f)
+		if err != nil {
+			logging.Error(ctx, "all orders failed", err)
+			return nil, err
+		}
+		resp := buildAllOrdersResponse(result)
+		resp.Degraded = order.Degraded(ctx)
+		return resp, nil
+	})
+}
+
+func (h *Handler) Order(w http.ResponseWriter, r *http.Request) {
+	orderID, tenantID, err := parseOrderRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	view, err := h.service.GetOrder(r.Context(), order.OrderFilter{
+		TenantID: tenantID,
+		OrderID:  orderID,
+		Language: r.URL.Query().Get("language"),
+	})
+	if errors.Is(err, order.ErrOrderNotFound) {
+		writeError(w, r, apperror.NotFound("order not found"))
+		return
+	}
+	if err != nil {
+		logging.Error(r.Context(), "order detail failed", err, "order_id", orderID)
+		writeError(w, r, err)
+		return
+	}
+	if !allowsOrder(r, view.CityID, view.PositionID) {
+		writeError(w, r, apperror.NotFound("order not found"))
 		return
 	}
 
+	writeJSON(w, http.StatusOK, mapOrderView(view))
+}
+
+// parseOrderRequest reads the order id of the path and the tenant of the
+// query or the token.
+func parseOrderRequest(r *http.Request) (int64, int64, error) {
+	orderID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || orderID <= 0 {
+		return 0, 0, apperror.Validation("invalid order id")
+	}
+
+	var scope OrderBaseRequest
+	if raw := r.URL.Query().Get("tenant_id"); raw != "" {
+		if scope.TenantID, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return 0, 0, apperror.Validation("invalid tenant_id")
+		}
+	}
+	if err := authorizeScope(r, &scope); err != nil {
+		return 0, 0, err
+	}
+	if scope.TenantID <= 0 {
+		return 0, 0, apperror.Validation("invalid tenant_id")
+	}
+	return orderID, scope.TenantID, nil
+}
+
+// allowsOrder reports whether the caller may see an order of the city and
+// position. An order the caller may not see is reported as missing, so its
+// existence is not disclosed.
+func allowsOrder(r *http.Request, cityID, positionID int64) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	return !ok || (principal.AllowsCity(cityID) && principal.AllowsPosition(positionID))
+}
+
+func buildGetAllOrdersFilter(req GetAllOrdersRequest) (order.GetAllOrdersFilter, error) {
 	page := req.Page
 	if page < 0 {
 		page = 0
@@ -113,6 +309,16 @@ func (h *Handler) AllOrders(w http.ResponseWriter, r *http.Request) {
 		pageSize = 50
 	}
 
+	sortKeys := buildSortKeys(req.Sort)
+	cursor, err := parseCursor(req.Cursor, order.GetAllSort(order.BaseFilter{
+		SortField: req.SortField,
+		SortOrder: req.SortOrder,
+		Sort:      sortKeys,
+	}))
+	if err != nil {
+		return order.GetAllOrdersFilter{}, err
+	}
+
 	attributes := make([]order.SearchAttribute, 0, len(req.Attributes))
 	for _, attribute := range req.Attributes {
 		attributes = append(attributes, order.SearchAttribute{
@@ -121,15 +327,23 @@ func (h *Handler) AllOrders(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	result, err := h.service.GetAllOrders(r.Context(), order.GetAllOrdersFilter{
+	period, err := buildDatePeriod(req)
+	if err != nil {
+		return order.GetAllOrdersFilter{}, err
+	}
+
+	return order.GetAllOrdersFilter{
 		BaseFilter: order.BaseFilter{
-			TenantID:  req.TenantID,
-			CityIDs:   req.CityIDs,
-			Language:  req.Language,
-			Date:      req.Date,
-			Tariffs:   req.Tariffs,
-			SortField: req.SortField,
-			SortOrder: req.SortOrder,
+			TenantID:      req.TenantID,
+			CityIDs:       req.CityIDs,
+			Language:      req.Language,
+			Date:          req.Date,
+			Tariffs:       req.Tariffs,
+			UserPositions: req.UserPositions,
+			SortField:     req.SortField,
+			SortOrder:     req.SortOrder,
+			Sort:          sortKeys,
+			Cursor:        cursor,
 		},
 		Page:         page,
 		PageSize:     pageSize,
@@ -137,14 +351,69 @@ func (h *Handler) AllOrders(w http.ResponseWriter, r *http.Request) {
 		Attributes:   attributes,
 		SearchString: req.SearchString,
 		ShopIDs:      req.ShopIDs,
-	})
-	if err != nil {
-		logging.Error(r.Context(), "all orders failed", err)
-		writeError(w, http.StatusInternalServerError, err)
-		return
+		Period:       period,
+	}, nil
+}
+
+// buildDatePeriod turns date_from/date_to and the time window into a period.
+// The fields are validated before, parse errors only guard direct callers.
+func buildDatePeriod(req GetAllOrdersRequest) (*order.DatePeriod, error) {
+	if req.DateFrom == "" && req.DateTo == "" {
+		return nil, nil
+	}
+	from, fromOK := order.ParseFilterDate(req.DateFrom)
+	to, toOK := order.ParseFilterDate(req.DateTo)
+	if !fromOK || !toOK {
+		return nil, errors.New("invalid date_from or date_to")
+	}
+	period := order.NewDatePeriod(from, to)
+
+	if req.TimeFrom != "" || req.TimeTo != "" {
+		windowFrom, fromOK := order.ParseClock(req.TimeFrom)
+		windowTo, toOK := order.ParseClock(req.TimeTo)
+		if !fromOK || !toOK {
+			return nil, errors.New("invalid time_from or time_to")
+		}
+		period.WindowFrom = windowFrom
+		period.WindowTo = windowTo
 	}
 
-	writeJSON(w, http.StatusOK, buildAllOrdersResponse(result))
+	return &period, nil
+}
+
+// buildSortKeys maps the sort request form, fields are validated before.
+func buildSortKeys(keys []SortKeyRequest) []order.SortKey {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	result := make([]order.SortKey, 0, len(keys))
+	for _, key := range keys {
+		field, ok := order.ParseSortField(key.Field)
+		if !ok {
+			continue
+		}
+		result = append(result, order.SortKey{Field: field, Desc: strings.EqualFold(key.Order, "desc")})
+	}
+	return result
+}
+
+// parseCursor parses the cursor of a request for its sort. Only sorts a
+// cursor can page accept one.
+func parseCursor(raw string, keys []order.SortKey) (*order.PageCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	field, desc, ok := order.CursorSort(keys)
+	if !ok {
+		return nil, apperror.Validation("cursor paging needs a single sort key on status_time, order_id or order_time")
+	}
+	direction := "asc"
+	if desc {
+		direction = "desc"
+	}
+	return order.ParseCursor(raw, field, direction)
 }
 
 func buildWarningFilter(req WarningFullRequest) order.WarningFilter {
@@ -160,6 +429,7 @@ func buildWarningFilter(req WarningFullRequest) order.WarningFilter {
 		UserPositions:  req.UserPositions,
 		SortField:      req.SortField,
 		SortOrder:      req.SortOrder,
+		Sort:           buildSortKeys(req.Sort),
 		Status:         req.Status,
 		Group:          req.Group,
 	}
@@ -171,5 +441,22 @@ func buildWarningFilter(req WarningFullRequest) order.WarningFilter {
 		BadRatingMax:           req.BadRatingMax,
 		StatusCompletedNotPaid: req.StatusCompletedNotPaid,
 		MinRealPrice:           req.MinRealPrice,
+		Rules:                  mapWarningRules(req.WarningRules),
+	}
+}
+
+func mapWarningRules(values map[string]WarningRuleRequest) order.WarningRuleSettings {
+	if len(values) == 0 {
+		return nil
+	}
+
+	result := make(order.WarningRuleSettings, len(values))
+	for code, value := range values {
+		result[code] = order.WarningRuleConfig{
+			Enabled:          value.Enabled,
+			ThresholdMinutes: value.ThresholdMinutes,
+			StatusIDs:        value.StatusIDs,
+		}
 	}
+	return result
 }
@@ -1,16 +1,27 @@
 package orderhttp
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"orders-service/internal/app/order"
+	"orders-service/internal/app/responsecache"
+	"orders-service/internal/app/tabstream"
+	"orders-service/internal/apperror"
+	"orders-service/internal/auth"
+	"orders-service/internal/health"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,6 +44,15 @@ type stubService struct {
 		ctx context.Context,
 		f order.GetAllOrdersFilter,
 	) (order.GetAllOrdersResult, error)
+	getOrderFunc        func(ctx context.Context, f order.OrderFilter) (order.OrderView, error)
+	getOrderHistoryFunc func(ctx context.Context, f order.OrderHistoryFilter) (order.OrderHistory, error)
+	getOrderStatsFunc   func(ctx context.Context, f order.OrderStatsFilter) (order.OrderStats, error)
+	exportAllOrdersFunc func(
+		ctx context.Context,
+		f order.GetAllOrdersFilter,
+		limit int,
+		emit func(page order.GetAllOrdersResult) error,
+	) error
 }
 
 func (s stubService) GetWarningOrder(ctx context.Context, f order.WarningFilter) ([]int64, error) {
@@ -72,6 +92,42 @@ func (s stubService) GetAllOrders(
 	return order.GetAllOrdersResult{}, nil
 }
 
+func (s stubService) GetOrder(ctx context.Context, f order.OrderFilter) (order.OrderView, error) {
+	if s.getOrderFunc != nil {
+		return s.getOrderFunc(ctx, f)
+	}
+	return order.OrderView{}, order.ErrOrderNotFound
+}
+
+func (s stubService) GetOrderHistory(
+	ctx context.Context,
+	f order.OrderHistoryFilter,
+) (order.OrderHistory, error) {
+	if s.getOrderHistoryFunc != nil {
+		return s.getOrderHistoryFunc(ctx, f)
+	}
+	return order.OrderHistory{}, order.ErrOrderNotFound
+}
+
+func (s stubService) GetOrderStats(ctx context.Context, f order.OrderStatsFilter) (order.OrderStats, error) {
+	if s.getOrderStatsFunc != nil {
+		return s.getOrderStatsFunc(ctx, f)
+	}
+	return order.OrderStats{GroupBy: f.GroupBy}, nil
+}
+
+func (s stubService) ExportAllOrders(
+	ctx context.Context,
+	f order.GetAllOrdersFilter,
+	limit int,
+	emit func(page order.GetAllOrdersResult) error,
+) error {
+	if s.exportAllOrdersFunc != nil {
+		return s.exportAllOrdersFunc(ctx, f, limit, emit)
+	}
+	return emit(order.GetAllOrdersResult{})
+}
+
 func TestOrders_BadJSON(t *testing.T) {
 	handler := NewHandler(stubService{})
 	req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(`{`))
@@ -83,8 +139,8 @@ func TestOrders_BadJSON(t *testing.T) {
 
 	var body map[string]any
 	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
-	require.Equal(t, "Bad Request", body["error"])
-	require.NotEmpty(t, body["description"])
+	require.Equal(t, "validation_failed", body["code"])
+	require.Equal(t, "request body is not valid JSON", body["message"])
 }
 
 func TestOrders_SuccessUsesDefaultsAndBuildsResponse(t *testing.T) {
@@ -174,6 +230,44 @@ func TestOrders_SuccessUsesDefaultsAndBuildsResponse(t *testing.T) {
 	require.Equal(t, "q4ccf", resp.Orders[0].OrderNumber)
 }
 
+func TestOrders_PassesWarningRuleOverrides(t *testing.T) {
+	var gotFilter order.WarningFilter
+	service := stubService{
+		getFormattedOrdersByGroupFunc: func(
+			ctx context.Context,
+			f order.WarningFilter,
+			page, pageSize int,
+		) (int64, []order.FormattedOrder, error) {
+			gotFilter = f
+			return 0, nil, nil
+		},
+		getOrdersForTabsFunc: func(ctx context.Context, f order.WarningFilter) (order.GroupOrdersResult, error) {
+			return order.GroupOrdersResult{}, nil
+		},
+		prepareOrdersDataFunc: func(
+			ctx context.Context,
+			orders []order.FormattedOrder,
+			f order.WarningFilter,
+		) ([]order.OrderView, error) {
+			return nil, nil
+		},
+	}
+
+	handler := NewHandler(service)
+	req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(
+		`{"tenant_id":68,"group":"warning","warning_rules":{"driver_late":{"enabled":true,"threshold_minutes":7}}}`,
+	))
+	rec := httptest.NewRecorder()
+
+	handler.Orders(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	rule := gotFilter.Rules[order.WarningDriverLate]
+	require.True(t, rule.IsEnabled())
+	require.Equal(t, int64(7), *rule.ThresholdMinutes)
+	require.Nil(t, rule.StatusIDs)
+}
+
 func TestOrders_ReturnsServiceError(t *testing.T) {
 	service := stubService{
 		getFormattedOrdersByGroupFunc: func(
@@ -213,7 +307,7 @@ func TestOrders_ReturnsServiceError(t *testing.T) {
 	handler.Orders(rec, req)
 
 	require.Equal(t, http.StatusInternalServerError, rec.Code)
-	require.Contains(t, rec.Body.String(), "internal error: formatted failed")
+	require.JSONEq(t, `{"code":"internal_error","message":"internal error"}`, rec.Body.String())
 }
 
 func TestAllOrders_Success(t *testing.T) {
@@ -281,3 +375,959 @@ func TestAllOrders_Success(t *testing.T) {
 	require.Len(t, resp.Orders, 1)
 	require.Equal(t, "q4ccf", resp.Orders[0].OrderNumber)
 }
+
+func TestAllOrders_PassesCursorAndReturnsNextCursor(t *testing.T) {
+	var gotFilter order.GetAllOrdersFilter
+	cursor := order.PageCursor{SortField: "o.order_time", Desc: true, Value: 1777409100, OrderID: 42}
+
+	handler := NewHandler(stubService{
+		getAllOrdersFunc: func(
+			ctx context.Context,
+			f order.GetAllOrdersFilter,
+		) (order.GetAllOrdersResult, error) {
+			gotFilter = f
+			return order.GetAllOrdersResult{
+				CountPerPage: 50,
+				NextCursor:   &order.PageCursor{SortField: "o.order_time", Desc: true, Value: 1777400000, OrderID: 7},
+			}, nil
+		},
+	})
+
+	body := `{"tenant_id":68,"sort_field":"order_time","sort_order":"desc","cursor":"` + cursor.Encode() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders/all", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	handler.AllOrders(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, gotFilter.Cursor)
+	require.Equal(t, cursor, *gotFilter.Cursor)
+
+	var resp allOrdersResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.NextCursor)
+
+	next, err := order.ParseCursor(*resp.NextCursor, "o.order_time", "desc")
+	require.NoError(t, err)
+	require.Equal(t, int64(7), next.OrderID)
+}
+
+func TestAllOrders_RejectsCursorForOtherSort(t *testing.T) {
+	handler := NewHandler(stubService{})
+	cursor := order.PageCursor{SortField: "o.order_time", Desc: true, Value: 1, OrderID: 1}
+
+	body := `{"tenant_id":68,"sort_field":"o.order_id","cursor":"` + cursor.Encode() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders/all", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	handler.AllOrders(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestOrder_Success(t *testing.T) {
+	var gotFilter order.OrderFilter
+	handler := NewHandler(stubService{
+		getOrderFunc: func(ctx context.Context, f order.OrderFilter) (order.OrderView, error) {
+			gotFilter = f
+			return order.OrderView{ID: f.OrderID, OrderNumber: "q4ccf"}, nil
+		},
+	})
+
+	r := chi.NewRouter()
+	RegisterRoutes(r, handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/123?tenant_id=68&language=ru", nil)
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, order.OrderFilter{TenantID: 68, OrderID: 123, Language: "ru"}, gotFilter)
+
+	var resp orderViewResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, int64(123), resp.ID)
+	require.Equal(t, "q4ccf", resp.OrderNumber)
+}
+
+func TestOrder_NotFound(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/123?tenant_id=68", nil)
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "not_found", body["code"])
+	require.NotEmpty(t, body["request_id"])
+}
+
+func TestOrder_RequiresTenant(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/123", nil)
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestOrderHistory_PagesTimeline(t *testing.T) {
+	var gotFilter order.OrderHistoryFilter
+	duration := int64(240)
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{
+		getOrderHistoryFunc: func(ctx context.Context, f order.OrderHistoryFilter) (order.OrderHistory, error) {
+			gotFilter = f
+			return order.OrderHistory{
+				OrderID:    f.OrderID,
+				TotalCount: 7,
+				Changes: []order.OrderHistoryEntry{
+					{
+						Field:          order.ChangeFieldStatus,
+						Value:          "17",
+						ChangeTime:     160,
+						Status:         &order.OrderStatusView{StatusID: 17, Name: "Executing", Category: "works"},
+						StatusDuration: &duration,
+					},
+					{Field: order.ChangeFieldWorker, Value: "77", ChangeTime: 170},
+				},
+			}, nil
+		},
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/123/history?tenant_id=68&language=ru&page=1&page_size=2", nil)
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, order.OrderHistoryFilter{TenantID: 68, OrderID: 123, Language: "ru", Page: 1, PageSize: 2}, gotFilter)
+
+	var body orderHistoryResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, int64(7), body.ChangeTotalCount)
+	require.Equal(t, 2, body.CountPerPage)
+	require.Len(t, body.Changes, 2)
+	require.Equal(t, "Executing", body.Changes[0].Status.Name)
+	require.Equal(t, int64(240), *body.Changes[0].StatusDuration)
+	require.Nil(t, body.Changes[1].Status)
+	require.NotContains(t, rec.Body.String(), `"status":null`)
+}
+
+func TestOrderHistory_ValidatesPaging(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/123/history?tenant_id=68&page=-1&page_size=1000", nil)
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), `"field":"page"`)
+	require.Contains(t, rec.Body.String(), `"field":"page_size"`)
+}
+
+func TestOrderStats_BuildsFilterAndResponse(t *testing.T) {
+	var gotFilter order.OrderStatsFilter
+	avg := 600.0
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{
+		getOrderStatsFunc: func(ctx context.Context, f order.OrderStatsFilter) (order.OrderStats, error) {
+			gotFilter = f
+			return order.OrderStats{
+				GroupBy: f.GroupBy,
+				Total:   order.OrderStatsGroup{Count: 2, CompletedCount: 1, CompletionRatio: 0.5},
+				Groups: []order.OrderStatsGroup{
+					{Key: "1", Count: 2, AvgPredvTime: &avg, CompletedCount: 1, CompletionRatio: 0.5},
+				},
+			}, nil
+		},
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/stats",
+		strings.NewReader(`{"tenant_id":68,"search_status":"completed","tariffs":[1],"group_by":"tariff","page":3}`))
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, order.StatsGroupTariff, gotFilter.GroupBy)
+	require.Equal(t, "completed", gotFilter.SearchStatus)
+	require.Equal(t, []int64{1}, gotFilter.Tariffs)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "tariff", body["groupBy"])
+	groups := body["groups"].([]any)
+	require.Len(t, groups, 1)
+	group := groups[0].(map[string]any)
+	require.Equal(t, "1", group["key"])
+	require.Equal(t, 600.0, group["avgPredvTime"])
+	require.Nil(t, group["avgPredvDistance"])
+	require.NotContains(t, body["total"], "key")
+}
+
+func TestOrderStats_RequiresKnownGroup(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/stats", strings.NewReader(`{"tenant_id":68,"group_by":"payment"}`))
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), `"field":"group_by"`)
+}
+
+func TestAllOrders_ServesIdenticalRequestsFromCache(t *testing.T) {
+	calls := 0
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{
+		getAllOrdersFunc: func(ctx context.Context, f order.GetAllOrdersFilter) (order.GetAllOrdersResult, error) {
+			calls++
+			return order.GetAllOrdersResult{OrderTotalCount: 1, CountPerPage: 50, Orders: []order.OrderView{{ID: 7}}}, nil
+		},
+	}, WithResponseCache(responsecache.New(responsecache.WithRouteTTL("/orders/all", time.Minute)))))
+
+	bodies := []string{
+		`{"tenant_id":68,"city_ids":[26068]}`,
+		`{ "city_ids": [26068], "tenant_id": 68 }`,
+		`{"tenant_id":68,"city_ids":[26069]}`,
+	}
+	var sources, responses []string
+	for _, body := range bodies {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		sources = append(sources, rec.Header().Get("X-Cache"))
+		responses = append(responses, rec.Body.String())
+	}
+
+	require.Equal(t, []string{"MISS", "HIT", "MISS"}, sources)
+	require.Equal(t, 2, calls)
+	require.Equal(t, responses[0], responses[1])
+	require.Contains(t, responses[0], `"orderTotalCount":1`)
+}
+
+func TestAllOrders_WithoutCacheHasNoCacheHeader(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{},
+		WithResponseCache(responsecache.New(responsecache.WithRouteTTL("/orders", time.Minute)))))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{"tenant_id":68}`)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, rec.Header().Get("X-Cache"))
+}
+
+func TestAllOrders_MarksDegradedComponents(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{
+		getAllOrdersFunc: func(ctx context.Context, f order.GetAllOrdersFilter) (order.GetAllOrdersResult, error) {
+			order.Degrade(ctx, order.DegradedActiveOrders, errors.New("redis down"))
+			order.Degrade(ctx, order.DegradedWaitTimes, errors.New("redis down"))
+			return order.GetAllOrdersResult{CountPerPage: 50}, nil
+		},
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{"tenant_id":68}`)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"degraded":["active_orders","wait_times"]`)
+
+	healthy := chi.NewRouter()
+	RegisterRoutes(healthy, NewHandler(stubService{}))
+	rec = httptest.NewRecorder()
+	healthy.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{"tenant_id":68}`)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotContains(t, rec.Body.String(), "degraded")
+}
+
+func TestAllOrders_DoesNotCacheDegradedResponses(t *testing.T) {
+	redisDown := true
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{
+		getAllOrdersFunc: func(ctx context.Context, f order.GetAllOrdersFilter) (order.GetAllOrdersResult, error) {
+			if redisDown {
+				order.Degrade(ctx, order.DegradedActiveOrders, errors.New("redis down"))
+			}
+			return order.GetAllOrdersResult{CountPerPage: 50}, nil
+		},
+	}, WithResponseCache(responsecache.New(responsecache.WithRouteTTL("/orders/all", time.Minute)))))
+
+	allOrders := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{"tenant_id":68}`)))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+	rec := allOrders()
+	require.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+	require.Contains(t, rec.Body.String(), `"degraded":["active_orders"]`)
+
+	redisDown = false
+	rec = allOrders()
+	require.Equal(t, "MISS", rec.Header().Get("X-Cache"), "the degraded response was not cached")
+	require.NotContains(t, rec.Body.String(), "degraded")
+	require.Equal(t, "HIT", allOrders().Header().Get("X-Cache"))
+}
+
+func TestRefreshWarnings_DropsCachedResponsesOfTenant(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{},
+		WithResponseCache(responsecache.New(responsecache.WithRouteTTL("/orders/all", time.Minute))),
+		WithWarningCache(order.NewWarningCache())))
+
+	allOrders := func() string {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{"tenant_id":68}`)))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Header().Get("X-Cache")
+	}
+	require.Equal(t, "MISS", allOrders())
+	require.Equal(t, "HIT", allOrders())
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/warnings/refresh", strings.NewReader(`{"tenant_id":68}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"refreshed":0}`, rec.Body.String())
+	require.Equal(t, "MISS", allOrders())
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/warnings/refresh", strings.NewReader(`{}`)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), "tenant_id")
+}
+
+func TestRefreshWarnings_NotRoutedWithoutWarningCache(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/warnings/refresh", strings.NewReader(`{"tenant_id":68}`)))
+
+	require.NotEqual(t, http.StatusOK, rec.Code)
+}
+
+func TestBuildOrdersResponse_ExposesWarningFreshness(t *testing.T) {
+	refreshedAt := time.Unix(1774000000, 0)
+	resp := buildOrdersResponse(0, 50, order.GroupOrdersResult{
+		Warnings: order.WarningFreshness{RefreshedAt: refreshedAt, Age: 20 * time.Second, Stale: true},
+	}, nil, nil)
+
+	require.Equal(t, &warningsFreshnessResponse{RefreshedAt: 1774000000, AgeSeconds: 20, Stale: true}, resp.Warnings)
+	require.Nil(t, buildOrdersResponse(0, 50, order.GroupOrdersResult{}, nil, nil).Warnings)
+}
+
+func TestMapOrderView_ExposesWarnings(t *testing.T) {
+	resp := mapOrderView(order.OrderView{
+		ID: 1,
+		Warnings: []order.WarningReason{
+			{Code: order.WarningUnpaid},
+			{Code: order.WarningBadReview, Details: map[string]any{"rating": int64(2)}},
+		},
+	})
+
+	require.Equal(t, []string{"unpaid", "bad_review"}, resp.Warnings)
+	require.Equal(t, []warningResponse{
+		{Code: "unpaid"},
+		{Code: "bad_review", Details: map[string]any{"rating": int64(2)}},
+	}, resp.WarningDetails)
+
+	empty := mapOrderView(order.OrderView{ID: 2})
+	body, err := json.Marshal(empty)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"warnings":[]`)
+	require.Contains(t, string(body), `"warning_details":[]`)
+}
+
+type stubTabsReader struct {
+	tabs order.GroupOrdersResult
+}
+
+func (s stubTabsReader) GetOrdersForTabs(ctx context.Context, f order.WarningFilter) (order.GroupOrdersResult, error) {
+	return s.tabs, nil
+}
+
+func readStreamEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	t.Helper()
+	event := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			if len(event) > 0 {
+				return event
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			event["comment"] = line
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		event[key] = value
+	}
+}
+
+func TestOrdersStream_SendsTabsAndHonoursLastEventID(t *testing.T) {
+	hub := tabstream.NewHub(stubTabsReader{tabs: order.GroupOrdersResult{
+		GroupCounts:     map[order.StatusGroup]int{order.StatusGroup0: 2},
+		OrdersForSignal: map[order.StatusGroup][]int64{order.StatusGroup0: {5, 4}},
+	}}, tabstream.WithRefreshInterval(10*time.Millisecond))
+	defer hub.Close()
+
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{},
+		WithTabStream(hub),
+		WithStreamHeartbeat(20*time.Millisecond),
+	))
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/orders/stream?tenant_id=68&city_ids=1,2")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	require.Equal(t, "3000", readStreamEvent(t, reader)["retry"])
+	event := readStreamEvent(t, reader)
+	resp.Body.Close()
+
+	require.Equal(t, "tabs", event["event"])
+	require.NotEmpty(t, event["id"])
+	require.JSONEq(t, `{"orderCounts":{"new":2},"ordersForSignal":{"new":[4,5]}}`, event["data"])
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/orders/stream?tenant_id=68&city_ids=1,2", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", event["id"])
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	reader = bufio.NewReader(resp.Body)
+	readStreamEvent(t, reader)
+	// The client already has the current tabs, only heartbeats follow.
+	require.Equal(t, ": heartbeat", readStreamEvent(t, reader)["comment"])
+}
+
+func TestOrdersStream_RequiresTenant(t *testing.T) {
+	handler := NewHandler(stubService{}, WithTabStream(tabstream.NewHub(stubTabsReader{})))
+	rec := httptest.NewRecorder()
+
+	handler.OrdersStream(rec, httptest.NewRequest(http.MethodGet, "/orders/stream", nil))
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAllOrdersExport_StreamsCSVWithinLimit(t *testing.T) {
+	var (
+		gotFilter order.GetAllOrdersFilter
+		gotLimit  int
+	)
+	handler := NewHandler(stubService{
+		exportAllOrdersFunc: func(
+			ctx context.Context,
+			f order.GetAllOrdersFilter,
+			limit int,
+			emit func(page order.GetAllOrdersResult) error,
+		) error {
+			gotFilter = f
+			gotLimit = limit
+			if err := emit(order.GetAllOrdersResult{
+				OrderTotalCount: 3,
+				Orders: []order.OrderView{{
+					ID:         1,
+					Status:     order.OrderStatusView{Name: "Новый заказ"},
+					CreateTime: 1774051200,
+				}},
+			}); err != nil {
+				return err
+			}
+			return emit(order.GetAllOrdersResult{
+				OrderTotalCount: 3,
+				Orders:          []order.OrderView{{ID: 2}},
+			})
+		},
+	}, WithExportRowLimit(2))
+
+	body, err := json.Marshal(ExportOrdersRequest{
+		GetAllOrdersRequest: GetAllOrdersRequest{
+			OrderBaseRequest: OrderBaseRequest{TenantID: 68, Language: "ru"},
+			SearchStatus:     "all",
+		},
+		Columns:  []string{"id", "status", "create_time"},
+		Timezone: "Europe/Moscow",
+		Limit:    5,
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/all/export?format=csv", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.AllOrdersExport(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 2, gotLimit)
+	require.Equal(t, int64(68), gotFilter.TenantID)
+	require.Equal(t, "all", gotFilter.SearchStatus)
+	require.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Header().Get("Content-Disposition"), `filename="orders.csv"`)
+	require.Equal(t, "3", rec.Header().Get("X-Export-Total"))
+	require.Equal(t, "true", rec.Header().Get("X-Export-Truncated"))
+	require.Equal(t,
+		"\ufeffID,Status,Created\n1,Новый заказ,21.03.2026 03:00\n2,,\n",
+		rec.Body.String(),
+	)
+}
+
+func TestAllOrdersExport_RejectsBadRequests(t *testing.T) {
+	handler := NewHandler(stubService{})
+
+	tests := []struct {
+		name  string
+		query string
+		body  string
+	}{
+		{"format", "?format=pdf", `{"tenant_id":68}`},
+		{"column", "?format=csv", `{"tenant_id":68,"columns":["password"]}`},
+		{"timezone", "?format=xlsx", `{"tenant_id":68,"timezone":"Mars/Base"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/orders/all/export"+tt.query, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			handler.AllOrdersExport(rec, req)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestAllOrdersExport_ReturnsJSONErrorBeforeFirstRow(t *testing.T) {
+	handler := NewHandler(stubService{
+		exportAllOrdersFunc: func(
+			ctx context.Context,
+			f order.GetAllOrdersFilter,
+			limit int,
+			emit func(page order.GetAllOrdersResult) error,
+		) error {
+			return errors.New("mysql down")
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/all/export", strings.NewReader(`{"tenant_id":68}`))
+	rec := httptest.NewRecorder()
+
+	handler.AllOrdersExport(rec, req)
+
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Contains(t, rec.Body.String(), `"code":"internal_error"`)
+	require.NotContains(t, rec.Body.String(), "mysql down")
+}
+
+type stubVerifier map[string]auth.Principal
+
+func (s stubVerifier) Verify(token string) (auth.Principal, error) {
+	principal, ok := s[token]
+	if !ok {
+		return auth.Principal{}, auth.ErrInvalidToken
+	}
+	return principal, nil
+}
+
+func newAuthRouter(service stubService) chi.Router {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(service, WithAuthentication(stubVerifier{
+		"dispatcher": {TenantID: 68, CityIDs: []int64{26068, 26069}, Positions: []int64{1}},
+	})))
+	return r
+}
+
+func TestAuth_RejectsMissingOrInvalidToken(t *testing.T) {
+	r := newAuthRouter(stubService{})
+
+	for _, header := range []string{"", "Basic abc", "Bearer unknown"} {
+		req := httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{}`))
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusUnauthorized, rec.Code, header)
+		require.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAuth_ClampsOmittedScopeToToken(t *testing.T) {
+	var gotFilter order.GetAllOrdersFilter
+	r := newAuthRouter(stubService{
+		getAllOrdersFunc: func(ctx context.Context, f order.GetAllOrdersFilter) (order.GetAllOrdersResult, error) {
+			gotFilter = f
+			return order.GetAllOrdersResult{}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{"city_ids":[26069]}`))
+	req.Header.Set("Authorization", "Bearer dispatcher")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, int64(68), gotFilter.TenantID)
+	require.Equal(t, []int64{26069}, gotFilter.CityIDs)
+
+	req = httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{"tenant_id":68}`))
+	req.Header.Set("Authorization", "Bearer dispatcher")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, []int64{26068, 26069}, gotFilter.CityIDs)
+}
+
+func TestAuth_RejectsScopeBeyondToken(t *testing.T) {
+	r := newAuthRouter(stubService{})
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"tenant", `{"tenant_id":69}`, "forbidden_tenant"},
+		{"city", `{"city_ids":[26068,1]}`, "forbidden_city"},
+		{"position", `{"user_positions":[1,5]}`, "forbidden_position"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer dispatcher")
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusForbidden, rec.Code)
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			require.Equal(t, tt.code, body["code"])
+			require.NotEmpty(t, body["details"])
+		})
+	}
+}
+
+func TestAuth_LimitsOrderSearchesToPositionsOfToken(t *testing.T) {
+	orders := []order.FormattedOrder{
+		{OrderID: 1, CityID: 26068, PositionID: 1},
+		{OrderID: 2, CityID: 26068, PositionID: 5},
+	}
+	visible := func(f order.GetAllOrdersFilter) []order.OrderView {
+		spec := order.GetAllFilterSpec(f)
+		var views []order.OrderView
+		for _, o := range orders {
+			if spec.Matches(o) {
+				views = append(views, order.OrderView{ID: o.OrderID})
+			}
+		}
+		return views
+	}
+	var statsFilter, exportFilter order.GetAllOrdersFilter
+	r := newAuthRouter(stubService{
+		getAllOrdersFunc: func(ctx context.Context, f order.GetAllOrdersFilter) (order.GetAllOrdersResult, error) {
+			views := visible(f)
+			return order.GetAllOrdersResult{OrderTotalCount: int64(len(views)), Orders: views}, nil
+		},
+		getOrderStatsFunc: func(ctx context.Context, f order.OrderStatsFilter) (order.OrderStats, error) {
+			statsFilter = f.GetAllOrdersFilter
+			return order.OrderStats{}, nil
+		},
+		exportAllOrdersFunc: func(ctx context.Context, f order.GetAllOrdersFilter, limit int, emit func(page order.GetAllOrdersResult) error) error {
+			exportFilter = f
+			return nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{"tenant_id":68}`))
+	req.Header.Set("Authorization", "Bearer dispatcher")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Orders []struct {
+			ID int64 `json:"id"`
+		} `json:"orders"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Orders, 1)
+	require.Equal(t, int64(1), body.Orders[0].ID)
+
+	for target, body := range map[string]string{
+		"/orders/stats":      `{"tenant_id":68,"group_by":"tariff"}`,
+		"/orders/all/export": `{"tenant_id":68}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer dispatcher")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	require.Equal(t, []int64{1}, statsFilter.UserPositions)
+	require.Equal(t, []int64{1}, exportFilter.UserPositions)
+}
+
+func TestAuth_HidesOrderOfOtherCity(t *testing.T) {
+	r := newAuthRouter(stubService{
+		getOrderFunc: func(ctx context.Context, f order.OrderFilter) (order.OrderView, error) {
+			require.Equal(t, int64(68), f.TenantID)
+			return order.OrderView{ID: f.OrderID, CityID: 1, PositionID: 1}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/123", nil)
+	req.Header.Set("Authorization", "Bearer dispatcher")
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAuth_HidesHistoryOfOtherPosition(t *testing.T) {
+	r := newAuthRouter(stubService{
+		getOrderHistoryFunc: func(ctx context.Context, f order.OrderHistoryFilter) (order.OrderHistory, error) {
+			require.Equal(t, int64(68), f.TenantID)
+			return order.OrderHistory{OrderID: f.OrderID, CityID: 26068, PositionID: 5}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/123/history", nil)
+	req.Header.Set("Authorization", "Bearer dispatcher")
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestMetrics_RecordsRequestsByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{
+		getOrderFunc: func(ctx context.Context, f order.OrderFilter) (order.OrderView, error) {
+			return order.OrderView{ID: f.OrderID}, nil
+		},
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/4242?tenant_id=68", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	require.Contains(t, rec.Body.String(),
+		`orders_http_request_duration_seconds_count{route="/orders/{id}",method="GET",status="200"}`)
+	require.NotContains(t, rec.Body.String(), "/orders/4242")
+}
+
+func TestHealth_ProbesSkipAuthentication(t *testing.T) {
+	checker := health.NewChecker()
+	checker.Add("mysql", func(ctx context.Context) error { return nil })
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(stubService{},
+		WithAuthentication(stubVerifier{}),
+		WithHealth(checker),
+	))
+
+	for _, path := range []string{"/healthz/live", "/healthz/ready"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		require.Equal(t, http.StatusOK, rec.Code, path)
+		require.Contains(t, rec.Body.String(), `"mysql":{"status":"up"`, path)
+	}
+}
+
+func TestOrders_ReportsFieldOfMistypedValue(t *testing.T) {
+	handler := NewHandler(stubService{})
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"tenant_id":"68"}`))
+	rec := httptest.NewRecorder()
+
+	handler.Orders(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.JSONEq(t, `{
+		"code": "validation_failed",
+		"message": "invalid value for tenant_id",
+		"details": [{"field": "tenant_id", "message": "must be of type number"}]
+	}`, rec.Body.String())
+}
+
+func TestOrders_MapsUpstreamFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "timeout"},
+		{"connection", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, http.StatusServiceUnavailable, "upstream_unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(stubService{
+				getFormattedOrdersByGroupFunc: func(ctx context.Context, f order.WarningFilter, page, pageSize int) (int64, []order.FormattedOrder, error) {
+					return 0, nil, tt.err
+				},
+				getOrdersForTabsFunc: func(ctx context.Context, f order.WarningFilter) (order.GroupOrdersResult, error) {
+					return order.GroupOrdersResult{}, tt.err
+				},
+			})
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"tenant_id":68}`))
+			rec := httptest.NewRecorder()
+
+			handler.Orders(rec, req)
+
+			require.Equal(t, tt.status, rec.Code)
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			require.Equal(t, tt.code, body["code"])
+			require.NotContains(t, rec.Body.String(), "refused")
+		})
+	}
+}
+
+func TestOrders_ReportsEveryInvalidField(t *testing.T) {
+	handler := NewHandler(stubService{})
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{
+		"city_ids": [26068, -1],
+		"date": "yesterday",
+		"page_size": 100000,
+		"sort_field": "o.phone",
+		"sort_order": "up"
+	}`))
+	rec := httptest.NewRecorder()
+
+	handler.Orders(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var body struct {
+		Code    string                `json:"code"`
+		Details []apperror.FieldError `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "validation_failed", body.Code)
+
+	fields := make([]string, 0, len(body.Details))
+	for _, detail := range body.Details {
+		fields = append(fields, detail.Field)
+	}
+	require.Equal(t, []string{"tenant_id", "city_ids", "date", "sort_field", "sort_order", "page_size"}, fields)
+}
+
+func TestAllOrders_RejectsUnknownFieldsAndLargeBodies(t *testing.T) {
+	handler := NewHandler(stubService{})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(`{"tenant_id":68,"tenant":68}`))
+	rec := httptest.NewRecorder()
+	handler.AllOrders(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), `"field":"tenant"`)
+
+	large := `{"tenant_id":68,"search_string":{"phone":"` + strings.Repeat("1", maxRequestBodyBytes) + `"}}`
+	req = httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(large))
+	rec = httptest.NewRecorder()
+	handler.AllOrders(rec, req)
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	require.Contains(t, rec.Body.String(), `"code":"request_too_large"`)
+}
+
+func TestAllOrders_DateRangeIsCappedPerTenant(t *testing.T) {
+	var got order.GetAllOrdersFilter
+	handler := NewHandler(stubService{
+		getAllOrdersFunc: func(ctx context.Context, f order.GetAllOrdersFilter) (order.GetAllOrdersResult, error) {
+			got = f
+			return order.GetAllOrdersResult{}, nil
+		},
+	}, WithMaxDateRange(7, map[int64]int{68: 31}))
+
+	body := `{"tenant_id":%d,"date_from":"01.04.2026","date_to":"15.04.2026","time_from":"20:00","time_to":"08:00"}`
+
+	rec := httptest.NewRecorder()
+	handler.AllOrders(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(fmt.Sprintf(body, 68))))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, got.Period)
+	require.Equal(t, 15, got.Period.Days())
+	require.Equal(t, int64(20*3600), got.Period.WindowFrom)
+	require.Equal(t, int64(8*3600), got.Period.WindowTo)
+
+	rec = httptest.NewRecorder()
+	handler.AllOrders(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(fmt.Sprintf(body, 70))))
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), `"field":"date_to"`)
+	require.Contains(t, rec.Body.String(), "7 days")
+}
+
+func TestAllOrders_MapsSortKeysAndRejectsInvalidOnes(t *testing.T) {
+	var got order.GetAllOrdersFilter
+	handler := NewHandler(stubService{
+		getAllOrdersFunc: func(ctx context.Context, f order.GetAllOrdersFilter) (order.GetAllOrdersResult, error) {
+			got = f
+			return order.GetAllOrdersResult{}, nil
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	handler.AllOrders(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(
+		`{"tenant_id":68,"sort":[{"field":"status_id"},{"field":"o.order_time","order":"desc"}]}`)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, []order.SortKey{
+		{Field: order.SortFieldStatusID},
+		{Field: order.SortFieldOrderTime, Desc: true},
+	}, got.Sort)
+
+	rec = httptest.NewRecorder()
+	handler.AllOrders(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(
+		`{"tenant_id":68,"sort":[{"field":"wait"},{"field":"city_id","order":"up"},{"field":"city_id"}]}`)))
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), `"field":"sort[0].field"`)
+	require.Contains(t, rec.Body.String(), `"field":"sort[1].order"`)
+	require.Contains(t, rec.Body.String(), `"field":"sort[2].field"`)
+
+	cursor := order.PageCursor{SortField: "o.order_id", OrderID: 10}.Encode()
+	rec = httptest.NewRecorder()
+	handler.AllOrders(rec, httptest.NewRequest(http.MethodPost, "/orders/all", strings.NewReader(
+		`{"tenant_id":68,"cursor":"`+cursor+`","sort":[{"field":"status_id"},{"field":"order_id"}]}`)))
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), "cursor paging")
+}
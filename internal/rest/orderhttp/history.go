@@ -0,0 +1,78 @@
+package orderhttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"orders-service/internal/app/order"
+	"orders-service/internal/apperror"
+	"orders-service/internal/logging"
+)
+
+const defaultHistoryPageSize = 100
+
+// OrderHistory serves the change timeline of an order, oldest change first,
+// paged with page and page_size of the query.
+func (h *Handler) OrderHistory(w http.ResponseWriter, r *http.Request) {
+	orderID, tenantID, err := parseOrderRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	page, pageSize, err := parseHistoryPage(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	history, err := h.service.GetOrderHistory(r.Context(), order.OrderHistoryFilter{
+		TenantID: tenantID,
+		OrderID:  orderID,
+		Language: r.URL.Query().Get("language"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if errors.Is(err, order.ErrOrderNotFound) {
+		writeError(w, r, apperror.NotFound("order not found"))
+		return
+	}
+	if err != nil {
+		logging.Error(r.Context(), "order history failed", err, "order_id", orderID)
+		writeError(w, r, err)
+		return
+	}
+	if !allowsOrder(r, history.CityID, history.PositionID) {
+		writeError(w, r, apperror.NotFound("order not found"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, buildOrderHistoryResponse(history, pageSize))
+}
+
+func parseHistoryPage(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+	page, pageSize := 0, defaultHistoryPageSize
+
+	var errs fieldErrors
+	if raw := query.Get("page"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			errs.add("page", "must be a non-negative number")
+		}
+		page = value
+	}
+	if raw := query.Get("page_size"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			errs.add("page_size", "must be a positive number")
+		}
+		pageSize = value
+	}
+	errs.page(pageSize)
+
+	if err := errs.err(); err != nil {
+		return 0, 0, err
+	}
+	return page, pageSize, nil
+}
@@ -7,6 +7,7 @@ func buildOrdersResponse(
 	pageSize int,
 	tabs order.GroupOrdersResult,
 	orders []order.OrderView,
+	nextCursor *order.PageCursor,
 ) ordersResponse {
 	return ordersResponse{
 		OrderTotalCount: totalCount,
@@ -14,6 +15,19 @@ func buildOrdersResponse(
 		OrderCounts:     mapStatusGroupCounts(tabs.GroupCounts),
 		CountPerPage:    pageSize,
 		Orders:          mapOrderViews(orders),
+		NextCursor:      encodeCursor(nextCursor),
+		Warnings:        mapWarningFreshness(tabs.Warnings),
+	}
+}
+
+func mapWarningFreshness(freshness order.WarningFreshness) *warningsFreshnessResponse {
+	if freshness.RefreshedAt.IsZero() {
+		return nil
+	}
+	return &warningsFreshnessResponse{
+		RefreshedAt: freshness.RefreshedAt.Unix(),
+		AgeSeconds:  freshness.Age.Seconds(),
+		Stale:       freshness.Stale,
 	}
 }
 
@@ -22,9 +36,74 @@ func buildAllOrdersResponse(result order.GetAllOrdersResult) allOrdersResponse {
 		OrderTotalCount: result.OrderTotalCount,
 		CountPerPage:    result.CountPerPage,
 		Orders:          mapOrderViews(result.Orders),
+		NextCursor:      encodeCursor(result.NextCursor),
+	}
+}
+
+func buildOrderHistoryResponse(history order.OrderHistory, pageSize int) orderHistoryResponse {
+	changes := make([]orderHistoryEntryResponse, 0, len(history.Changes))
+	for _, change := range history.Changes {
+		entry := orderHistoryEntryResponse{
+			Field:          change.Field,
+			Value:          change.Value,
+			ChangeTime:     change.ChangeTime,
+			StatusDuration: change.StatusDuration,
+		}
+		if change.Status != nil {
+			entry.Status = &statusResponse{
+				StatusID: change.Status.StatusID,
+				Name:     change.Status.Name,
+				Category: change.Status.Category,
+				Color:    change.Status.Color,
+			}
+		}
+		changes = append(changes, entry)
+	}
+
+	return orderHistoryResponse{
+		OrderID:          history.OrderID,
+		ChangeTotalCount: history.TotalCount,
+		CountPerPage:     pageSize,
+		Changes:          changes,
+	}
+}
+
+func buildOrderStatsResponse(stats order.OrderStats) orderStatsResponse {
+	groups := make([]orderStatsGroupResponse, 0, len(stats.Groups))
+	for _, group := range stats.Groups {
+		groups = append(groups, mapOrderStatsGroup(group))
+	}
+
+	return orderStatsResponse{
+		GroupBy: stats.GroupBy,
+		Total:   mapOrderStatsGroup(stats.Total),
+		Groups:  groups,
 	}
 }
 
+func mapOrderStatsGroup(group order.OrderStatsGroup) orderStatsGroupResponse {
+	return orderStatsGroupResponse{
+		Key:              group.Key,
+		Count:            group.Count,
+		SummaryCost:      group.SummaryCost,
+		AvgPredvDistance: group.AvgPredvDistance,
+		AvgPredvTime:     group.AvgPredvTime,
+		CompletedCount:   group.CompletedCount,
+		RejectedCount:    group.RejectedCount,
+		CompletionRatio:  group.CompletionRatio,
+		RejectionRatio:   group.RejectionRatio,
+	}
+}
+
+func encodeCursor(cursor *order.PageCursor) *string {
+	if cursor == nil {
+		return nil
+	}
+
+	value := cursor.Encode()
+	return &value
+}
+
 func mapStatusGroupIDs(values map[order.StatusGroup][]int64) map[string][]int64 {
 	result := make(map[string][]int64, len(values))
 	for key, ids := range values {
@@ -44,94 +123,110 @@ func mapStatusGroupCounts(values map[order.StatusGroup]int) map[string]int {
 func mapOrderViews(values []order.OrderView) []orderViewResponse {
 	result := make([]orderViewResponse, 0, len(values))
 	for _, value := range values {
-		addresses := make([]addressResponse, 0, len(value.Address))
-		for _, address := range value.Address {
-			addresses = append(addresses, addressResponse{
-				ID:      address.ID,
-				City:    address.City,
-				Street:  address.Street,
-				Label:   address.Label,
-				House:   address.House,
-				Apt:     address.Apt,
-				Parking: address.Parking,
-				Type:    address.Type,
-			})
-		}
+		result = append(result, mapOrderView(value))
+	}
 
-		options := make([]optionResponse, 0, len(value.Options))
-		for _, option := range value.Options {
-			options = append(options, optionResponse{
-				OptionID: option.OptionID,
-				Name:     option.Name,
-				Quantity: option.Quantity,
-			})
-		}
+	return result
+}
 
-		var worker *workerResponse
-		if value.Worker != nil {
-			worker = &workerResponse{
-				WorkerID: value.Worker.WorkerID,
-				Callsign: value.Worker.Callsign,
-				Name:     value.Worker.Name,
-				Phone:    value.Worker.Phone,
-			}
-		}
+func mapOrderView(value order.OrderView) orderViewResponse {
+	addresses := make([]addressResponse, 0, len(value.Address))
+	for _, address := range value.Address {
+		addresses = append(addresses, addressResponse{
+			ID:      address.ID,
+			City:    address.City,
+			Street:  address.Street,
+			Label:   address.Label,
+			House:   address.House,
+			Apt:     address.Apt,
+			Parking: address.Parking,
+			Type:    address.Type,
+		})
+	}
 
-		var car *carResponse
-		if value.Car != nil {
-			car = &carResponse{
-				CarID:  value.Car.CarID,
-				Name:   value.Car.Name,
-				Color:  value.Car.Color,
-				Number: value.Car.Number,
-			}
+	options := make([]optionResponse, 0, len(value.Options))
+	for _, option := range value.Options {
+		options = append(options, optionResponse{
+			OptionID: option.OptionID,
+			Name:     option.Name,
+			Quantity: option.Quantity,
+		})
+	}
+
+	var worker *workerResponse
+	if value.Worker != nil {
+		worker = &workerResponse{
+			WorkerID: value.Worker.WorkerID,
+			Callsign: value.Worker.Callsign,
+			Name:     value.Worker.Name,
+			Phone:    value.Worker.Phone,
 		}
+	}
 
-		result = append(result, orderViewResponse{
-			ID:             value.ID,
-			OrderNumber:    value.OrderNumber,
-			OrderIDForSort: value.OrderIDForSort,
-			Status: statusResponse{
-				StatusID: value.Status.StatusID,
-				Name:     value.Status.Name,
-				Category: value.Status.Category,
-				Color:    value.Status.Color,
-			},
-			DateForSort: value.DateForSort,
-			Date:        value.Date,
-			Address:     addresses,
-			CityID:      value.CityID,
-			Phone:       value.Phone,
-			Device:      value.Device,
-			DeviceName:  value.DeviceName,
-			Client: clientResponse{
-				ClientID: value.Client.ClientID,
-				Phone:    value.Client.Phone,
-				Name:     value.Client.Name,
-				LastName: value.Client.LastName,
-			},
-			Dispatcher: value.Dispatcher,
-			Worker:     worker,
-			Car:        car,
-			Tariff: tariffResponse{
-				TariffID:          value.Tariff.TariffID,
-				Name:              value.Tariff.Name,
-				QuantitativeTitle: value.Tariff.QuantitativeTitle,
-				PriceForUnit:      value.Tariff.PriceForUnit,
-				UnitName:          value.Tariff.UnitName,
-			},
-			Options:      options,
-			Comment:      value.Comment,
-			SummaryCost:  value.SummaryCost,
-			StatusTime:   value.StatusTime,
-			TimeToClient: value.TimeToClient,
-			WaitTime:     value.WaitTime,
-			CreateTime:   value.CreateTime,
-			OrderTime:    value.OrderTime,
-			PositionID:   value.PositionID,
-			UnitQuantity: value.UnitQuantity,
+	warnings := make([]string, 0, len(value.Warnings))
+	warningDetails := make([]warningResponse, 0, len(value.Warnings))
+	for _, reason := range value.Warnings {
+		warnings = append(warnings, reason.Code)
+		warningDetails = append(warningDetails, warningResponse{
+			Code:    reason.Code,
+			Details: reason.Details,
 		})
 	}
 
-	return result
+	var car *carResponse
+	if value.Car != nil {
+		car = &carResponse{
+			CarID:  value.Car.CarID,
+			Name:   value.Car.Name,
+			Color:  value.Car.Color,
+			Number: value.Car.Number,
+		}
+	}
+
+	return orderViewResponse{
+		ID:             value.ID,
+		OrderNumber:    value.OrderNumber,
+		OrderIDForSort: value.OrderIDForSort,
+		Status: statusResponse{
+			StatusID: value.Status.StatusID,
+			Name:     value.Status.Name,
+			Category: value.Status.Category,
+			Color:    value.Status.Color,
+		},
+		DateForSort: value.DateForSort,
+		Date:        value.Date,
+		Address:     addresses,
+		CityID:      value.CityID,
+		Phone:       value.Phone,
+		Device:      value.Device,
+		DeviceName:  value.DeviceName,
+		Client: clientResponse{
+			ClientID: value.Client.ClientID,
+			Phone:    value.Client.Phone,
+			Name:     value.Client.Name,
+			LastName: value.Client.LastName,
+		},
+		Dispatcher: value.Dispatcher,
+		Worker:     worker,
+		Car:        car,
+		Tariff: tariffResponse{
+			TariffID:          value.Tariff.TariffID,
+			Name:              value.Tariff.Name,
+			QuantitativeTitle: value.Tariff.QuantitativeTitle,
+			PriceForUnit:      value.Tariff.PriceForUnit,
+			UnitName:          value.Tariff.UnitName,
+		},
+		Options:        options,
+		Comment:        value.Comment,
+		SummaryCost:    value.SummaryCost,
+		StatusTime:     value.StatusTime,
+		TimeToClient:   value.TimeToClient,
+		WaitTime:       value.WaitTime,
+		CreateTime:     value.CreateTime,
+		OrderTime:      value.OrderTime,
+		PositionID:     value.PositionID,
+		UnitQuantity:   value.UnitQuantity,
+		Warnings:       warnings,
+		WarningDetails: warningDetails,
+	}
 }
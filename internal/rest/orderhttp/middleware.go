@@ -4,9 +4,13 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"net/http"
+	"strconv"
 	"time"
 
 	"orders-service/internal/logging"
+	"orders-service/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func RequestContextMiddleware(next http.Handler) http.Handler {
@@ -43,6 +47,23 @@ func AccessLogMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// MetricsMiddleware records the request duration by route pattern, so
+// /orders/{id} is one series and not one per order.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		metrics.HTTPRequestDuration.ObserveDuration(start, route, r.Method, strconv.Itoa(recorder.statusCode))
+	})
+}
+
 type statusRecorder struct {
 	http.ResponseWriter
 	statusCode int
@@ -53,6 +74,12 @@ func (r *statusRecorder) WriteHeader(statusCode int) {
 	r.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap lets http.ResponseController reach the flusher and the deadlines of
+// the underlying writer, which the order stream needs.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func newRequestID() string {
 	var value [16]byte
 	if _, err := rand.Read(value[:]); err != nil {
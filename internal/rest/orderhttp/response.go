@@ -2,18 +2,99 @@ package orderhttp
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
+
+	"orders-service/internal/apperror"
+	"orders-service/internal/logging"
 )
 
+type errorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
-func writeError(w http.ResponseWriter, status int, err error) {
-	writeJSON(w, status, map[string]any{
-		"error":       http.StatusText(status),
-		"description": err.Error(),
+// writeError sends err in the error shape of the API. Errors without a kind
+// are reported by apperror.From, so their text never reaches the client;
+// handlers log the cause before calling it.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := apperror.From(err)
+	writeJSON(w, appErr.Kind.Status(), errorResponse{
+		Code:      appErr.ErrorCode(),
+		Message:   appErr.Message,
+		RequestID: logging.RequestID(r.Context()),
+		Details:   appErr.Details,
 	})
 }
+
+// invalidRequest marks an error of the request parsing as a validation error.
+// Only errors of this package and the filter parsers go through it, their
+// text is meant for the client.
+func invalidRequest(err error) error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperror.Validation(err.Error())
+}
+
+// decodeJSON reads the request body into target. The body is limited to
+// maxRequestBodyBytes and unknown fields are rejected. Decoder messages name
+// Go types, the client gets the JSON field instead.
+func decodeJSON(w http.ResponseWriter, r *http.Request, target any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(target)
+	if err == nil && decoder.More() {
+		err = errors.New("unexpected data after the JSON object")
+	}
+	if err == nil {
+		return nil
+	}
+
+	var (
+		tooLarge *http.MaxBytesError
+		typeErr  *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		return apperror.New(apperror.ErrTooLarge,
+			fmt.Sprintf("request body must not be larger than %d bytes", maxRequestBodyBytes))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return apperror.Wrap(apperror.ErrValidation, "invalid value for "+typeErr.Field, err).
+			WithDetails([]apperror.FieldError{{Field: typeErr.Field, Message: "must be of type " + jsonType(typeErr.Type.Kind())}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return apperror.Wrap(apperror.ErrValidation, "unknown field "+field, err).
+			WithDetails([]apperror.FieldError{{Field: field, Message: "is not a known field"}})
+	default:
+		return apperror.Wrap(apperror.ErrValidation, "request body is not valid JSON", err)
+	}
+}
+
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "number"
+	}
+}
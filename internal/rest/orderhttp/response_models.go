@@ -6,12 +6,63 @@ type ordersResponse struct {
 	OrderCounts     map[string]int      `json:"orderCounts"`
 	CountPerPage    int                 `json:"countPerPage"`
 	Orders          []orderViewResponse `json:"orders"`
+	NextCursor      *string             `json:"next_cursor"`
+	// Warnings is set when the warning tab comes from the warning cache.
+	Warnings *warningsFreshnessResponse `json:"warningsFreshness,omitempty"`
+	// Degraded lists the components the response was served without.
+	Degraded []string `json:"degraded,omitempty"`
+}
+
+type warningsFreshnessResponse struct {
+	RefreshedAt int64   `json:"refreshedAt"`
+	AgeSeconds  float64 `json:"ageSeconds"`
+	Stale       bool    `json:"stale"`
+}
+
+type refreshWarningsResponse struct {
+	Refreshed int `json:"refreshed"`
 }
 
 type allOrdersResponse struct {
 	OrderTotalCount int64               `json:"orderTotalCount"`
 	CountPerPage    int                 `json:"countPerPage"`
 	Orders          []orderViewResponse `json:"orders"`
+	NextCursor      *string             `json:"next_cursor"`
+	Degraded        []string            `json:"degraded,omitempty"`
+}
+
+type orderHistoryResponse struct {
+	OrderID          int64                       `json:"orderId"`
+	ChangeTotalCount int64                       `json:"changeTotalCount"`
+	CountPerPage     int                         `json:"countPerPage"`
+	Changes          []orderHistoryEntryResponse `json:"changes"`
+}
+
+type orderHistoryEntryResponse struct {
+	Field          string          `json:"field"`
+	Value          string          `json:"value"`
+	ChangeTime     int64           `json:"change_time"`
+	Status         *statusResponse `json:"status,omitempty"`
+	StatusDuration *int64          `json:"status_duration,omitempty"`
+}
+
+type orderStatsResponse struct {
+	GroupBy  string                    `json:"groupBy"`
+	Total    orderStatsGroupResponse   `json:"total"`
+	Groups   []orderStatsGroupResponse `json:"groups"`
+	Degraded []string                  `json:"degraded,omitempty"`
+}
+
+type orderStatsGroupResponse struct {
+	Key              string   `json:"key,omitempty"`
+	Count            int64    `json:"count"`
+	SummaryCost      float64  `json:"summaryCost"`
+	AvgPredvDistance *float64 `json:"avgPredvDistance"`
+	AvgPredvTime     *float64 `json:"avgPredvTime"`
+	CompletedCount   int64    `json:"completedCount"`
+	RejectedCount    int64    `json:"rejectedCount"`
+	CompletionRatio  float64  `json:"completionRatio"`
+	RejectionRatio   float64  `json:"rejectionRatio"`
 }
 
 type orderViewResponse struct {
@@ -41,6 +92,13 @@ type orderViewResponse struct {
 	OrderTime      int64             `json:"order_time"`
 	PositionID     int64             `json:"positionId"`
 	UnitQuantity   *float64          `json:"unit_quantity,omitempty"`
+	Warnings       []string          `json:"warnings"`
+	WarningDetails []warningResponse `json:"warning_details"`
+}
+
+type warningResponse struct {
+	Code    string         `json:"code"`
+	Details map[string]any `json:"details,omitempty"`
 }
 
 type statusResponse struct {
@@ -3,14 +3,35 @@ package orderhttp
 import (
 	"net/http"
 
+	"orders-service/internal/metrics"
+
 	"github.com/go-chi/chi/v5"
 )
 
 func RegisterRoutes(r chi.Router, handler *Handler) {
 	r.Use(RequestContextMiddleware)
 	r.Use(AccessLogMiddleware)
-	r.Post("/orders", handler.Orders)
-	r.Post("/orders/all", handler.AllOrders)
+	r.Use(MetricsMiddleware)
+	r.Group(func(r chi.Router) {
+		if handler.verifier != nil {
+			r.Use(AuthMiddleware(handler.verifier))
+		}
+		r.Post("/orders", handler.Orders)
+		r.Post("/orders/all", handler.AllOrders)
+		r.Post("/orders/all/export", handler.AllOrdersExport)
+		r.Post("/orders/stats", handler.OrderStats)
+		r.Get("/orders/stream", handler.OrdersStream)
+		r.Get("/orders/{id}", handler.Order)
+		r.Get("/orders/{id}/history", handler.OrderHistory)
+		if handler.warnings != nil {
+			r.Post("/orders/warnings/refresh", handler.RefreshWarnings)
+		}
+	})
+	r.Handle("/metrics", metrics.Default.Handler())
+	if handler.health != nil {
+		r.Handle("/healthz/live", handler.health.LiveHandler())
+		r.Handle("/healthz/ready", handler.health.ReadyHandler())
+	}
 	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("pong"))
 	})
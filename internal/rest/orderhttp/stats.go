@@ -0,0 +1,47 @@
+package orderhttp
+
+import (
+	"net/http"
+
+	"orders-service/internal/app/order"
+	"orders-service/internal/logging"
+)
+
+// OrderStats aggregates the all-orders search by the grouping of group_by.
+func (h *Handler) OrderStats(w http.ResponseWriter, r *http.Request) {
+	var req OrderStatsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := authorizeScope(r, &req.OrderBaseRequest); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := validateOrderStatsRequest(req, h.maxDateRangeDays(req.TenantID)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	req.Cursor = ""
+	f, err := buildGetAllOrdersFilter(req.GetAllOrdersRequest)
+	if err != nil {
+		writeError(w, r, invalidRequest(err))
+		return
+	}
+
+	ctx := order.WithDegradation(r.Context())
+	stats, err := h.service.GetOrderStats(ctx, order.OrderStatsFilter{
+		GetAllOrdersFilter: f,
+		GroupBy:            req.GroupBy,
+	})
+	if err != nil {
+		logging.Error(r.Context(), "order stats failed", err, "group_by", req.GroupBy)
+		writeError(w, r, err)
+		return
+	}
+
+	resp := buildOrderStatsResponse(stats)
+	resp.Degraded = order.Degraded(ctx)
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,193 @@
+package orderhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"orders-service/internal/apperror"
+	"orders-service/internal/logging"
+)
+
+const (
+	defaultStreamHeartbeat = 15 * time.Second
+	streamRetryMS          = 3000
+)
+
+type tabsEventResponse struct {
+	OrdersForSignal map[string][]int64 `json:"ordersForSignal"`
+	OrderCounts     map[string]int     `json:"orderCounts"`
+}
+
+// OrdersStream sends the tab counters of a tenant as Server-Sent Events. An
+// event is sent on connect and whenever the counters or signal lists change; a
+// client reconnecting with Last-Event-ID skips the event it already has.
+func (h *Handler) OrdersStream(w http.ResponseWriter, r *http.Request) {
+	if h.tabs == nil {
+		writeError(w, r, apperror.New(apperror.ErrNotImplemented, "order stream is disabled"))
+		return
+	}
+
+	req, err := parseStreamRequest(r.URL.Query())
+	if err != nil {
+		writeError(w, r, invalidRequest(err))
+		return
+	}
+	if err := authorizeScope(r, &req.OrderBaseRequest); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := validateWarningFullRequest(req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	f := buildWarningFilter(req)
+
+	rc := http.NewResponseController(w)
+	// The server write timeout is meant for regular requests, a stream stays
+	// open until the client goes away.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logging.Warn(r.Context(), "order stream write deadline not cleared", "error", err.Error())
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMS)
+	if err := rc.Flush(); err != nil {
+		logging.Error(r.Context(), "order stream flush failed", err)
+		return
+	}
+
+	sub := h.tabs.Subscribe(f)
+	defer sub.Close()
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	started := time.Now()
+	events := 0
+	defer func() {
+		logging.Info(r.Context(), "order stream closed",
+			"tenant_id", f.BaseFilter.TenantID,
+			"duration_ms", time.Since(started).Milliseconds(),
+			"events", events,
+		)
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case snapshot, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if snapshot.ID == lastEventID {
+				continue
+			}
+			payload, err := json.Marshal(tabsEventResponse{
+				OrdersForSignal: mapStatusGroupIDs(snapshot.OrdersForSignal),
+				OrderCounts:     mapStatusGroupCounts(snapshot.GroupCounts),
+			})
+			if err != nil {
+				logging.Error(r.Context(), "order stream encode failed", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: tabs\ndata: %s\n\n", snapshot.ID, payload); err != nil {
+				return
+			}
+			lastEventID = snapshot.ID
+			events++
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// parseStreamRequest reads the tab filter from the query. The tenant may be
+// omitted when it comes from the token.
+func parseStreamRequest(query url.Values) (WarningFullRequest, error) {
+	var (
+		req WarningFullRequest
+		err error
+	)
+	if raw := query.Get("tenant_id"); raw != "" {
+		if req.TenantID, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return WarningFullRequest{}, errors.New("invalid tenant_id")
+		}
+	}
+	req.Language = query.Get("language")
+
+	lists := []struct {
+		name   string
+		target *[]int64
+	}{
+		{"city_ids", &req.CityIDs},
+		{"tariffs", &req.Tariffs},
+		{"user_positions", &req.UserPositions},
+		{"finished_status", &req.FinishedStatus},
+	}
+	for _, list := range lists {
+		if *list.target, err = parseInt64List(query.Get(list.name)); err != nil {
+			return WarningFullRequest{}, fmt.Errorf("invalid %s", list.name)
+		}
+	}
+
+	ints := []struct {
+		name   string
+		target *int64
+	}{
+		{"bad_rating_max", &req.BadRatingMax},
+		{"status_completed_not_paid", &req.StatusCompletedNotPaid},
+	}
+	for _, value := range ints {
+		raw := query.Get(value.name)
+		if raw == "" {
+			continue
+		}
+		if *value.target, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return WarningFullRequest{}, fmt.Errorf("invalid %s", value.name)
+		}
+	}
+
+	if raw := query.Get("min_real_price"); raw != "" {
+		if req.MinRealPrice, err = strconv.ParseFloat(raw, 64); err != nil {
+			return WarningFullRequest{}, errors.New("invalid min_real_price")
+		}
+	}
+
+	return req, nil
+}
+
+// parseInt64List accepts comma separated ids, e.g. city_ids=1,2,3.
+func parseInt64List(raw string) ([]int64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	result := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		value, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
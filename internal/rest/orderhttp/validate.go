@@ -0,0 +1,260 @@
+package orderhttp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"orders-service/internal/app/order"
+	"orders-service/internal/apperror"
+)
+
+const (
+	maxRequestBodyBytes = 1 << 20
+	maxPageSize         = 500
+	maxListLength       = 1000
+	maxSearchAttributes = 20
+	maxSearchLength     = 255
+
+	defaultMaxDateRangeDays = 31
+)
+
+// fieldErrors collects every invalid field of a request, so the client sees
+// them all at once.
+type fieldErrors []apperror.FieldError
+
+func (e *fieldErrors) add(field, format string, args ...any) {
+	*e = append(*e, apperror.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e fieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return apperror.Fields(e)
+}
+
+func (e *fieldErrors) ids(field string, values []int64) {
+	if len(values) > maxListLength {
+		e.add(field, "must not have more than %d items", maxListLength)
+		return
+	}
+	for _, value := range values {
+		if value <= 0 {
+			e.add(field, "must contain positive ids")
+			return
+		}
+	}
+}
+
+func (e *fieldErrors) nonNegative(field string, value int64) {
+	if value < 0 {
+		e.add(field, "must not be negative")
+	}
+}
+
+// page only caps page_size, a negative page or page size still falls back to
+// the defaults of the handlers.
+func (e *fieldErrors) page(pageSize int) {
+	if pageSize > maxPageSize {
+		e.add("page_size", "must not be greater than %d", maxPageSize)
+	}
+}
+
+// base checks the fields shared by the order lists. It runs after
+// authorizeScope, which fills the tenant from the token.
+func (e *fieldErrors) base(req OrderBaseRequest, sortField func(string) bool) {
+	if req.TenantID <= 0 {
+		e.add("tenant_id", "is required")
+	}
+	e.ids("city_ids", req.CityIDs)
+	e.ids("status", req.Status)
+	e.ids("tariffs", req.Tariffs)
+	e.ids("user_positions", req.UserPositions)
+
+	if req.Date != nil && *req.Date != "" {
+		if _, ok := order.ParseFilterDate(*req.Date); !ok {
+			e.add("date", "must be a date like 2026-03-21 or 21.03.2026")
+		}
+	}
+	if req.StatusTimeFrom != nil {
+		e.nonNegative("status_time_from", *req.StatusTimeFrom)
+	}
+	if req.StatusTimeTo != nil {
+		e.nonNegative("status_time_to", *req.StatusTimeTo)
+	}
+	if req.StatusTimeFrom != nil && req.StatusTimeTo != nil && *req.StatusTimeFrom > *req.StatusTimeTo {
+		e.add("status_time_to", "must not be before status_time_from")
+	}
+
+	e.sort(req.Sort)
+	if req.SortField != "" && !sortField(req.SortField) {
+		e.add("sort_field", "is not a sortable field")
+	}
+	if req.SortOrder != "" && !strings.EqualFold(req.SortOrder, "asc") && !strings.EqualFold(req.SortOrder, "desc") {
+		e.add("sort_order", "must be asc or desc")
+	}
+}
+
+func (e *fieldErrors) sort(keys []SortKeyRequest) {
+	if len(keys) > order.MaxSortKeys {
+		e.add("sort", "must not have more than %d keys", order.MaxSortKeys)
+		return
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		field, ok := order.ParseSortField(key.Field)
+		switch {
+		case !ok:
+			e.add(fmt.Sprintf("sort[%d].field", i), "must be one of %s", strings.Join(order.SortFields(), ", "))
+		case seen[field]:
+			e.add(fmt.Sprintf("sort[%d].field", i), "is already sorted by")
+		}
+		seen[field] = true
+		if key.Order != "" && !strings.EqualFold(key.Order, "asc") && !strings.EqualFold(key.Order, "desc") {
+			e.add(fmt.Sprintf("sort[%d].order", i), "must be asc or desc")
+		}
+	}
+}
+
+func validateWarningFullRequest(req WarningFullRequest) error {
+	var errs fieldErrors
+	errs.base(req.OrderBaseRequest, order.IsSortField)
+	errs.page(req.PageSize)
+	errs.ids("warning_status", req.WarningStatus)
+	errs.ids("finished_status", req.FinishedStatus)
+	errs.nonNegative("status_completed_not_paid", req.StatusCompletedNotPaid)
+	errs.nonNegative("bad_rating_max", req.BadRatingMax)
+	if req.MinRealPrice < 0 {
+		errs.add("min_real_price", "must not be negative")
+	}
+	for _, code := range sortedKeys(req.WarningRules) {
+		rule := req.WarningRules[code]
+		field := "warning_rules." + code
+		if rule.ThresholdMinutes != nil {
+			errs.nonNegative(field+".threshold_minutes", *rule.ThresholdMinutes)
+		}
+		errs.ids(field+".status_ids", rule.StatusIDs)
+	}
+	return errs.err()
+}
+
+func validateGetAllOrdersRequest(req GetAllOrdersRequest, maxRangeDays int) error {
+	var errs fieldErrors
+	errs.getAllOrders(req, maxRangeDays)
+	errs.page(req.PageSize)
+	return errs.err()
+}
+
+func (e *fieldErrors) getAllOrders(req GetAllOrdersRequest, maxRangeDays int) {
+	e.base(req.OrderBaseRequest, order.IsGetAllSortField)
+	e.period(req, maxRangeDays)
+	e.ids("shop_ids", req.ShopIDs)
+
+	if len(req.Attributes) > maxSearchAttributes {
+		e.add("attributes", "must not have more than %d items", maxSearchAttributes)
+	}
+	for i, attribute := range req.Attributes {
+		if strings.TrimSpace(attribute.Attribute) == "" {
+			e.add(fmt.Sprintf("attributes[%d].attribute", i), "is required")
+		}
+		if len(attribute.SearchString) > maxSearchLength {
+			e.add(fmt.Sprintf("attributes[%d].searchString", i), "must not be longer than %d bytes", maxSearchLength)
+		}
+	}
+	if len(req.SearchString) > maxSearchAttributes {
+		e.add("search_string", "must not have more than %d keys", maxSearchAttributes)
+	}
+	for _, key := range sortedKeys(req.SearchString) {
+		if value := req.SearchString[key]; len(value) > maxSearchLength {
+			e.add("search_string."+key, "must not be longer than %d bytes", maxSearchLength)
+		}
+	}
+}
+
+// validateExportOrdersRequest skips the paging fields, the export ignores
+// them.
+func validateExportOrdersRequest(req ExportOrdersRequest, maxRangeDays int) error {
+	var errs fieldErrors
+	errs.getAllOrders(req.GetAllOrdersRequest, maxRangeDays)
+	errs.nonNegative("limit", int64(req.Limit))
+	if len(req.Columns) > maxListLength {
+		errs.add("columns", "must not have more than %d items", maxListLength)
+	}
+	return errs.err()
+}
+
+// validateOrderStatsRequest skips the paging fields, the statistics cover
+// every order of the search.
+func validateOrderStatsRequest(req OrderStatsRequest, maxRangeDays int) error {
+	var errs fieldErrors
+	errs.getAllOrders(req.GetAllOrdersRequest, maxRangeDays)
+	if !order.IsStatsGroup(req.GroupBy) {
+		errs.add("group_by", "must be one of %s", strings.Join(order.StatsGroups(), ", "))
+	}
+	return errs.err()
+}
+
+// validateRefreshWarningsRequest only needs the tenant, the refresh covers
+// every cached warning set of it.
+func validateRefreshWarningsRequest(req OrderBaseRequest) error {
+	var errs fieldErrors
+	if req.TenantID <= 0 {
+		errs.add("tenant_id", "is required")
+	}
+	return errs.err()
+}
+
+func (h *Handler) maxDateRangeDays(tenantID int64) int {
+	if days, ok := h.tenantRanges[tenantID]; ok && days > 0 {
+		return days
+	}
+	return h.maxRangeDays
+}
+
+// period checks date_from/date_to and the time window. Both ends are
+// required, and the range may not be longer than maxRangeDays.
+func (e *fieldErrors) period(req GetAllOrdersRequest, maxRangeDays int) {
+	if req.DateFrom == "" && req.DateTo == "" {
+		if req.TimeFrom != "" || req.TimeTo != "" {
+			e.add("time_from", "requires date_from and date_to")
+		}
+		return
+	}
+
+	from, fromOK := order.ParseFilterDate(req.DateFrom)
+	if !fromOK {
+		e.add("date_from", "must be a date like 2026-03-21 or 21.03.2026")
+	}
+	to, toOK := order.ParseFilterDate(req.DateTo)
+	if !toOK {
+		e.add("date_to", "must be a date like 2026-03-21 or 21.03.2026")
+	}
+	if fromOK && toOK {
+		if to.Before(from) {
+			e.add("date_to", "must not be before date_from")
+		} else if days := order.NewDatePeriod(from, to).Days(); maxRangeDays > 0 && days > maxRangeDays {
+			e.add("date_to", "the range must not be longer than %d days", maxRangeDays)
+		}
+	}
+
+	if req.TimeFrom == "" && req.TimeTo == "" {
+		return
+	}
+	if _, ok := order.ParseClock(req.TimeFrom); !ok {
+		e.add("time_from", "must be a time like 20:00")
+	}
+	if _, ok := order.ParseClock(req.TimeTo); !ok {
+		e.add("time_to", "must be a time like 08:00")
+	}
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
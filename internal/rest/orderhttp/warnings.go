@@ -0,0 +1,46 @@
+package orderhttp
+
+import (
+	"net/http"
+
+	"orders-service/internal/app/order"
+	"orders-service/internal/logging"
+)
+
+// WithWarningCache enables POST /orders/warnings/refresh for the cache the
+// service reads the warning sets from.
+func WithWarningCache(cache *order.WarningCache) Option {
+	return func(h *Handler) {
+		h.warnings = cache
+	}
+}
+
+// RefreshWarnings recomputes the cached warning sets of the tenant and drops
+// its cached responses, so the next poll shows the new warning tab.
+func (h *Handler) RefreshWarnings(w http.ResponseWriter, r *http.Request) {
+	var req OrderBaseRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := authorizeScope(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := validateRefreshWarningsRequest(req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	refreshed, err := h.warnings.Refresh(r.Context(), req.TenantID)
+	if err != nil {
+		logging.Error(r.Context(), "warning refresh failed", err, "tenant_id", req.TenantID)
+		writeError(w, r, err)
+		return
+	}
+	if h.cache != nil {
+		h.cache.Invalidate(req.TenantID)
+	}
+
+	writeJSON(w, http.StatusOK, refreshWarningsResponse{Refreshed: refreshed})
+}